@@ -2,21 +2,25 @@ package asr
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"sync"
 
 	"airecorder/internal/config"
+	"airecorder/internal/logging"
+	"airecorder/internal/tracing"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DiarizationSegment 说话者分离片段
 type DiarizationSegment struct {
-	Start   float32
-	End     float32
-	Speaker int
-	Text    string
+	Start      float32
+	End        float32
+	Speaker    int
+	Text       string
+	Redactions []Redaction // 本片段文本中的脱敏位置，时间为整段音频中的时间
 }
 
 // DiarizationManager 说话者分离管理器
@@ -26,9 +30,9 @@ type DiarizationManager struct {
 	mu          sync.Mutex
 }
 
-// NewDiarizationManager 创建说话者分离管理器
-func NewDiarizationManager(cfg *config.Config) *DiarizationManager {
-	log.Println("Initializing Speaker Diarization Manager...")
+// NewDiarizationManager 创建说话者分离管理器，模型无法加载时返回错误
+func NewDiarizationManager(cfg *config.Config) (*DiarizationManager, error) {
+	slog.Info("Initializing Speaker Diarization Manager...")
 
 	modelsDir := cfg.SpeakerDiarization.ModelsDir
 
@@ -53,15 +57,16 @@ func NewDiarizationManager(cfg *config.Config) *DiarizationManager {
 	// 创建说话者分离器
 	diarization := sherpa.NewOfflineSpeakerDiarization(&diarizationConfig)
 	if diarization == nil {
-		log.Fatal("Failed to create speaker diarization")
+		return nil, fmt.Errorf("failed to create speaker diarization (segmentation: %s, embedding: %s)",
+			diarizationConfig.Segmentation.Pyannote.Model, diarizationConfig.Embedding.Model)
 	}
 
-	log.Println("Speaker Diarization Manager initialized successfully")
+	slog.Info("Speaker Diarization Manager initialized successfully")
 
 	return &DiarizationManager{
 		config:      cfg,
 		diarization: diarization,
-	}
+	}, nil
 }
 
 // Process 处理音频并返回说话者分离片段（不包含文本）
@@ -123,7 +128,7 @@ func (m *DiarizationManager) mergeAdjacentSegments(segments []DiarizationSegment
 	merged = append(merged, current)
 
 	if len(merged) < len(segments) {
-		log.Printf("Merged adjacent segments: %d -> %d", len(segments), len(merged))
+		slog.Debug("Merged adjacent segments", "from", len(segments), "to", len(merged))
 	}
 
 	return merged
@@ -142,7 +147,7 @@ func (m *DiarizationManager) mergeSpeakersIfNeeded(segments []DiarizationSegment
 		return segments // 不需要合并
 	}
 
-	log.Printf("Warning: Detected %d speakers, exceeding maximum %d. Merging speakers...", numSpeakers, maxSpeakers)
+	slog.Warn("Detected more speakers than allowed, merging speakers", "speakers", numSpeakers, "max_speakers", maxSpeakers)
 
 	// 统计每个说话者的信息
 	type speakerStats struct {
@@ -187,8 +192,8 @@ func (m *DiarizationManager) mergeSpeakersIfNeeded(segments []DiarizationSegment
 		speakerId := statsList[i].id
 		speakerMapping[speakerId] = len(mainSpeakers) // 重新编号为 0, 1, 2, ...
 		mainSpeakers = append(mainSpeakers, speakerId)
-		log.Printf("  Keep Speaker %d -> %d (duration: %.2fs, segments: %d)",
-			speakerId, len(mainSpeakers)-1, statsList[i].totalDuration, statsList[i].segmentCount)
+		slog.Debug("Keep speaker", "speaker", speakerId, "to", len(mainSpeakers)-1,
+			"duration_sec", statsList[i].totalDuration, "segments", statsList[i].segmentCount)
 	}
 
 	// 次要说话者（需要合并）
@@ -196,8 +201,8 @@ func (m *DiarizationManager) mergeSpeakersIfNeeded(segments []DiarizationSegment
 		// 将次要说话者轮流分配到主要说话者
 		targetIdx := i % maxSpeakers
 		speakerMapping[statsList[i].id] = targetIdx
-		log.Printf("  Merge Speaker %d -> Speaker %d (duration: %.2fs, segments: %d)",
-			statsList[i].id, targetIdx, statsList[i].totalDuration, statsList[i].segmentCount)
+		slog.Debug("Merge speaker", "speaker", statsList[i].id, "to", targetIdx,
+			"duration_sec", statsList[i].totalDuration, "segments", statsList[i].segmentCount)
 	}
 
 	// 应用映射
@@ -208,14 +213,23 @@ func (m *DiarizationManager) mergeSpeakersIfNeeded(segments []DiarizationSegment
 	// 合并后再次合并相邻片段（因为映射后可能产生新的相邻同说话者片段）
 	segments = m.mergeAdjacentSegments(segments)
 
-	log.Printf("Successfully merged speakers from %d to %d (final segments: %d)", numSpeakers, maxSpeakers, len(segments))
+	slog.Info("Merged speakers", "from", numSpeakers, "to", maxSpeakers, "segments", len(segments))
 	return segments
 }
 
 // ProcessWithASR 处理音频并结合 ASR 识别每个片段，可选传入进度回调 func(total, completed int)
 func (m *DiarizationManager) ProcessWithASR(samples []float32, sampleRate int, asrManager *OfflineASRManager, progressCb ...func(total, completed int)) ([]DiarizationSegment, error) {
+	return m.ProcessWithASROptions(samples, sampleRate, asrManager, RecognizeOptions{}, progressCb...)
+}
+
+// ProcessWithASROptions 处理音频并按指定识别参数识别每个片段，可选传入进度回调 func(total, completed int)
+func (m *DiarizationManager) ProcessWithASROptions(samples []float32, sampleRate int, asrManager *OfflineASRManager, opts RecognizeOptions, progressCb ...func(total, completed int)) ([]DiarizationSegment, error) {
 	// 先进行说话者分离
+	_, span := tracing.Start(opts.Context, "diarization.process",
+		attribute.Float64("audio.duration_sec", float64(len(samples))/float64(sampleRate)))
 	segments, err := m.Process(samples, sampleRate)
+	span.SetAttributes(attribute.Int("diarization.segments", len(segments)))
+	tracing.End(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -246,12 +260,13 @@ func (m *DiarizationManager) ProcessWithASR(samples []float32, sampleRate int, a
 		segmentSamples := samples[startIdx:endIdx]
 
 		// 识别该片段
-		text, err := asrManager.RecognizeSegment(segmentSamples, sampleRate)
+		transcript, err := asrManager.RecognizeDetailed(segmentSamples, sampleRate, opts)
 		if err != nil {
-			log.Printf("Warning: failed to recognize segment %d: %v", i, err)
+			logging.FromContext(opts.Context).Warn("Failed to recognize segment", "segment", i, "error", err)
 			seg.Text = ""
 		} else {
-			seg.Text = text
+			seg.Text = transcript.Text
+			seg.Redactions = shiftRedactions(transcript.Redactions, 0, float32(startIdx)/float32(sampleRate))
 		}
 		if cb != nil {
 			cb(total, i+1)
@@ -266,10 +281,10 @@ func (m *DiarizationManager) Close() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	log.Println("Closing Speaker Diarization Manager...")
+	slog.Info("Closing Speaker Diarization Manager...")
 
 	// 删除说话者分离器
 	sherpa.DeleteOfflineSpeakerDiarization(m.diarization)
 
-	log.Println("Speaker Diarization Manager closed")
+	slog.Info("Speaker Diarization Manager closed")
 }
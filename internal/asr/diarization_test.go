@@ -14,9 +14,9 @@ func TestDiarization(t *testing.T) {
 	}
 
 	// 创建说话者分离管理器
-	diarizationMgr := NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		t.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := NewDiarizationManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
@@ -90,16 +90,16 @@ func TestDiarizationWithASR(t *testing.T) {
 	}
 
 	// 创建说话者分离管理器
-	diarizationMgr := NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		t.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := NewDiarizationManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
 	// 创建离线识别管理器
-	asrMgr := NewOfflineASRManager(cfg)
-	if asrMgr == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	asrMgr, err := NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer asrMgr.Close()
 
@@ -171,9 +171,9 @@ func TestDiarizationSampleRate(t *testing.T) {
 	}
 
 	// 创建说话者分离管理器
-	diarizationMgr := NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		t.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := NewDiarizationManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
@@ -215,9 +215,9 @@ func BenchmarkDiarization(b *testing.B) {
 	}
 
 	// 创建说话者分离管理器
-	diarizationMgr := NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		b.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := NewDiarizationManager(cfg)
+	if err != nil {
+		b.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
@@ -250,15 +250,15 @@ func BenchmarkDiarizationWithASR(b *testing.B) {
 	}
 
 	// 创建管理器
-	diarizationMgr := NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		b.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := NewDiarizationManager(cfg)
+	if err != nil {
+		b.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
-	asrMgr := NewOfflineASRManager(cfg)
-	if asrMgr == nil {
-		b.Fatal("Failed to create offline ASR manager")
+	asrMgr, err := NewOfflineASRManager(cfg)
+	if err != nil {
+		b.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer asrMgr.Close()
 
@@ -0,0 +1,268 @@
+package asr
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"airecorder/internal/config"
+)
+
+// ErrHotwordsDisabled 未启用热词功能
+var ErrHotwordsDisabled = errors.New("hotwords are not enabled")
+
+// Hotword 一条热词及其加分
+type Hotword struct {
+	Phrase string  `json:"phrase"`
+	Boost  float32 `json:"boost,omitempty"` // 为 0 时使用 hotwords.default_boost
+}
+
+// HotwordList 服务端保存的命名热词表
+type HotwordList struct {
+	Name      string    `json:"name"`
+	Hotwords  []Hotword `json:"hotwords"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// HotwordStore 命名热词表存储，修改后写回 store_file
+type HotwordStore struct {
+	path         string
+	defaultBoost float32
+	maxPhrases   int
+	lists        map[string]HotwordList
+	mu           sync.RWMutex
+}
+
+// NewHotwordStore 创建热词表存储，未启用时返回 nil。持久化文件读取失败时以空表启动
+func NewHotwordStore(cfg config.HotwordsConfig) *HotwordStore {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	s := &HotwordStore{
+		path:         cfg.StoreFile,
+		defaultBoost: cfg.DefaultBoost,
+		maxPhrases:   cfg.MaxPhrases,
+		lists:        make(map[string]HotwordList),
+	}
+	if s.defaultBoost <= 0 {
+		s.defaultBoost = 1.5
+	}
+	if s.maxPhrases <= 0 {
+		s.maxPhrases = 200
+	}
+
+	if s.path != "" {
+		data, err := os.ReadFile(s.path)
+		switch {
+		case err == nil:
+			var lists []HotwordList
+			if err := json.Unmarshal(data, &lists); err != nil {
+				slog.Warn("Failed to parse hotword store", "path", s.path, "error", err)
+			}
+			for _, l := range lists {
+				s.lists[l.Name] = l
+			}
+		case !os.IsNotExist(err):
+			slog.Warn("Failed to read hotword store", "path", s.path, "error", err)
+		}
+	}
+
+	slog.Info("Hotword store initialized", "lists", len(s.lists))
+	return s
+}
+
+// List 返回全部命名热词表（按名称排序）
+func (s *HotwordStore) List() []HotwordList {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lists := make([]HotwordList, 0, len(s.lists))
+	for _, l := range s.lists {
+		lists = append(lists, l)
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Name < lists[j].Name })
+	return lists
+}
+
+// Get 获取命名热词表
+func (s *HotwordStore) Get(name string) (HotwordList, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.lists[name]
+	return l, ok
+}
+
+// Put 创建或替换命名热词表并持久化
+func (s *HotwordStore) Put(name string, hotwords []Hotword) (HotwordList, error) {
+	if !IsValidHotwordListName(name) {
+		return HotwordList{}, fmt.Errorf("invalid hotword list name %q", name)
+	}
+	normalized, err := s.normalize(hotwords)
+	if err != nil {
+		return HotwordList{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := HotwordList{
+		Name:      name,
+		Hotwords:  normalized,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+	old, existed := s.lists[name]
+	s.lists[name] = l
+	if err := s.save(); err != nil {
+		if existed {
+			s.lists[name] = old
+		} else {
+			delete(s.lists, name)
+		}
+		return HotwordList{}, err
+	}
+	return l, nil
+}
+
+// Delete 删除命名热词表并持久化，不存在时返回 false
+func (s *HotwordStore) Delete(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.lists[name]
+	if !ok {
+		return false, nil
+	}
+	delete(s.lists, name)
+	if err := s.save(); err != nil {
+		s.lists[name] = old
+		return false, err
+	}
+	return true, nil
+}
+
+// Resolve 合并命名热词表与请求中的热词，返回归一化后的结果；两者都为空时返回 nil
+func (s *HotwordStore) Resolve(listName string, inline []Hotword) ([]Hotword, error) {
+	if listName == "" && len(inline) == 0 {
+		return nil, nil
+	}
+	if s == nil {
+		return nil, ErrHotwordsDisabled
+	}
+
+	var merged []Hotword
+	if listName != "" {
+		l, ok := s.Get(listName)
+		if !ok {
+			return nil, fmt.Errorf("hotword list %q not found", listName)
+		}
+		merged = append(merged, l.Hotwords...)
+	}
+	merged = append(merged, inline...)
+	return s.normalize(merged)
+}
+
+// normalize 去除空白与重复短语（后出现的覆盖先出现的加分），补全默认加分并检查数量
+func (s *HotwordStore) normalize(hotwords []Hotword) ([]Hotword, error) {
+	index := make(map[string]int, len(hotwords))
+	result := make([]Hotword, 0, len(hotwords))
+	for _, h := range hotwords {
+		phrase := strings.Join(strings.Fields(h.Phrase), " ")
+		if phrase == "" {
+			continue
+		}
+		if strings.Contains(phrase, ":") {
+			return nil, fmt.Errorf("hotword %q must not contain ':'", phrase)
+		}
+		if h.Boost < 0 {
+			return nil, fmt.Errorf("hotword %q has negative boost", phrase)
+		}
+		boost := h.Boost
+		if boost == 0 {
+			boost = s.defaultBoost
+		}
+		if i, ok := index[phrase]; ok {
+			result[i].Boost = boost
+			continue
+		}
+		index[phrase] = len(result)
+		result = append(result, Hotword{Phrase: phrase, Boost: boost})
+	}
+	if len(result) > s.maxPhrases {
+		return nil, fmt.Errorf("too many hotwords: %d (max %d)", len(result), s.maxPhrases)
+	}
+	return result, nil
+}
+
+// save 写回持久化文件（调用方需持有写锁）；先写临时文件再重命名，避免写一半
+func (s *HotwordStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	lists := make([]HotwordList, 0, len(s.lists))
+	for _, l := range s.lists {
+		lists = append(lists, l)
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Name < lists[j].Name })
+
+	data, err := json.MarshalIndent(lists, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create hotword store dir: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write hotword store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write hotword store: %w", err)
+	}
+	return nil
+}
+
+// IsValidHotwordListName 热词表名称只允许字母、数字、- 和 _，长度 1-64
+func IsValidHotwordListName(name string) bool {
+	if len(name) == 0 || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// formatHotwords 生成 sherpa-onnx 热词文件内容，每行一个短语，以 " :加分" 结尾
+func formatHotwords(hotwords []Hotword) string {
+	var b strings.Builder
+	for _, h := range hotwords {
+		b.WriteString(h.Phrase)
+		b.WriteString(" :")
+		b.WriteString(strconv.FormatFloat(float64(h.Boost), 'f', -1, 32))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// hotwordsKey 热词内容摘要，用于复用相同热词集合的识别器
+func hotwordsKey(hotwords []Hotword) string {
+	sum := sha1.Sum([]byte(formatHotwords(hotwords)))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,89 @@
+package asr
+
+import (
+	"path/filepath"
+	"testing"
+
+	"airecorder/internal/config"
+)
+
+func newTestHotwordStore(t *testing.T, path string) *HotwordStore {
+	t.Helper()
+	return NewHotwordStore(config.HotwordsConfig{Enabled: true, StoreFile: path, DefaultBoost: 2, MaxPhrases: 3})
+}
+
+func TestHotwordStoreResolve(t *testing.T) {
+	store := newTestHotwordStore(t, "")
+	if _, err := store.Put("products", []Hotword{{Phrase: "AI Recorder", Boost: 3}, {Phrase: "  深度  学习 "}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	got, err := store.Resolve("products", []Hotword{{Phrase: "张三"}, {Phrase: "AI Recorder", Boost: 4}})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	want := []Hotword{{Phrase: "AI Recorder", Boost: 4}, {Phrase: "深度 学习", Boost: 2}, {Phrase: "张三", Boost: 2}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("hotword %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	if _, err := store.Resolve("missing", nil); err == nil {
+		t.Fatal("expected error for unknown list")
+	}
+	if _, err := store.Resolve("", []Hotword{{Phrase: "a"}, {Phrase: "b"}, {Phrase: "c"}, {Phrase: "d"}}); err == nil {
+		t.Fatal("expected error when exceeding max phrases")
+	}
+	if _, err := store.Resolve("", []Hotword{{Phrase: "a :3"}}); err == nil {
+		t.Fatal("expected error for phrase containing ':'")
+	}
+
+	// 未启用热词时只有空请求可以通过
+	var disabled *HotwordStore
+	if got, err := disabled.Resolve("", nil); err != nil || got != nil {
+		t.Fatalf("expected empty result when disabled, got %v, %v", got, err)
+	}
+	if _, err := disabled.Resolve("", []Hotword{{Phrase: "a"}}); err != ErrHotwordsDisabled {
+		t.Fatalf("expected ErrHotwordsDisabled, got %v", err)
+	}
+}
+
+func TestHotwordStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hotwords", "lists.json")
+
+	store := newTestHotwordStore(t, path)
+	if _, err := store.Put("names", []Hotword{{Phrase: "李四", Boost: 1.5}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := store.Put("bad name", []Hotword{{Phrase: "x"}}); err == nil {
+		t.Fatal("expected error for invalid list name")
+	}
+
+	reloaded := newTestHotwordStore(t, path)
+	list, ok := reloaded.Get("names")
+	if !ok || len(list.Hotwords) != 1 || list.Hotwords[0].Phrase != "李四" {
+		t.Fatalf("list not persisted: %+v", list)
+	}
+
+	if deleted, err := reloaded.Delete("names"); err != nil || !deleted {
+		t.Fatalf("Delete failed: %v, %v", deleted, err)
+	}
+	if lists := newTestHotwordStore(t, path).List(); len(lists) != 0 {
+		t.Fatalf("expected empty store after delete, got %v", lists)
+	}
+}
+
+func TestFormatHotwords(t *testing.T) {
+	got := formatHotwords([]Hotword{{Phrase: "HELLO WORLD", Boost: 2}, {Phrase: "语音识别", Boost: 1.5}})
+	want := "HELLO WORLD :2\n语音识别 :1.5\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if hotwordsKey([]Hotword{{Phrase: "a", Boost: 1}}) == hotwordsKey([]Hotword{{Phrase: "a", Boost: 2}}) {
+		t.Fatal("different boosts must produce different keys")
+	}
+}
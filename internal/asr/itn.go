@@ -0,0 +1,544 @@
+package asr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 逆文本正则化（ITN）：把识别结果中的口语化数字转写为书面形式，
+// 例如「二零二五年三月五号」→「2025年3月5号」、「百分之五十」→「50%」、
+// 「twenty five percent」→「25%」。规则偏保守，无法确定含义时保持原文
+
+// ApplyITN 对文本依次执行中文与英文 ITN
+func ApplyITN(text string) string {
+	if text == "" {
+		return text
+	}
+	return itnEnglish(itnChinese(text))
+}
+
+// ---------------- 中文 ----------------
+
+var zhDigits = map[rune]int64{
+	'零': 0, '〇': 0, '幺': 1, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+var zhUnits = map[rune]int64{
+	'十': 10, '百': 100, '千': 1000, '万': 10000, '亿': 100000000,
+}
+
+// zhNumberSuffixes 紧跟这些字时，单个数字也转写（「三月」「五号」「八元」）
+var zhNumberSuffixes = map[rune]bool{
+	'月': true, '日': true, '号': true, '元': true, '块': true, '岁': true, '度': true,
+}
+
+func isZhDigit(r rune) bool { _, ok := zhDigits[r]; return ok }
+func isZhUnit(r rune) bool  { _, ok := zhUnits[r]; return ok }
+
+// itnChinese 扫描连续的中文数字串并按上下文转写
+func itnChinese(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i := 0; i < len(runes); {
+		// 百分之X
+		if hasRunesAt(runes, i, "百分之") {
+			if num, next, ok := zhNumberWithDecimal(runes, i+3); ok {
+				b.WriteString(num)
+				b.WriteByte('%')
+				i = next
+				continue
+			}
+		}
+
+		if !isZhDigit(runes[i]) && runes[i] != '十' {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(runes) && (isZhDigit(runes[end]) || isZhUnit(runes[end])) {
+			end++
+		}
+		run := runes[i:end]
+		value, isDigits, ok := parseZhRun(run)
+		if !ok {
+			b.WriteString(string(run))
+			i = end
+			continue
+		}
+
+		var next rune
+		if end < len(runes) {
+			next = runes[end]
+		}
+
+		// 时间与小数：X点半、X点Y分、X点钟、X点Y
+		if next == '点' {
+			if hour, minute, after, ok := zhTime(runes, value, end); ok {
+				b.WriteString(hour)
+				b.WriteString(minute)
+				i = after
+				continue
+			}
+			// 只有确实带小数部分时才按小数处理，「有一点累」保持原文
+			if num, after, ok := zhNumberWithDecimal(runes, i); ok && after > end {
+				b.WriteString(num)
+				i = after
+				continue
+			}
+		}
+
+		switch {
+		case next == '年' && isDigits && len(run) >= 2:
+			// 年份逐位读：二零二五年
+			b.WriteString(value)
+		case len(run) >= 2 && (!isDigits || len(run) >= 3 || zhNumberSuffixes[next] || next == '年'):
+			b.WriteString(value)
+		case len(run) == 1 && zhNumberSuffixes[next] && run[0] != '两':
+			b.WriteString(value)
+		default:
+			b.WriteString(string(run))
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// parseZhRun 解析中文数字串。纯数字（无十百千万）逐位转写；含单位时按位值计算
+func parseZhRun(run []rune) (value string, isDigits bool, ok bool) {
+	isDigits = true
+	for _, r := range run {
+		if isZhUnit(r) {
+			isDigits = false
+			break
+		}
+	}
+
+	if isDigits {
+		var b strings.Builder
+		for _, r := range run {
+			if r == '两' {
+				// 「两」不用于逐位读数
+				return "", true, false
+			}
+			b.WriteByte(byte('0' + zhDigits[r]))
+		}
+		return b.String(), true, true
+	}
+
+	n, ok := parseZhPositional(run)
+	if !ok {
+		return "", false, false
+	}
+	return strconv.FormatInt(n, 10), false, true
+}
+
+// parseZhPositional 按位值解析「一千二百三十四」「三万五」「十二」等，格式不合法时返回 false
+func parseZhPositional(run []rune) (int64, bool) {
+	var total, section, current, lastUnit int64
+	hasDigit := false // current 中是否有尚未乘以单位的数字
+
+	for i, r := range run {
+		if d, ok := zhDigits[r]; ok {
+			if hasDigit {
+				// 两个数字相连（零除外）不是合法的位值读法，如「一五一十」
+				if run[i-1] != '零' && run[i-1] != '〇' {
+					return 0, false
+				}
+			}
+			if d == 0 {
+				lastUnit = 0
+				hasDigit = true
+				current = 0
+				continue
+			}
+			current = d
+			hasDigit = true
+			continue
+		}
+
+		unit := zhUnits[r]
+		switch {
+		case unit >= 10000:
+			if !hasDigit && section == 0 && total == 0 {
+				return 0, false
+			}
+			if unit == 100000000 {
+				total = (total + section + current) * unit
+			} else {
+				total += (section + current) * unit
+			}
+			section, current = 0, 0
+		default:
+			if !hasDigit {
+				// 仅允许「十」省略前面的「一」
+				if unit != 10 {
+					return 0, false
+				}
+				current = 1
+			}
+			section += current * unit
+			current = 0
+		}
+		lastUnit = unit
+		hasDigit = false
+	}
+
+	// 口语省略末位单位：「三万五」= 35000，「两百五」= 250
+	if hasDigit && current > 0 && lastUnit >= 100 {
+		current *= lastUnit / 10
+	}
+	return total + section + current, true
+}
+
+// zhNumberWithDecimal 从 start 开始解析「整数[点数字]」，返回阿拉伯数字形式
+func zhNumberWithDecimal(runes []rune, start int) (string, int, bool) {
+	end := start
+	for end < len(runes) && (isZhDigit(runes[end]) || isZhUnit(runes[end])) {
+		end++
+	}
+	if end == start {
+		return "", start, false
+	}
+	intPart, _, ok := parseZhRun(runes[start:end])
+	if !ok {
+		return "", start, false
+	}
+
+	if end < len(runes)-1 && runes[end] == '点' && isZhDigit(runes[end+1]) {
+		frac := end + 1
+		var b strings.Builder
+		for frac < len(runes) && isZhDigit(runes[frac]) && runes[frac] != '两' {
+			b.WriteByte(byte('0' + zhDigits[runes[frac]]))
+			frac++
+		}
+		if b.Len() > 0 && (frac >= len(runes) || runes[frac] != '分') {
+			return intPart + "." + b.String(), frac, true
+		}
+	}
+	return intPart, end, true
+}
+
+// zhTime 解析「X点半」「X点Y分」「X点钟」，value 为已转写的小时
+func zhTime(runes []rune, value string, dot int) (string, string, int, bool) {
+	hour, err := strconv.Atoi(value)
+	if err != nil || hour > 24 {
+		return "", "", dot, false
+	}
+	after := dot + 1
+	if after < len(runes) {
+		switch runes[after] {
+		case '半':
+			return value, ":30", after + 1, true
+		case '钟':
+			return value + "点钟", "", after + 1, true
+		}
+	}
+
+	end := after
+	for end < len(runes) && (isZhDigit(runes[end]) || isZhUnit(runes[end])) {
+		end++
+	}
+	if end > after && end < len(runes) && runes[end] == '分' {
+		minute, ok := parseZhPositional(runes[after:end])
+		if !ok {
+			if v, isDigits, ok2 := parseZhRun(runes[after:end]); ok2 && isDigits {
+				minute, _ = strconv.ParseInt(v, 10, 64)
+				ok = true
+			}
+		}
+		if ok && minute < 60 {
+			return value, ":" + twoDigits(minute), end + 1, true
+		}
+	}
+	return "", "", dot, false
+}
+
+func hasRunesAt(runes []rune, i int, s string) bool {
+	target := []rune(s)
+	if i+len(target) > len(runes) {
+		return false
+	}
+	for j, r := range target {
+		if runes[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func twoDigits(n int64) string {
+	if n < 10 {
+		return "0" + strconv.FormatInt(n, 10)
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// ---------------- 英文 ----------------
+
+var enSmall = map[string]int64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11,
+	"twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16,
+	"seventeen": 17, "eighteen": 18, "nineteen": 19,
+}
+
+var enTens = map[string]int64{
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+	"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+var enScales = map[string]int64{
+	"hundred": 100, "thousand": 1000, "million": 1000000, "billion": 1000000000,
+}
+
+var enOrdinals = map[string]int64{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6,
+	"seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10, "eleventh": 11,
+	"twelfth": 12, "thirteenth": 13, "fourteenth": 14, "fifteenth": 15,
+	"sixteenth": 16, "seventeenth": 17, "eighteenth": 18, "nineteenth": 19,
+	"twentieth": 20, "thirtieth": 30,
+}
+
+var enMonths = map[string]bool{
+	"january": true, "february": true, "march": true, "april": true, "may": true, "june": true,
+	"july": true, "august": true, "september": true, "october": true, "november": true, "december": true,
+}
+
+// enToken 英文 ITN 的分词结果：单词或单词之间的原始分隔内容
+type enToken struct {
+	text   string
+	isWord bool
+}
+
+func tokenizeEnglish(text string) []enToken {
+	var tokens []enToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i
+		isWord := isASCIILetter(runes[i])
+		for j < len(runes) && isASCIILetter(runes[j]) == isWord {
+			j++
+		}
+		tokens = append(tokens, enToken{text: string(runes[i:j]), isWord: isWord})
+		i = j
+	}
+	return tokens
+}
+
+func isASCIILetter(r rune) bool {
+	return r < unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+// isJoiner 数字单词之间允许的分隔：空格或连字符
+func isJoiner(t enToken) bool {
+	return !t.isWord && strings.Trim(t.text, " -") == ""
+}
+
+// itnEnglish 转写英文数字短语、百分比、金额、小数与日期序数词
+func itnEnglish(text string) string {
+	tokens := tokenizeEnglish(text)
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i := 0; i < len(tokens); {
+		t := tokens[i]
+		if !t.isWord {
+			b.WriteString(t.text)
+			i++
+			continue
+		}
+
+		lower := strings.ToLower(t.text)
+
+		// 月份 + 序数词：march fifth → march 5
+		if enMonths[lower] && i+2 < len(tokens) && isJoiner(tokens[i+1]) {
+			if day, next, ok := parseEnOrdinal(tokens, i+2); ok {
+				b.WriteString(t.text)
+				b.WriteString(tokens[i+1].text)
+				b.WriteString(strconv.FormatInt(day, 10))
+				i = next
+				continue
+			}
+		}
+
+		value, words, next := parseEnCardinal(tokens, i)
+		if words == 0 {
+			b.WriteString(t.text)
+			i++
+			continue
+		}
+		num := strconv.FormatInt(value, 10)
+
+		// 小数：three point one four
+		if frac, after, ok := parseEnDecimal(tokens, next); ok {
+			num += "." + frac
+			next = after
+			words += 2
+		}
+
+		// 后缀：percent / dollars
+		suffix, after := "", next
+		if next+1 < len(tokens) && isJoiner(tokens[next]) && tokens[next+1].isWord {
+			switch strings.ToLower(tokens[next+1].text) {
+			case "percent":
+				suffix, after = "%", next+2
+			case "dollar", "dollars":
+				suffix, after = "$", next+2
+			}
+		}
+
+		// 单个 zero~nine 不带后缀时多为普通用词（one of them），保持原文
+		if suffix == "" && words == 1 && value < 10 {
+			for _, tok := range tokens[i:next] {
+				b.WriteString(tok.text)
+			}
+			i = next
+			continue
+		}
+
+		switch suffix {
+		case "%":
+			b.WriteString(num + "%")
+		case "$":
+			b.WriteString("$" + num)
+		default:
+			b.WriteString(num)
+		}
+		i = after
+	}
+	return b.String()
+}
+
+// parseEnCardinal 从 start 开始尽可能长地解析基数词，返回数值、使用的单词数与下一个 token 下标
+func parseEnCardinal(tokens []enToken, start int) (int64, int, int) {
+	var total, current int64
+	words := 0
+	next := start
+	// last 记录上一个数字单词的类别，用于拒绝「one two」这类不构成一个数的序列
+	const (
+		none = iota
+		small
+		tens
+		hundred
+		scale
+	)
+	last := none
+
+	for i := start; i < len(tokens); {
+		t := tokens[i]
+		if !t.isWord {
+			break
+		}
+		w := strings.ToLower(t.text)
+
+		switch {
+		case w == "and" && last == hundred:
+			// one hundred and five：and 后必须紧跟数字
+			if i+2 < len(tokens) && isJoiner(tokens[i+1]) && isEnNumberWord(tokens[i+2].text) {
+				i += 2
+				continue
+			}
+			return total + current, words, next
+		case isEnSmall(w):
+			n := enSmall[w]
+			if last == small || (last == tens && (n == 0 || n >= 10)) {
+				return total + current, words, next
+			}
+			current += n
+			last = small
+		case enTens[w] > 0:
+			if last == small || last == tens {
+				return total + current, words, next
+			}
+			current += enTens[w]
+			last = tens
+		case enScales[w] > 0:
+			if words == 0 {
+				return 0, 0, start
+			}
+			s := enScales[w]
+			if s == 100 {
+				if last == hundred || last == scale && current == 0 {
+					return total + current, words, next
+				}
+				current *= 100
+				last = hundred
+			} else {
+				total += current * s
+				current = 0
+				last = scale
+			}
+		default:
+			return total + current, words, next
+		}
+
+		words++
+		next = i + 1
+		// 数字单词之间只允许空格或连字符
+		if i+1 < len(tokens) && isJoiner(tokens[i+1]) {
+			i += 2
+			continue
+		}
+		break
+	}
+	return total + current, words, next
+}
+
+func isEnSmall(w string) bool {
+	_, ok := enSmall[w]
+	return ok
+}
+
+func isEnNumberWord(w string) bool {
+	w = strings.ToLower(w)
+	_, a := enSmall[w]
+	_, b := enTens[w]
+	return a || b
+}
+
+// parseEnDecimal 解析「point 数字…」，小数部分逐位读
+func parseEnDecimal(tokens []enToken, start int) (string, int, bool) {
+	if start+3 >= len(tokens) || !isJoiner(tokens[start]) || strings.ToLower(tokens[start+1].text) != "point" {
+		return "", start, false
+	}
+	var b strings.Builder
+	next := start
+	for i := start + 3; i < len(tokens); i += 2 {
+		if !isJoiner(tokens[i-1]) {
+			break
+		}
+		d, ok := enSmall[strings.ToLower(tokens[i].text)]
+		if !ok || d >= 10 {
+			break
+		}
+		b.WriteByte(byte('0' + d))
+		next = i + 1
+	}
+	if b.Len() == 0 {
+		return "", start, false
+	}
+	return b.String(), next, true
+}
+
+// parseEnOrdinal 解析 1-31 的序数词（fifth、twenty first、thirty-first）
+func parseEnOrdinal(tokens []enToken, start int) (int64, int, bool) {
+	w := strings.ToLower(tokens[start].text)
+	if v, ok := enOrdinals[w]; ok {
+		return v, start + 1, true
+	}
+	if (w == "twenty" || w == "thirty") && start+2 < len(tokens) && isJoiner(tokens[start+1]) {
+		if v, ok := enOrdinals[strings.ToLower(tokens[start+2].text)]; ok && v < 10 {
+			day := enTens[w] + v
+			if day <= 31 {
+				return day, start + 3, true
+			}
+		}
+	}
+	return 0, start, false
+}
@@ -0,0 +1,54 @@
+package asr
+
+import "testing"
+
+func TestApplyITNChinese(t *testing.T) {
+	cases := map[string]string{
+		"二零二五年三月五号":      "2025年3月5号",
+		"百分之五十":          "50%",
+		"百分之三点五":         "3.5%",
+		"一千二百三十四":        "1234",
+		"三万五":            "35000",
+		"两百五十块钱":         "250块钱",
+		"十二月三十一日":        "12月31日",
+		"一百零五个人":         "105个人",
+		"圆周率是三点一四":       "圆周率是3.14",
+		"下午三点半开会":        "下午3:30开会",
+		"三点零五分出发":        "3:05出发",
+		"三点钟":            "3点钟",
+		"电话是一三八零零一三八零零零": "电话是13800138000",
+		// 习语与单字数字保持原文
+		"有一点累": "有一点累",
+		"一模一样": "一模一样",
+		"一五一十": "一五一十",
+		"乱七八糟": "乱七八糟",
+		"一个人":  "一个人",
+	}
+	for in, want := range cases {
+		if got := ApplyITN(in); got != want {
+			t.Errorf("ApplyITN(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApplyITNEnglish(t *testing.T) {
+	cases := map[string]string{
+		"twenty five percent":         "25%",
+		"one hundred and five people": "105 people",
+		"TWO THOUSAND TWENTY FIVE":    "2025",
+		"it costs five dollars":       "it costs $5",
+		"three point one four":        "3.14",
+		"see you on march fifth":      "see you on march 5",
+		"october twenty-first":        "october 21",
+		"twelve apples":               "12 apples",
+		"one of them":                 "one of them",
+		"one two three":               "one two three",
+		"fifty-six thousand":          "56000",
+		"a hundred":                   "a hundred",
+	}
+	for in, want := range cases {
+		if got := ApplyITN(in); got != want {
+			t.Errorf("ApplyITN(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package asr
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"airecorder/internal/config"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+const (
+	// LanguageSourceRequest 语种由请求显式指定
+	LanguageSourceRequest = "request"
+	// LanguageSourceDetected 语种由语种识别模型检测得到
+	LanguageSourceDetected = "detected"
+)
+
+// LanguageResult 语种判定结果
+type LanguageResult struct {
+	Language   string  // 语种代码，例如 zh、en、yue
+	Confidence float32 // 置信度 0-1（多窗口投票占比）
+	Source     string  // request 或 detected
+}
+
+// LanguageIdentifier 语种识别器
+type LanguageIdentifier struct {
+	config *config.Config
+	slid   *sherpa.SpokenLanguageIdentification
+	mu     sync.Mutex
+}
+
+// NewLanguageIdentifier 创建语种识别器，模型加载失败时返回 nil（不影响识别主流程）
+func NewLanguageIdentifier(cfg *config.Config) *LanguageIdentifier {
+	if !cfg.LanguageID.Enabled {
+		return nil
+	}
+
+	slog.Info("Initializing Language Identifier...")
+
+	modelsDir := cfg.LanguageID.ModelsDir
+
+	slidConfig := sherpa.SpokenLanguageIdentificationConfig{}
+	slidConfig.Whisper.Encoder = filepath.Join(modelsDir, cfg.LanguageID.Encoder)
+	slidConfig.Whisper.Decoder = filepath.Join(modelsDir, cfg.LanguageID.Decoder)
+	slidConfig.Whisper.TailPaddings = cfg.LanguageID.TailPaddings
+	slidConfig.NumThreads = cfg.LanguageID.NumThreads
+	if slidConfig.NumThreads <= 0 {
+		slidConfig.NumThreads = 1
+	}
+	slidConfig.Provider = "cpu"
+	slidConfig.Debug = 0
+
+	slid := sherpa.NewSpokenLanguageIdentification(&slidConfig)
+	if slid == nil {
+		slog.Warn("Failed to create language identifier, language routing disabled")
+		return nil
+	}
+
+	slog.Info("Language Identifier initialized successfully")
+
+	return &LanguageIdentifier{
+		config: cfg,
+		slid:   slid,
+	}
+}
+
+// Identify 检测音频语种。从音频中均匀选取若干窗口分别识别，按投票占比给出置信度
+func (l *LanguageIdentifier) Identify(samples []float32, sampleRate int) (LanguageResult, error) {
+	windowSec := l.config.LanguageID.ProbeWindowSec
+	if windowSec <= 0 {
+		windowSec = 10 // Whisper 单次最多处理 30 秒，10 秒足够判断语种
+	}
+	numWindows := l.config.LanguageID.ProbeWindows
+	if numWindows <= 0 {
+		numWindows = 3
+	}
+
+	windows := probeWindows(len(samples), sampleRate*windowSec, numWindows)
+	if len(windows) == 0 {
+		return LanguageResult{}, fmt.Errorf("audio too short for language identification")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	votes := make([]string, 0, len(windows))
+	for _, w := range windows {
+		stream := l.slid.CreateStream()
+		if stream == nil {
+			continue
+		}
+		stream.AcceptWaveform(sampleRate, samples[w[0]:w[1]])
+		result := l.slid.Compute(stream)
+		sherpa.DeleteOfflineStream(stream)
+
+		if result != nil && result.Lang != "" {
+			votes = append(votes, NormalizeLanguage(result.Lang))
+		}
+	}
+
+	if len(votes) == 0 {
+		return LanguageResult{}, fmt.Errorf("language identification returned no result")
+	}
+
+	lang, confidence := voteLanguage(votes, len(windows))
+	return LanguageResult{
+		Language:   lang,
+		Confidence: confidence,
+		Source:     LanguageSourceDetected,
+	}, nil
+}
+
+// Close 释放语种识别模型
+func (l *LanguageIdentifier) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.slid != nil {
+		sherpa.DeleteSpokenLanguageIdentification(l.slid)
+		l.slid = nil
+	}
+}
+
+// NormalizeLanguage 统一语种代码格式（小写、去空白，兼容 zh-CN 这类区域写法）
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if idx := strings.IndexAny(lang, "-_"); idx > 0 {
+		lang = lang[:idx]
+	}
+	return lang
+}
+
+// probeWindows 在音频中均匀选取至多 n 个长度为 size 的窗口，返回 [start, end) 样本区间
+func probeWindows(total, size, n int) [][2]int {
+	if total <= 0 || size <= 0 || n <= 0 {
+		return nil
+	}
+	if total <= size {
+		return [][2]int{{0, total}}
+	}
+
+	// 音频不够放下 n 个完整窗口时减少窗口数
+	if maxWindows := total / size; maxWindows < n {
+		n = maxWindows
+	}
+	if n == 1 {
+		start := (total - size) / 2
+		return [][2]int{{start, start + size}}
+	}
+
+	step := (total - size) / (n - 1)
+	windows := make([][2]int, n)
+	for i := 0; i < n; i++ {
+		start := i * step
+		windows[i] = [2]int{start, start + size}
+	}
+	return windows
+}
+
+// voteLanguage 多数投票，置信度为得票数占窗口总数的比例；票数相同时取先达到该票数的语种
+func voteLanguage(votes []string, total int) (string, float32) {
+	counts := make(map[string]int, len(votes))
+	best, bestCount := "", 0
+	for _, v := range votes {
+		counts[v]++
+		if counts[v] > bestCount {
+			best, bestCount = v, counts[v]
+		}
+	}
+	if total < len(votes) {
+		total = len(votes)
+	}
+	return best, float32(bestCount) / float32(total)
+}
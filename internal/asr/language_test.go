@@ -0,0 +1,93 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	cases := map[string]string{
+		"zh":     "zh",
+		" EN ":   "en",
+		"zh-CN":  "zh",
+		"yue_HK": "yue",
+		"":       "",
+	}
+	for in, want := range cases {
+		if got := NormalizeLanguage(in); got != want {
+			t.Fatalf("NormalizeLanguage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProbeWindows(t *testing.T) {
+	// 短于一个窗口：整段音频作为唯一窗口
+	if got := probeWindows(100, 160, 3); len(got) != 1 || got[0] != [2]int{0, 100} {
+		t.Fatalf("unexpected windows for short audio: %v", got)
+	}
+
+	// 只够放两个完整窗口：窗口数自动减少
+	if got := probeWindows(350, 160, 3); len(got) != 2 || got[0] != [2]int{0, 160} || got[1] != [2]int{190, 350} {
+		t.Fatalf("unexpected windows when audio fits two windows: %v", got)
+	}
+
+	// 足够长：首尾窗口贴边，均匀分布
+	got := probeWindows(1000, 100, 3)
+	want := [][2]int{{0, 100}, {450, 550}, {900, 1000}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d windows, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("window %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	if got := probeWindows(0, 100, 3); got != nil {
+		t.Fatalf("expected no windows for empty audio, got %v", got)
+	}
+}
+
+func TestVoteLanguage(t *testing.T) {
+	lang, conf := voteLanguage([]string{"zh", "en", "zh"}, 3)
+	if lang != "zh" {
+		t.Fatalf("expected zh, got %s", lang)
+	}
+	if conf < 0.66 || conf > 0.67 {
+		t.Fatalf("expected confidence 2/3, got %f", conf)
+	}
+
+	// 有窗口未返回结果时，置信度按窗口总数计算
+	lang, conf = voteLanguage([]string{"yue"}, 2)
+	if lang != "yue" || conf != 0.5 {
+		t.Fatalf("expected yue/0.5, got %s/%f", lang, conf)
+	}
+}
+
+func TestCheckLanguageRequiresRecognizer(t *testing.T) {
+	m := &OfflineASRManager{
+		languageRecognizers: map[string]*sherpa.OfflineRecognizer{"yue": nil},
+		defaultLanguage:     "zh",
+	}
+	for _, lang := range []string{"", "yue", "YUE-HK", "zh", "zh-CN"} {
+		if err := m.CheckLanguage(lang); err != nil {
+			t.Errorf("%q: unexpected error %v", lang, err)
+		}
+	}
+	if err := m.CheckLanguage("en"); err == nil || !strings.Contains(err.Error(), "yue, zh") {
+		t.Fatalf("expected error listing available languages, got %v", err)
+	}
+	// 未启用语种识别时只能识别默认模型的语种
+	if err := (&OfflineASRManager{defaultLanguage: "zh"}).CheckLanguage("zh"); err != nil {
+		t.Fatalf("default language rejected: %v", err)
+	}
+	if err := (&OfflineASRManager{defaultLanguage: "zh"}).CheckLanguage("en"); err == nil {
+		t.Fatal("expected error for a language the default model cannot serve")
+	}
+	// 默认模型语种未知时回退到默认模型
+	if err := (&OfflineASRManager{}).CheckLanguage("en"); err != nil {
+		t.Fatalf("unknown default language should fall back to the default model: %v", err)
+	}
+}
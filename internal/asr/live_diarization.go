@@ -0,0 +1,274 @@
+package asr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"sync"
+
+	"airecorder/internal/config"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// maxSpeakerSegmentSec 计算说话人特征时最多使用每句末尾的音频时长（秒）
+const maxSpeakerSegmentSec = 30
+
+// defaultSpeakerExtractors 未配置 extractors 时的特征提取器数量
+const defaultSpeakerExtractors = 2
+
+// errEmbedderClosed 特征提取器已释放（服务关闭时仍有会话在处理）
+var errEmbedderClosed = errors.New("speaker embedder closed")
+
+// SpeakerEmbedder 说话人特征提取器池。各实时会话从池中取用提取器，
+// 同时到达句末的会话可以并行计算，池空时等待其他会话归还
+type SpeakerEmbedder struct {
+	pool      chan *sherpa.SpeakerEmbeddingExtractor // 空闲的提取器
+	size      int                                    // 提取器总数
+	done      chan struct{}                          // Close 后关闭，之后 Compute 直接返回错误
+	closeOnce sync.Once
+	cfg       config.StreamingDiarizationConfig
+}
+
+// NewSpeakerEmbedder 创建说话人特征提取器，未启用实时说话人分离或创建失败时返回 nil
+func NewSpeakerEmbedder(cfg *config.Config) *SpeakerEmbedder {
+	sd := cfg.SpeakerDiarization
+	if !sd.Streaming.Enabled {
+		return nil
+	}
+
+	model := filepath.Join(sd.ModelsDir, sd.EmbeddingModel)
+	numThreads := sd.NumThreads
+	if numThreads <= 0 {
+		numThreads = 1
+	}
+	size := sd.Streaming.Extractors
+	if size <= 0 {
+		size = defaultSpeakerExtractors
+	}
+
+	e := &SpeakerEmbedder{
+		pool: make(chan *sherpa.SpeakerEmbeddingExtractor, size),
+		done: make(chan struct{}),
+		cfg:  sd.Streaming,
+	}
+	for i := 0; i < size; i++ {
+		extractor := sherpa.NewSpeakerEmbeddingExtractor(&sherpa.SpeakerEmbeddingExtractorConfig{
+			Model:      model,
+			NumThreads: numThreads,
+			Provider:   "cpu",
+		})
+		if extractor == nil {
+			break
+		}
+		e.pool <- extractor
+		e.size++
+	}
+	if e.size == 0 {
+		slog.Warn("Failed to create speaker embedding extractor, live diarization disabled", "model", model)
+		return nil
+	}
+	if e.size < size {
+		slog.Warn("Created fewer speaker embedding extractors than configured", "created", e.size, "configured", size)
+	}
+
+	slog.Info("Live speaker diarization enabled", "threshold", sd.Streaming.Threshold, "max_speakers", sd.Streaming.MaxSpeakers, "extractors", e.size)
+	return e
+}
+
+// Compute 计算一段音频的说话人特征，音频过短或提取器已释放时返回错误
+func (e *SpeakerEmbedder) Compute(samples []float32, sampleRate int) ([]float32, error) {
+	var extractor *sherpa.SpeakerEmbeddingExtractor
+	select {
+	case extractor = <-e.pool:
+	case <-e.done:
+		return nil, errEmbedderClosed
+	}
+	defer func() { e.pool <- extractor }()
+
+	stream := extractor.CreateStream()
+	if stream == nil {
+		return nil, fmt.Errorf("failed to create embedding stream")
+	}
+	defer sherpa.DeleteOnlineStream(stream)
+
+	stream.AcceptWaveform(sampleRate, samples)
+	stream.InputFinished()
+	if !extractor.IsReady(stream) {
+		return nil, fmt.Errorf("segment too short for speaker embedding")
+	}
+	return extractor.Compute(stream), nil
+}
+
+// Close 释放全部提取器，等待使用中的提取器归还；之后的 Compute 返回错误
+func (e *SpeakerEmbedder) Close() {
+	if e == nil {
+		return
+	}
+	e.closeOnce.Do(func() {
+		close(e.done)
+		for i := 0; i < e.size; i++ {
+			sherpa.DeleteSpeakerEmbeddingExtractor(<-e.pool)
+		}
+	})
+}
+
+// SegmentSpeaker 一句结果对应的说话人
+type SegmentSpeaker struct {
+	Segment int `json:"segment"`
+	Speaker int `json:"speaker"`
+}
+
+// speakerCluster 在线聚类中的一个说话人
+type speakerCluster struct {
+	centroid []float32 // 已归一化特征的累加和
+	count    int
+}
+
+// OnlineSpeakerClusterer 会话内的在线说话人聚类：新特征与已有说话人的余弦相似度达到阈值时归入该说话人，
+// 否则创建新说话人（达到 maxSpeakers 后归入最相近的说话人）。保留各句特征，结束时可重新聚类修正标签
+type OnlineSpeakerClusterer struct {
+	threshold   float32
+	maxSpeakers int
+	clusters    []speakerCluster
+	embeddings  [][]float32 // 各句特征（归一化），无特征的句子为 nil
+	labels      []int       // 各句实时给出的说话人，-1 表示未知
+}
+
+// NewOnlineSpeakerClusterer 创建在线聚类器，threshold 为余弦相似度阈值，maxSpeakers 为 0 时不限
+func NewOnlineSpeakerClusterer(threshold float32, maxSpeakers int) *OnlineSpeakerClusterer {
+	if threshold <= 0 {
+		threshold = 0.5
+	}
+	return &OnlineSpeakerClusterer{threshold: threshold, maxSpeakers: maxSpeakers}
+}
+
+// Assign 为新一句的特征分配说话人，emb 为 nil（音频过短等）时记为未知并返回 -1
+func (c *OnlineSpeakerClusterer) Assign(emb []float32) int {
+	if emb == nil {
+		c.embeddings = append(c.embeddings, nil)
+		c.labels = append(c.labels, -1)
+		return -1
+	}
+
+	v := normalize(emb)
+	best, score := c.nearest(v)
+	speaker := best
+	switch {
+	case best >= 0 && score >= c.threshold:
+	case c.maxSpeakers > 0 && len(c.clusters) >= c.maxSpeakers:
+	default:
+		speaker = len(c.clusters)
+		c.clusters = append(c.clusters, speakerCluster{centroid: make([]float32, len(v))})
+	}
+
+	cl := &c.clusters[speaker]
+	for i := range v {
+		cl.centroid[i] += v[i]
+	}
+	cl.count++
+
+	c.embeddings = append(c.embeddings, v)
+	c.labels = append(c.labels, speaker)
+	return speaker
+}
+
+// nearest 与 v 最相近的说话人及余弦相似度，没有说话人时返回 -1
+func (c *OnlineSpeakerClusterer) nearest(v []float32) (int, float32) {
+	best, score := -1, float32(-2)
+	for i, cl := range c.clusters {
+		if s := cosine(cl.centroid, v); s > score {
+			best, score = i, s
+		}
+	}
+	return best, score
+}
+
+// Labels 各句实时给出的说话人（segment 从 1 开始）
+func (c *OnlineSpeakerClusterer) Labels() []SegmentSpeaker {
+	result := make([]SegmentSpeaker, 0, len(c.labels))
+	for i, l := range c.labels {
+		if l >= 0 {
+			result = append(result, SegmentSpeaker{Segment: i + 1, Speaker: l})
+		}
+	}
+	return result
+}
+
+// Refine 用全部特征重新聚类，修正实时标签：先合并中心相似度达到阈值的说话人，
+// 再把每句重新分给最相近的说话人；编号按首次出现的顺序重排
+func (c *OnlineSpeakerClusterer) Refine() []SegmentSpeaker {
+	// 复制中心，按相似度贪心合并
+	centroids := make([][]float32, 0, len(c.clusters))
+	for _, cl := range c.clusters {
+		centroids = append(centroids, append([]float32(nil), cl.centroid...))
+	}
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(centroids) && !merged; i++ {
+			for j := i + 1; j < len(centroids); j++ {
+				if cosine(centroids[i], centroids[j]) >= c.threshold {
+					for k := range centroids[i] {
+						centroids[i][k] += centroids[j][k]
+					}
+					centroids = append(centroids[:j], centroids[j+1:]...)
+					merged = true
+					break
+				}
+			}
+		}
+	}
+
+	// 重新分配并按首次出现顺序编号
+	renumber := make(map[int]int)
+	result := make([]SegmentSpeaker, 0, len(c.embeddings))
+	for i, v := range c.embeddings {
+		if v == nil {
+			continue
+		}
+		best, score := -1, float32(-2)
+		for k, cen := range centroids {
+			if s := cosine(cen, v); s > score {
+				best, score = k, s
+			}
+		}
+		id, ok := renumber[best]
+		if !ok {
+			id = len(renumber)
+			renumber[best] = id
+		}
+		result = append(result, SegmentSpeaker{Segment: i + 1, Speaker: id})
+	}
+	return result
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	n := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	if n == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = x / n
+	}
+	return out
+}
+
+func cosine(a, b []float32) float32 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / math.Sqrt(na*nb))
+}
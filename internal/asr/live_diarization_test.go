@@ -0,0 +1,84 @@
+package asr
+
+import (
+	"errors"
+	"testing"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+func TestOnlineSpeakerClustererAssign(t *testing.T) {
+	c := NewOnlineSpeakerClusterer(0.8, 0)
+	a := []float32{1, 0, 0}
+	b := []float32{0, 1, 0}
+
+	got := []int{
+		c.Assign(a),
+		c.Assign([]float32{0.95, 0.1, 0}),
+		c.Assign(b),
+		c.Assign(nil),
+		c.Assign([]float32{0.1, 0.9, 0.1}),
+	}
+	want := []int{0, 0, 1, -1, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("segment %d: speaker %d, want %d (all: %v)", i+1, got[i], want[i], got)
+		}
+	}
+
+	labels := c.Labels()
+	if len(labels) != 4 || labels[2].Segment != 3 || labels[3].Segment != 5 {
+		t.Fatalf("unexpected labels %+v", labels)
+	}
+}
+
+func TestOnlineSpeakerClustererMaxSpeakers(t *testing.T) {
+	c := NewOnlineSpeakerClusterer(0.9, 2)
+	c.Assign([]float32{1, 0, 0})
+	c.Assign([]float32{0, 1, 0})
+	if got := c.Assign([]float32{0.2, 0, 1}); got != 0 {
+		t.Fatalf("third voice should fall back to the nearest speaker, got %d", got)
+	}
+}
+
+func TestOnlineSpeakerClustererRefine(t *testing.T) {
+	// 同一说话人的前两句特征差异较大，实时分成了两个说话人；第三句归入第二个说话人后
+	// 其中心向第一个说话人靠拢，重新聚类时两者合并
+	c := NewOnlineSpeakerClusterer(0.7, 0)
+	c.Assign([]float32{1, 0})
+	c.Assign([]float32{0.6, 0.8})
+	if got := c.Assign([]float32{0.8, 0.6}); got != 1 {
+		t.Fatalf("third segment should join speaker 1 in real time, got %d", got)
+	}
+	c.Assign([]float32{-1, 0})
+
+	refined := c.Refine()
+	speakers := make(map[int]int)
+	for _, s := range refined {
+		speakers[s.Segment] = s.Speaker
+	}
+	if speakers[1] != speakers[2] || speakers[2] != speakers[3] {
+		t.Fatalf("first three segments should be merged: %+v", refined)
+	}
+	if speakers[4] == speakers[1] {
+		t.Fatalf("opposite voice should stay separate: %+v", refined)
+	}
+	if speakers[1] != 0 || speakers[4] != 1 {
+		t.Fatalf("speakers should be numbered by first appearance: %+v", refined)
+	}
+}
+
+func TestSpeakerEmbedderClosed(t *testing.T) {
+	e := &SpeakerEmbedder{pool: make(chan *sherpa.SpeakerEmbeddingExtractor, 1), done: make(chan struct{})}
+	e.Close()
+	e.Close()
+	if _, err := e.Compute(make([]float32, 16000), 16000); !errors.Is(err, errEmbedderClosed) {
+		t.Fatalf("expected errEmbedderClosed, got %v", err)
+	}
+
+	// 已释放的会话不再计算特征
+	s := &StreamingASRSession{embedder: e, speakers: NewOnlineSpeakerClusterer(0.5, 0), released: true}
+	if speaker, ok := s.AssignSpeaker(); !ok || speaker != -1 {
+		t.Fatalf("expected unknown speaker for released session, got %d (%v)", speaker, ok)
+	}
+}
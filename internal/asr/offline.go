@@ -2,25 +2,41 @@ package asr
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"path/filepath"
+	"log/slog"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"airecorder/internal/config"
+	"airecorder/internal/logging"
+	"airecorder/internal/metrics"
+	"airecorder/internal/tracing"
 
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // OfflineASRManager 离线识别管理器
 type OfflineASRManager struct {
-	config      *config.Config
-	recognizer  *sherpa.OfflineRecognizer
-	punctuation *PunctuationManager
-	mu          sync.Mutex
-	stats       struct {
+	config              *config.Config
+	models              *ModelRegistry
+	ownsModels          bool                                 // 注册表由本管理器创建，关闭时一并释放
+	languageRecognizers map[string]*sherpa.OfflineRecognizer // 语种 -> 专用识别器（由 langMu 保护）
+	defaultLanguage     string                               // language_id.default_language，默认模型识别的语种
+	langMu              sync.RWMutex                         // 解码期间持有读锁，Close 持有写锁释放识别器
+	languageID          *LanguageIdentifier
+	punctuation         *PunctuationManager
+	pipelines           *TextPipelines
+	mu                  sync.Mutex   // 串行化解码，不覆盖识别器的获取与文本后处理
+	chunkDurationSec    atomic.Int64 // offline_asr.chunk_duration_sec，可运行中调整，0 表示默认值
+	maxConcurrency      atomic.Int64 // offline_asr.max_concurrency，可运行中调整，0 表示默认值
+	stats               struct {
 		totalRequests int64
 		totalDuration float64
 		successCount  int64
@@ -28,98 +44,357 @@ type OfflineASRManager struct {
 	}
 }
 
+// RecognizeOptions 单次识别的可选参数
+type RecognizeOptions struct {
+	Model    string      // 指定模型名称，为空时使用默认模型
+	Language string      // 目标语种，未指定模型且命中 language_id.recognizers 时使用对应模型
+	Hotwords []Hotword   // 热词（需 transducer 模型），指定时不使用语种专用模型
+	Text     TextOptions // 文本后处理流水线及阶段开关
+	// Context 发起识别的请求或任务的追踪上下文，解码与后处理的 span 挂在其下，为空时不关联
+	Context context.Context
+}
+
 // NewOfflineASRManager 创建离线识别管理器
-func NewOfflineASRManager(cfg *config.Config) *OfflineASRManager {
-	log.Println("Initializing Offline ASR Manager...")
+func NewOfflineASRManager(cfg *config.Config) (*OfflineASRManager, error) {
+	models := NewModelRegistry(cfg, config.ModelTypeOffline)
+	m, err := NewOfflineASRManagerWithRegistry(cfg, models)
+	if err != nil {
+		models.Close()
+		return nil, err
+	}
+	m.ownsModels = true
+	return m, nil
+}
+
+// NewOfflineASRManagerWithRegistry 使用共享的模型注册表创建离线识别管理器，
+// 默认模型不可用或文本流水线配置错误时返回错误
+func NewOfflineASRManagerWithRegistry(cfg *config.Config, models *ModelRegistry) (*OfflineASRManager, error) {
+	slog.Info("Initializing Offline ASR Manager...")
+
+	// 检查默认识别器
+	name, err := models.ResolveName(config.ModelTypeOffline, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create offline recognizer: %w", err)
+	}
+	slog.Info("Default offline model", "model", name)
+
+	// 创建标点符号管理器
+	punctMgr := NewPunctuationManager(cfg)
+
+	pipelines, err := NewTextPipelines(cfg, punctMgr)
+	if err != nil {
+		punctMgr.Close()
+		return nil, fmt.Errorf("failed to create text pipelines: %w", err)
+	}
+
+	// 按语种加载额外的识别器，加载失败的语种回退到默认模型
+	languageRecognizers := make(map[string]*sherpa.OfflineRecognizer)
+	if cfg.LanguageID.Enabled {
+		for lang, langCfg := range cfg.LanguageID.Recognizers {
+			lang = NormalizeLanguage(lang)
+			rec, err := newOfflineRecognizer(langCfg)
+			if err != nil {
+				slog.Warn("Failed to create offline recognizer for language, falling back to default model", "language", lang, "error", err)
+				continue
+			}
+			languageRecognizers[lang] = rec
+			slog.Info("Offline recognizer for language loaded", "language", lang, "model_type", langCfg.ModelType)
+		}
+	}
+
+	slog.Info("Offline ASR Manager initialized successfully")
+
+	m := &OfflineASRManager{
+		config:              cfg,
+		models:              models,
+		languageRecognizers: languageRecognizers,
+		defaultLanguage:     NormalizeLanguage(cfg.LanguageID.DefaultLanguage),
+		languageID:          NewLanguageIdentifier(cfg),
+		punctuation:         punctMgr,
+		pipelines:           pipelines,
+	}
+	m.SetChunking(cfg.OfflineASR.ChunkDurationSec, cfg.OfflineASR.MaxConcurrency)
+	return m, nil
+}
 
-	// 构建模型路径
-	modelsDir := cfg.OfflineASR.ModelsDir
+// sherpaOfflineModelTypes model_type 与 sherpa-onnx 模型类型提示的对应关系，
+// 未列出的类型留空由 sherpa-onnx 根据已填写的模型自动判断
+var sherpaOfflineModelTypes = map[string]string{
+	config.OfflineModelParaformer:   "paraformer",
+	config.OfflineModelWhisper:      "whisper",
+	config.OfflineModelTransducer:   "transducer",
+	config.OfflineModelNemoCTC:      "nemo_ctc",
+	config.OfflineModelZipformerCTC: "zipformer2_ctc",
+	config.OfflineModelTDNN:         "tdnn",
+}
+
+// newOfflineRecognizer 根据模型配置创建离线识别器，模型文件缺失时返回列出缺失文件的错误
+func newOfflineRecognizer(oc config.OfflineASRConfig) (*sherpa.OfflineRecognizer, error) {
+	if err := oc.Validate(); err != nil {
+		return nil, err
+	}
+	files, _ := oc.ModelFiles()
+	path := make(map[string]string, len(files))
+	for _, f := range files {
+		path[f.Field] = f.Path
+	}
 
 	recognizerConfig := sherpa.OfflineRecognizerConfig{}
 
 	// 根据模型类型配置
-	switch cfg.OfflineASR.ModelType {
-	case "paraformer":
-		// Paraformer uses a single model file
-		modelPath := filepath.Join(modelsDir, cfg.OfflineASR.Encoder)
-		if cfg.OfflineASR.Encoder == "" || cfg.OfflineASR.Encoder == "encoder.onnx" {
-			// Use default model file name
-			modelPath = filepath.Join(modelsDir, "model.int8.onnx")
+	switch oc.ModelType {
+	case config.OfflineModelParaformer:
+		recognizerConfig.ModelConfig.Paraformer.Model = path["model"]
+	case config.OfflineModelWhisper:
+		recognizerConfig.ModelConfig.Whisper.Encoder = path["encoder"]
+		recognizerConfig.ModelConfig.Whisper.Decoder = path["decoder"]
+		recognizerConfig.ModelConfig.Whisper.Language = oc.Language
+		recognizerConfig.ModelConfig.Whisper.Task = oc.Task
+		if recognizerConfig.ModelConfig.Whisper.Task == "" {
+			recognizerConfig.ModelConfig.Whisper.Task = "transcribe"
+		}
+		recognizerConfig.ModelConfig.Whisper.TailPaddings = -1
+	case config.OfflineModelTransducer:
+		recognizerConfig.ModelConfig.Transducer.Encoder = path["encoder"]
+		recognizerConfig.ModelConfig.Transducer.Decoder = path["decoder"]
+		recognizerConfig.ModelConfig.Transducer.Joiner = path["joiner"]
+	case config.OfflineModelSenseVoice:
+		recognizerConfig.ModelConfig.SenseVoice.Model = path["model"]
+		recognizerConfig.ModelConfig.SenseVoice.Language = oc.Language
+		if oc.UseITN {
+			recognizerConfig.ModelConfig.SenseVoice.UseInverseTextNormalization = 1
 		}
-		recognizerConfig.ModelConfig.Paraformer.Model = modelPath
-	case "whisper":
-		// Whisper still uses separate Encoder and Decoder files
-		recognizerConfig.ModelConfig.Whisper.Encoder = filepath.Join(modelsDir, cfg.OfflineASR.Encoder)
-		recognizerConfig.ModelConfig.Whisper.Decoder = filepath.Join(modelsDir, cfg.OfflineASR.Decoder)
-	case "transducer":
-		// Transducer models use encoder, decoder, and joiner
-		recognizerConfig.ModelConfig.Transducer.Encoder = filepath.Join(modelsDir, cfg.OfflineASR.Encoder)
-		recognizerConfig.ModelConfig.Transducer.Decoder = filepath.Join(modelsDir, cfg.OfflineASR.Decoder)
-		// Note: joiner should be added to config if needed
-	}
-
-	recognizerConfig.ModelConfig.Tokens = filepath.Join(modelsDir, cfg.OfflineASR.Tokens)
-	recognizerConfig.ModelConfig.NumThreads = cfg.OfflineASR.NumThreads
+	case config.OfflineModelNemoCTC:
+		recognizerConfig.ModelConfig.NemoCTC.Model = path["model"]
+	case config.OfflineModelZipformerCTC:
+		recognizerConfig.ModelConfig.ZipformerCtc.Model = path["model"]
+	case config.OfflineModelTDNN:
+		recognizerConfig.ModelConfig.Tdnn.Model = path["model"]
+	case config.OfflineModelFireRedASR:
+		recognizerConfig.ModelConfig.FireRedAsr.Encoder = path["encoder"]
+		recognizerConfig.ModelConfig.FireRedAsr.Decoder = path["decoder"]
+	case config.OfflineModelMoonshine:
+		recognizerConfig.ModelConfig.Moonshine.Preprocessor = path["preprocessor"]
+		recognizerConfig.ModelConfig.Moonshine.Encoder = path["encoder"]
+		recognizerConfig.ModelConfig.Moonshine.UncachedDecoder = path["uncached_decoder"]
+		recognizerConfig.ModelConfig.Moonshine.CachedDecoder = path["cached_decoder"]
+	}
+
+	recognizerConfig.ModelConfig.Tokens = path["tokens"]
+	recognizerConfig.ModelConfig.NumThreads = oc.NumThreads
 	recognizerConfig.ModelConfig.Provider = "cpu"
 	recognizerConfig.ModelConfig.Debug = 0
-	recognizerConfig.ModelConfig.ModelType = cfg.OfflineASR.ModelType
+	recognizerConfig.ModelConfig.ModelType = sherpaOfflineModelTypes[oc.ModelType]
+
+	recognizerConfig.ModelConfig.ModelingUnit = oc.ModelingUnit
+	recognizerConfig.ModelConfig.BpeVocab = config.ResolveModelPath(oc.ModelsDir, oc.BpeVocab)
 
 	// 解码配置
-	recognizerConfig.DecodingMethod = cfg.OfflineASR.DecodingMethod
-	recognizerConfig.MaxActivePaths = cfg.OfflineASR.MaxActivePaths
+	recognizerConfig.DecodingMethod = oc.DecodingMethod
+	recognizerConfig.MaxActivePaths = oc.MaxActivePaths
+
+	// 热词仅对 transducer 生效
+	if oc.SupportsHotwords() {
+		recognizerConfig.HotwordsFile = config.ResolveModelPath(oc.ModelsDir, oc.HotwordsFile)
+		recognizerConfig.HotwordsScore = oc.HotwordsScore
+	}
 
-	// 创建识别器
 	recognizer := sherpa.NewOfflineRecognizer(&recognizerConfig)
 	if recognizer == nil {
-		log.Fatal("Failed to create offline recognizer")
+		return nil, fmt.Errorf("sherpa-onnx failed to create recognizer (model_type=%s)", oc.ModelType)
 	}
+	return recognizer, nil
+}
 
-	log.Println("Offline ASR Manager initialized successfully")
+// ResolveLanguage 确定本次识别使用的语种：请求显式指定优先，否则调用语种识别模型检测。
+// 两者都不可用时返回空结果，使用默认模型识别。指定模型或热词时不使用语种专用模型，调用方应跳过检测
+func (m *OfflineASRManager) ResolveLanguage(samples []float32, sampleRate int, requested string) LanguageResult {
+	return m.ResolveLanguageContext(context.Background(), samples, sampleRate, requested)
+}
 
-	// 创建标点符号管理器
-	punctMgr := NewPunctuationManager(cfg)
+// ResolveLanguageContext 同 ResolveLanguage，检测日志使用 ctx 中的 logger（带请求或任务 ID）
+func (m *OfflineASRManager) ResolveLanguageContext(ctx context.Context, samples []float32, sampleRate int, requested string) LanguageResult {
+	if lang := NormalizeLanguage(requested); lang != "" {
+		return LanguageResult{Language: lang, Confidence: 1, Source: LanguageSourceRequest}
+	}
+
+	if m.languageID == nil {
+		return LanguageResult{}
+	}
+
+	result, err := m.languageID.Identify(samples, sampleRate)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Language identification failed", "error", err)
+		return LanguageResult{}
+	}
+
+	logging.FromContext(ctx).Info("Detected language", "language", result.Language, "confidence", result.Confidence)
+	return result
+}
+
+// CheckLanguage 检查请求指定的语种能否识别，未指定时返回 nil。
+// 配置了专用识别器的语种与默认模型的语种（language_id.default_language）可以识别；
+// 未配置 default_language 时默认模型的语种未知，其余语种同样回退到默认模型
+func (m *OfflineASRManager) CheckLanguage(requested string) error {
+	lang := NormalizeLanguage(requested)
+	if lang == "" || m.defaultLanguage == "" || lang == m.defaultLanguage {
+		return nil
+	}
+	m.langMu.RLock()
+	defer m.langMu.RUnlock()
+	if _, ok := m.languageRecognizers[lang]; ok {
+		return nil
+	}
+	languages := []string{m.defaultLanguage}
+	for l := range m.languageRecognizers {
+		if l != m.defaultLanguage {
+			languages = append(languages, l)
+		}
+	}
+	sort.Strings(languages)
+	return fmt.Errorf("no recognizer configured for language %q, available: %s", lang, strings.Join(languages, ", "))
+}
+
+// ResolveModel 校验模型名称并返回实际使用的模型名称，name 为空时返回默认模型
+func (m *OfflineASRManager) ResolveModel(name string) (string, error) {
+	return m.models.ResolveName(config.ModelTypeOffline, name)
+}
+
+// ResolvePipeline 检查文本后处理流水线是否存在，为空表示默认流水线
+func (m *OfflineASRManager) ResolvePipeline(name string) error {
+	_, err := m.pipelines.Get(name)
+	return err
+}
+
+// ResolveHotwords 合并命名热词表与请求中的热词，并检查所选模型是否支持热词
+func (m *OfflineASRManager) ResolveHotwords(model, listName string, inline []Hotword) ([]Hotword, error) {
+	hotwords, err := m.models.ResolveHotwords(listName, inline)
+	if err != nil || len(hotwords) == 0 {
+		return nil, err
+	}
+	if err := m.models.CheckHotwords(config.ModelTypeOffline, model); err != nil {
+		return nil, err
+	}
+	return hotwords, nil
+}
+
+// ModelName 返回按给定参数识别时实际使用的模型名称，语种专用模型记为 language:<语种>
+func (m *OfflineASRManager) ModelName(opts RecognizeOptions) string {
+	if opts.Model == "" && len(opts.Hotwords) == 0 {
+		lang := NormalizeLanguage(opts.Language)
+		m.langMu.RLock()
+		_, ok := m.languageRecognizers[lang]
+		m.langMu.RUnlock()
+		if ok {
+			return "language:" + lang
+		}
+	}
+	name, _ := m.ResolveModel(opts.Model)
+	return name
+}
+
+// acquireRecognizer 选择并占用识别器：指定模型优先，其次是语种专用模型，最后是默认模型。
+// 同时返回实际使用的模型名称（同 ModelName），语种专用模型在 release 之前一直持有 langMu 读锁，
+// 调用方不能再调用 ModelName 等获取 langMu 的方法。
+// 返回的 release 必须在解码结束后调用，热加载替换下来的旧模型要等引用释放后才会销毁。
+// 首次使用的热词集合需要加载模型，调用方不能持有 m.mu
+func (m *OfflineASRManager) acquireRecognizer(opts RecognizeOptions) (*sherpa.OfflineRecognizer, string, func(), error) {
+	if opts.Model == "" && len(opts.Hotwords) == 0 {
+		lang := NormalizeLanguage(opts.Language)
+		m.langMu.RLock()
+		if rec, ok := m.languageRecognizers[lang]; ok {
+			return rec, "language:" + lang, m.langMu.RUnlock, nil
+		}
+		m.langMu.RUnlock()
+	}
 
-	return &OfflineASRManager{
-		config:      cfg,
-		recognizer:  recognizer,
-		punctuation: punctMgr,
+	handle, err := m.models.AcquireOfflineWithHotwords(opts.Model, opts.Hotwords)
+	if err != nil {
+		return nil, "", nil, err
 	}
+	return handle.offline, handle.name, handle.Release, nil
 }
 
 // Recognize 识别音频
 func (m *OfflineASRManager) Recognize(samples []float32, sampleRate int) (string, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.RecognizeWithOptions(samples, sampleRate, RecognizeOptions{})
+}
+
+// Transcript 识别结果文本及脱敏位置
+type Transcript struct {
+	Text       string
+	Redactions []Redaction // redact 阶段生效时的脱敏位置，时间相对于输入音频起点
+}
+
+// RecognizeWithOptions 按指定参数识别音频
+func (m *OfflineASRManager) RecognizeWithOptions(samples []float32, sampleRate int, opts RecognizeOptions) (string, error) {
+	transcript, err := m.RecognizeDetailed(samples, sampleRate, opts)
+	return transcript.Text, err
+}
+
+// RecognizeDetailed 按指定参数识别音频，同时返回脱敏位置
+func (m *OfflineASRManager) RecognizeDetailed(samples []float32, sampleRate int, opts RecognizeOptions) (transcript Transcript, err error) {
+	ctx, span := tracing.Start(opts.Context, "asr.decode",
+		attribute.Float64("audio.duration_sec", float64(len(samples))/float64(sampleRate)))
+	defer func() { tracing.End(span, err) }()
+	opts.Context = ctx
 
 	atomic.AddInt64(&m.stats.totalRequests, 1)
 
+	recognizer, model, release, err := m.acquireRecognizer(opts)
+	if err != nil {
+		atomic.AddInt64(&m.stats.failureCount, 1)
+		return Transcript{}, err
+	}
+	defer release()
+
+	result, err := m.decode(recognizer, model, samples, sampleRate)
+	if err != nil {
+		atomic.AddInt64(&m.stats.failureCount, 1)
+		return Transcript{}, err
+	}
+
+	atomic.AddInt64(&m.stats.successCount, 1)
+
+	return m.transcript(result, float32(len(samples))/float32(sampleRate), opts), nil
+}
+
+// decode 解码一段音频，只在解码期间持有 m.mu，并按模型记录音频时长与解码实时率
+func (m *OfflineASRManager) decode(recognizer *sherpa.OfflineRecognizer, model string, samples []float32, sampleRate int) (*sherpa.OfflineRecognizerResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// 创建流
-	stream := sherpa.NewOfflineStream(m.recognizer)
+	stream := sherpa.NewOfflineStream(recognizer)
 	if stream == nil {
-		atomic.AddInt64(&m.stats.failureCount, 1)
-		return "", fmt.Errorf("failed to create stream")
+		return nil, fmt.Errorf("failed to create stream")
 	}
 	defer sherpa.DeleteOfflineStream(stream)
 
-	// 接受音频数据
+	// 接受音频数据并解码，不计入等待锁的时间
+	begin := time.Now()
 	stream.AcceptWaveform(sampleRate, samples)
-
-	// 解码
-	m.recognizer.Decode(stream)
+	recognizer.Decode(stream)
+	elapsed := time.Since(begin)
 
 	// 获取结果
 	result := stream.GetResult()
 	if result == nil {
-		atomic.AddInt64(&m.stats.failureCount, 1)
-		return "", fmt.Errorf("failed to get recognition result")
+		return nil, fmt.Errorf("failed to get recognition result")
 	}
+	if seconds := float64(len(samples)) / float64(sampleRate); seconds > 0 {
+		metrics.AudioSeconds.Add(seconds, "offline", model)
+		metrics.DecodeRTF.Observe(elapsed.Seconds()/seconds, "offline", model)
+	}
+	return result, nil
+}
 
-	atomic.AddInt64(&m.stats.successCount, 1)
-
-	// 添加标点符号
-	textWithPunct := m.punctuation.AddPunctuation(result.Text)
-
-	return textWithPunct, nil
+// transcript 执行文本后处理流水线（标点、ITN 等），并按 token 时间戳估计脱敏内容的时间
+func (m *OfflineASRManager) transcript(result *sherpa.OfflineRecognizerResult, duration float32, opts RecognizeOptions) Transcript {
+	text := m.pipelines.ProcessContext(opts.Context, result.Text, opts.Text)
+	spans := m.pipelines.Redactions(text, opts.Text)
+	alignRedactions(spans, text, result.Tokens, result.Timestamps, duration)
+	return Transcript{Text: text, Redactions: spans}
 }
 
 // RecognizeSegment 识别音频片段（用于说话者分离）
@@ -127,10 +402,26 @@ func (m *OfflineASRManager) RecognizeSegment(samples []float32, sampleRate int)
 	return m.Recognize(samples, sampleRate)
 }
 
+// RecognizeSegmentWithOptions 按指定参数识别音频片段（用于说话者分离）
+func (m *OfflineASRManager) RecognizeSegmentWithOptions(samples []float32, sampleRate int, opts RecognizeOptions) (string, error) {
+	return m.RecognizeWithOptions(samples, sampleRate, opts)
+}
+
 // RecognizeChunked 分块识别长音频，可选传入进度回调 func(total, completed int)
 func (m *OfflineASRManager) RecognizeChunked(samples []float32, sampleRate int, progressCb ...func(total, completed int)) (string, error) {
+	return m.RecognizeChunkedWithOptions(samples, sampleRate, RecognizeOptions{}, progressCb...)
+}
+
+// RecognizeChunkedWithOptions 按指定参数分块识别长音频，可选传入进度回调 func(total, completed int)
+func (m *OfflineASRManager) RecognizeChunkedWithOptions(samples []float32, sampleRate int, opts RecognizeOptions, progressCb ...func(total, completed int)) (string, error) {
+	transcript, err := m.RecognizeChunkedDetailed(samples, sampleRate, opts, progressCb...)
+	return transcript.Text, err
+}
+
+// RecognizeChunkedDetailed 分块识别长音频，同时返回脱敏位置（已平移到合并后的文本与整段音频中）
+func (m *OfflineASRManager) RecognizeChunkedDetailed(samples []float32, sampleRate int, opts RecognizeOptions, progressCb ...func(total, completed int)) (Transcript, error) {
 	// 获取分块时长配置（默认60秒，提高处理效率）
-	chunkDurationSec := m.config.OfflineASR.ChunkDurationSec
+	chunkDurationSec := int(m.chunkDurationSec.Load())
 	if chunkDurationSec <= 0 {
 		chunkDurationSec = 60 // 使用60秒的块，大幅提高效率
 	}
@@ -141,11 +432,18 @@ func (m *OfflineASRManager) RecognizeChunked(samples []float32, sampleRate int,
 
 	// 如果音频短于分块大小，直接识别
 	if totalSamples <= chunkSize {
-		return m.Recognize(samples, sampleRate)
+		return m.RecognizeDetailed(samples, sampleRate, opts)
 	}
 
-	log.Printf("[ChunkedASR] Starting chunked recognition: total_duration=%.2fs, chunk_duration=%ds, estimated_chunks=%d",
-		totalDuration, chunkDurationSec, (totalSamples+chunkSize-1)/chunkSize)
+	logger := logging.FromContext(opts.Context).With("component", "ChunkedASR")
+	logger.Info("Starting chunked recognition", "duration_sec", totalDuration,
+		"chunk_duration_sec", chunkDurationSec, "chunks", (totalSamples+chunkSize-1)/chunkSize)
+
+	spanCtx, span := tracing.Start(opts.Context, "asr.recognize_chunked",
+		attribute.Float64("audio.duration_sec", totalDuration),
+		attribute.Int("asr.chunks", (totalSamples+chunkSize-1)/chunkSize))
+	defer span.End()
+	opts.Context = spanCtx
 
 	// 动态计算超时时间：基础时间 + 音频时长的3倍（考虑处理开销）
 	// 最小30分钟，最大从配置读取（默认120分钟）
@@ -162,26 +460,27 @@ func (m *OfflineASRManager) RecognizeChunked(samples []float32, sampleRate int,
 		totalTimeout = maxTimeout
 	}
 
-	log.Printf("[ChunkedASR] Setting processing timeout: %.2f minutes (audio: %.2f min, max: %d min)",
-		totalTimeout.Minutes(), totalDuration/60, maxTimeoutMin)
-	ctx, cancel := context.WithTimeout(context.Background(), totalTimeout)
+	logger.Debug("Chunked recognition timeout",
+		"timeout_min", totalTimeout.Minutes(), "audio_min", totalDuration/60, "max_min", maxTimeoutMin)
+	// 调用方取消 opts.Context 时（如任务被取消）在当前分块结束后停止
+	ctx, cancel := context.WithTimeout(spanCtx, totalTimeout)
 	defer cancel()
 
 	// 使用goroutine池并行处理，提高效率
 	maxWorkers := 4 // 默认使用4个worker，加快处理速度
-	if m.config.OfflineASR.MaxConcurrency > 0 {
-		maxWorkers = m.config.OfflineASR.MaxConcurrency
+	if n := int(m.maxConcurrency.Load()); n > 0 {
+		maxWorkers = n
 	}
 
 	type chunkResult struct {
-		index int
-		text  string
-		err   error
+		index      int
+		transcript Transcript
+		err        error
 	}
 
 	// 计算总块数
 	numChunks := (totalSamples + chunkSize - 1) / chunkSize
-	results := make([]string, numChunks)
+	results := make([]Transcript, numChunks)
 	resultChan := make(chan chunkResult, numChunks)
 	workChan := make(chan int, numChunks)
 
@@ -195,7 +494,11 @@ func (m *OfflineASRManager) RecognizeChunked(samples []float32, sampleRate int,
 				// 检查是否超时
 				select {
 				case <-ctx.Done():
-					log.Printf("[ChunkedASR] Worker %d stopped due to timeout", workerID)
+					if errors.Is(ctx.Err(), context.Canceled) {
+						resultChan <- chunkResult{index: chunkIndex, err: fmt.Errorf("processing cancelled")}
+						return
+					}
+					logger.Warn("Chunk worker stopped due to timeout", "worker", workerID)
 					resultChan <- chunkResult{index: chunkIndex, err: fmt.Errorf("processing timeout")}
 					return
 				default:
@@ -211,16 +514,17 @@ func (m *OfflineASRManager) RecognizeChunked(samples []float32, sampleRate int,
 				progress := float64(chunkIndex+1) / float64(numChunks) * 100
 
 				if chunkIndex%5 == 0 { // 每5个块输出一次日志
-					log.Printf("[ChunkedASR] Worker %d processing chunk %d/%d: offset=%.2fs, duration=%.2fs, progress=%.1f%%",
-						workerID, chunkIndex+1, numChunks, float64(offset)/float64(sampleRate), chunkDur, progress)
+					logger.Debug("Processing chunk", "worker", workerID,
+						"chunk", chunkIndex+1, "chunks", numChunks, "offset_sec", float64(offset)/float64(sampleRate),
+						"duration_sec", chunkDur, "progress", progress)
 				}
 
 				// 提取当前块
 				chunk := samples[offset:end]
 
 				// 识别当前块（每个worker有自己的锁，减少竞争）
-				text, err := m.recognizeChunkWithCleanup(chunk, sampleRate, chunkIndex+1)
-				resultChan <- chunkResult{index: chunkIndex, text: text, err: err}
+				transcript, err := m.recognizeChunkWithCleanup(chunk, sampleRate, chunkIndex+1, opts)
+				resultChan <- chunkResult{index: chunkIndex, transcript: transcript, err: err}
 			}
 		}(w)
 	}
@@ -252,67 +556,69 @@ func (m *OfflineASRManager) RecognizeChunked(samples []float32, sampleRate int,
 			cb(numChunks, completedChunks)
 		}
 		if result.err != nil {
-			log.Printf("[ChunkedASR] Warning: chunk %d failed: %v", result.index+1, result.err)
+			logger.Warn("Chunk failed", "chunk", result.index+1, "error", result.err)
 			failedChunks++
 		} else {
-			results[result.index] = result.text
+			results[result.index] = result.transcript
 		}
 	}
 
-	// 合并所有结果
+	// 合并所有结果，脱敏位置平移到合并后的文本与整段音频中
 	var fullText string
-	for i, text := range results {
+	var redactions []Redaction
+	for i, transcript := range results {
+		text := transcript.Text
 		if text != "" {
 			if fullText != "" {
 				fullText += " "
 			}
+			chunkOffset := float32(i*chunkSize) / float32(sampleRate)
+			redactions = append(redactions, shiftRedactions(transcript.Redactions, utf8.RuneCountInString(fullText), chunkOffset)...)
 			fullText += text
 		} else if i < len(results)-1 { // 不是最后一块但为空，可能失败了
-			log.Printf("[ChunkedASR] Warning: chunk %d has no text", i+1)
+			logger.Warn("Chunk has no text", "chunk", i+1)
 		}
 	}
 
-	log.Printf("[ChunkedASR] Completed: total_chunks=%d, failed_chunks=%d, result_length=%d chars",
-		numChunks, failedChunks, len(fullText))
+	logger.Info("Chunked recognition completed", "chunks", numChunks, "failed_chunks", failedChunks,
+		"result_chars", utf8.RuneCountInString(fullText))
 
+	span.SetAttributes(attribute.Int("asr.failed_chunks", failedChunks))
+	if errors.Is(ctx.Err(), context.Canceled) && failedChunks > 0 {
+		err := fmt.Errorf("processing cancelled")
+		span.SetStatus(codes.Error, err.Error())
+		return Transcript{}, err
+	}
 	if fullText == "" && failedChunks > 0 {
-		return "", fmt.Errorf("all chunks failed to recognize")
+		err := fmt.Errorf("all chunks failed to recognize")
+		span.SetStatus(codes.Error, err.Error())
+		return Transcript{}, err
 	}
 
-	return fullText, nil
+	return Transcript{Text: fullText, Redactions: redactions}, nil
 }
 
 // recognizeChunkWithCleanup 识别单个块并确保资源清理
-func (m *OfflineASRManager) recognizeChunkWithCleanup(samples []float32, sampleRate int, chunkID int) (string, error) {
-	// 不使用全局锁，让多个块可以并发处理（如果需要的话）
-	// 但由于 sherpa-onnx 的线程安全性，这里还是用锁
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// 创建流
-	stream := sherpa.NewOfflineStream(m.recognizer)
-	if stream == nil {
-		return "", fmt.Errorf("failed to create stream for chunk %d", chunkID)
+func (m *OfflineASRManager) recognizeChunkWithCleanup(samples []float32, sampleRate int, chunkID int, opts RecognizeOptions) (transcript Transcript, err error) {
+	ctx, span := tracing.Start(opts.Context, "asr.decode_chunk",
+		attribute.Int("asr.chunk", chunkID),
+		attribute.Float64("audio.duration_sec", float64(len(samples))/float64(sampleRate)))
+	defer func() { tracing.End(span, err) }()
+	opts.Context = ctx
+
+	recognizer, model, release, err := m.acquireRecognizer(opts)
+	if err != nil {
+		return Transcript{}, err
 	}
-	// 确保流被释放
-	defer sherpa.DeleteOfflineStream(stream)
+	defer release()
 
-	// 接受音频数据
-	stream.AcceptWaveform(sampleRate, samples)
-
-	// 解码
-	m.recognizer.Decode(stream)
-
-	// 获取结果
-	result := stream.GetResult()
-	if result == nil {
-		return "", fmt.Errorf("failed to get recognition result for chunk %d", chunkID)
+	// 由于 sherpa-onnx 的线程安全性，解码仍然串行，识别器获取与文本后处理可以并发
+	result, err := m.decode(recognizer, model, samples, sampleRate)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("chunk %d: %w", chunkID, err)
 	}
 
-	// 添加标点符号
-	textWithPunct := m.punctuation.AddPunctuation(result.Text)
-
-	return textWithPunct, nil
+	return m.transcript(result, float32(len(samples))/float32(sampleRate), opts), nil
 }
 
 // GetMaxFileSizeMB 获取最大文件大小配置（MB）
@@ -325,12 +631,23 @@ func (m *OfflineASRManager) GetMaxFileSizeMB() int {
 
 // GetChunkDurationSec 获取分块时长配置（秒）
 func (m *OfflineASRManager) GetChunkDurationSec() int {
-	if m.config.OfflineASR.ChunkDurationSec > 0 {
-		return m.config.OfflineASR.ChunkDurationSec
+	if n := int(m.chunkDurationSec.Load()); n > 0 {
+		return n
 	}
 	return 30 // 默认30秒
 }
 
+// SetChunking 调整长音频的分块时长（秒）与并行处理的分块数，对之后开始的识别生效，0 表示默认值
+func (m *OfflineASRManager) SetChunking(chunkDurationSec, maxConcurrency int) {
+	m.chunkDurationSec.Store(int64(chunkDurationSec))
+	m.maxConcurrency.Store(int64(maxConcurrency))
+}
+
+// Chunking 当前的分块时长与并行分块数（0 表示默认值）
+func (m *OfflineASRManager) Chunking() (chunkDurationSec, maxConcurrency int) {
+	return int(m.chunkDurationSec.Load()), int(m.maxConcurrency.Load())
+}
+
 // GetStats 获取统计信息
 func (m *OfflineASRManager) GetStats() map[string]interface{} {
 	return map[string]interface{}{
@@ -342,18 +659,32 @@ func (m *OfflineASRManager) GetStats() map[string]interface{} {
 
 // Close 关闭管理器
 func (m *OfflineASRManager) Close() {
+	// 锁顺序与解码一致：langMu -> mu，等待使用中的解码结束
+	m.langMu.Lock()
+	defer m.langMu.Unlock()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	log.Println("Closing Offline ASR Manager...")
+	slog.Info("Closing Offline ASR Manager...")
 
 	// 关闭标点符号管理器
 	if m.punctuation != nil {
 		m.punctuation.Close()
 	}
 
+	// 关闭语种识别器
+	if m.languageID != nil {
+		m.languageID.Close()
+	}
+
 	// 删除识别器
-	sherpa.DeleteOfflineRecognizer(m.recognizer)
+	for lang, rec := range m.languageRecognizers {
+		sherpa.DeleteOfflineRecognizer(rec)
+		delete(m.languageRecognizers, lang)
+	}
+	if m.ownsModels {
+		m.models.Close()
+	}
 
-	log.Println("Offline ASR Manager closed")
+	slog.Info("Offline ASR Manager closed")
 }
@@ -25,9 +25,9 @@ func TestOfflineASR(t *testing.T) {
 	}
 
 	// 创建离线识别管理器
-	manager := NewOfflineASRManager(cfg)
-	if manager == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	manager, err := NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -86,9 +86,9 @@ func TestOfflineASRWithShortAudio(t *testing.T) {
 	}
 
 	// 创建离线识别管理器
-	manager := NewOfflineASRManager(cfg)
-	if manager == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	manager, err := NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -138,9 +138,9 @@ func TestOfflineASRStats(t *testing.T) {
 	}
 
 	// 创建离线识别管理器
-	manager := NewOfflineASRManager(cfg)
-	if manager == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	manager, err := NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -175,9 +175,9 @@ func BenchmarkOfflineASR(b *testing.B) {
 	}
 
 	// 创建离线识别管理器
-	manager := NewOfflineASRManager(cfg)
-	if manager == nil {
-		b.Fatal("Failed to create offline ASR manager")
+	manager, err := NewOfflineASRManager(cfg)
+	if err != nil {
+		b.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer manager.Close()
 
@@ -1,7 +1,7 @@
 package asr
 
 import (
-	"log"
+	"log/slog"
 	"path/filepath"
 	"sync"
 
@@ -21,14 +21,14 @@ type PunctuationManager struct {
 // NewPunctuationManager 创建标点符号管理器
 func NewPunctuationManager(cfg *config.Config) *PunctuationManager {
 	if !cfg.Punctuation.Enabled {
-		log.Println("Punctuation is disabled")
+		slog.Info("Punctuation is disabled")
 		return &PunctuationManager{
 			config:  cfg,
 			enabled: false,
 		}
 	}
 
-	log.Println("Initializing Punctuation Manager...")
+	slog.Info("Initializing Punctuation Manager...")
 
 	// 构建模型路径
 	modelPath := filepath.Join(cfg.Punctuation.ModelDir, cfg.Punctuation.Model)
@@ -44,14 +44,14 @@ func NewPunctuationManager(cfg *config.Config) *PunctuationManager {
 	// 创建标点符号处理器
 	punctuation := sherpa.NewOfflinePunctuation(&punctConfig)
 	if punctuation == nil {
-		log.Println("Warning: Failed to create punctuation processor, will return text without punctuation")
+		slog.Warn("Failed to create punctuation processor, will return text without punctuation")
 		return &PunctuationManager{
 			config:  cfg,
 			enabled: false,
 		}
 	}
 
-	log.Println("Punctuation Manager initialized successfully")
+	slog.Info("Punctuation Manager initialized successfully")
 
 	return &PunctuationManager{
 		config:      cfg,
@@ -89,12 +89,12 @@ func (m *PunctuationManager) Close() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	log.Println("Closing Punctuation Manager...")
+	slog.Info("Closing Punctuation Manager...")
 
 	// 删除标点符号处理器
 	if m.punctuation != nil {
 		sherpa.DeleteOfflinePunc(m.punctuation)
 	}
 
-	log.Println("Punctuation Manager closed")
+	slog.Info("Punctuation Manager closed")
 }
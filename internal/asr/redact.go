@@ -0,0 +1,395 @@
+package asr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 脱敏信息类型（redact 阶段 entities）
+const (
+	RedactPhone    = "phone"
+	RedactIDCard   = "id_card"
+	RedactBankCard = "bank_card"
+	RedactEmail    = "email"
+)
+
+// defaultRedactTags 各类型默认替换标签
+var defaultRedactTags = map[string]string{
+	RedactPhone:    "[PHONE]",
+	RedactIDCard:   "[ID_CARD]",
+	RedactBankCard: "[BANK_CARD]",
+	RedactEmail:    "[EMAIL]",
+}
+
+// redactionPadding 估计的时间范围前后各扩展的秒数，保证静音能覆盖实际发音
+const redactionPadding = 0.2
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	// numberPattern 由空格或连字符分隔的数字串，可带 + 前缀与身份证末位 X
+	numberPattern = regexp.MustCompile(`\+?\d+(?:[ \-]\d+)*[Xx]?`)
+	digitGroup    = regexp.MustCompile(`\d+[Xx]?`)
+)
+
+// Redaction 一处被脱敏的内容。Start/End 为替换标签在结果文本中的字符（rune）位置，
+// StartTime/EndTime 为按识别结果估计的音频时间（秒），已包含少量前后余量
+type Redaction struct {
+	Type      string  `json:"type"`
+	Start     int     `json:"start"`
+	End       int     `json:"end"`
+	StartTime float32 `json:"start_time"`
+	EndTime   float32 `json:"end_time"`
+}
+
+// redactStage 将手机号、固话、身份证号（校验位）、银行卡号（Luhn 校验）与邮箱替换为标签
+type redactStage struct {
+	entities map[string]bool
+	tags     map[string]string
+}
+
+func newRedactStage(entities []string, tags map[string]string) (*redactStage, error) {
+	s := &redactStage{entities: make(map[string]bool), tags: make(map[string]string)}
+	if len(entities) == 0 {
+		entities = []string{RedactPhone, RedactIDCard, RedactBankCard, RedactEmail}
+	}
+	for _, e := range entities {
+		tag, ok := defaultRedactTags[e]
+		if !ok {
+			return nil, fmt.Errorf("unknown redact entity %q", e)
+		}
+		s.entities[e] = true
+		s.tags[e] = tag
+	}
+	for e, tag := range tags {
+		if _, ok := defaultRedactTags[e]; !ok {
+			return nil, fmt.Errorf("unknown redact entity %q", e)
+		}
+		if tag == "" {
+			return nil, fmt.Errorf("empty tag for redact entity %q", e)
+		}
+		if s.entities[e] {
+			s.tags[e] = tag
+		}
+	}
+	return s, nil
+}
+
+func (s *redactStage) Type() string { return StageRedact }
+
+func (s *redactStage) Process(text string) string {
+	if s.entities[RedactEmail] {
+		text = emailPattern.ReplaceAllString(text, s.tags[RedactEmail])
+	}
+	return numberPattern.ReplaceAllStringFunc(text, s.redactNumber)
+}
+
+// redactNumber 处理一段数字串。整段不符合任何类型时按分隔后的数字组寻找最长的可识别子段，
+// 例如「13800138000 2」只替换前面的手机号
+func (s *redactStage) redactNumber(match string) string {
+	plus := strings.HasPrefix(match, "+")
+	groups := digitGroup.FindAllStringIndex(match, -1)
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(groups); {
+		matched := false
+		for j := len(groups); j > i; j-- {
+			var digits strings.Builder
+			for _, g := range groups[i:j] {
+				digits.WriteString(match[g[0]:g[1]])
+			}
+			// X 只能出现在最后一组末尾
+			d := digits.String()
+			if strings.ContainsAny(d[:len(d)-1], "Xx") {
+				continue
+			}
+			entity := s.classify(d)
+			if entity == "" {
+				continue
+			}
+			start := groups[i][0]
+			if i == 0 && plus {
+				start = 0
+			}
+			b.WriteString(match[last:start])
+			b.WriteString(s.tags[entity])
+			last = groups[j-1][1]
+			i = j
+			matched = true
+			break
+		}
+		if !matched {
+			i++
+		}
+	}
+	b.WriteString(match[last:])
+	return b.String()
+}
+
+// classify 判断数字串的类型，不符合任何已启用类型时返回空
+func (s *redactStage) classify(d string) string {
+	if strings.HasSuffix(d, "X") || strings.HasSuffix(d, "x") {
+		if s.entities[RedactIDCard] && validIDCard(d) {
+			return RedactIDCard
+		}
+		return ""
+	}
+	switch {
+	case s.entities[RedactIDCard] && validIDCard(d):
+		return RedactIDCard
+	case s.entities[RedactPhone] && isPhoneNumber(d):
+		return RedactPhone
+	case s.entities[RedactBankCard] && len(d) >= 13 && len(d) <= 19 && luhnValid(d):
+		return RedactBankCard
+	}
+	return ""
+}
+
+// find 在最终文本中定位替换标签，后续阶段修改了大小写也能识别
+func (s *redactStage) find(text string) []Redaction {
+	lower := strings.ToLower(text)
+	var spans []Redaction
+	for entity, tag := range s.tags {
+		tag = strings.ToLower(tag)
+		for from := 0; ; {
+			i := strings.Index(lower[from:], tag)
+			if i < 0 {
+				break
+			}
+			start := from + i
+			spans = append(spans, Redaction{
+				Type:  entity,
+				Start: utf8.RuneCountInString(text[:start]),
+				End:   utf8.RuneCountInString(text[:start+len(tag)]),
+			})
+			from = start + len(tag)
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return spans
+}
+
+// isPhoneNumber 大陆手机号（可带 86 前缀）或带区号的固定电话
+func isPhoneNumber(d string) bool {
+	mobile := func(n string) bool {
+		return len(n) == 11 && n[0] == '1' && n[1] >= '3' && n[1] <= '9'
+	}
+	switch {
+	case mobile(d):
+		return true
+	case len(d) == 13 && strings.HasPrefix(d, "86") && mobile(d[2:]):
+		return true
+	case len(d) >= 10 && len(d) <= 12 && d[0] == '0' && d[1] != '0':
+		return true
+	}
+	return false
+}
+
+// validIDCard 18 位居民身份证号：前 17 位加权求和模 11 得到校验位
+func validIDCard(d string) bool {
+	if len(d) != 18 {
+		return false
+	}
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		if d[i] < '0' || d[i] > '9' {
+			return false
+		}
+		sum += int(d[i]-'0') * weights[i]
+	}
+	check := "10X98765432"[sum%11]
+	last := d[17]
+	if last == 'x' {
+		last = 'X'
+	}
+	return last == check
+}
+
+// luhnValid Luhn 校验（银行卡号）
+func luhnValid(d string) bool {
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		if d[i] < '0' || d[i] > '9' {
+			return false
+		}
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isContentRune 计入对齐位置的字符（排除空白与标点）
+func isContentRune(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsPunct(r) && r != '▁'
+}
+
+// alignRedactions 估计脱敏内容的音频时间。后处理会改变文本长度，因此按有效字符的相对位置
+// 映射到识别器输出的 token 上取时间戳；没有 token 时间戳时按音频时长线性估计。
+// 有 token 时，标签以外的字符按一对一计，各标签平分剩余的 token 字符，使标签前后的位置尽量对齐。
+// 结果时间相对于本段音频起点，并在前后各扩展 redactionPadding 秒
+func alignRedactions(spans []Redaction, text string, tokens []string, timestamps []float32, duration float32) {
+	if len(spans) == 0 {
+		return
+	}
+
+	// 识别器 token 的有效字符累计数
+	useTokens := len(tokens) > 0 && len(tokens) == len(timestamps)
+	cum := make([]int, len(tokens)+1)
+	if useTokens {
+		for i, tok := range tokens {
+			n := 0
+			for _, r := range tok {
+				if isContentRune(r) {
+					n++
+				}
+			}
+			cum[i+1] = cum[i] + n
+		}
+		useTokens = cum[len(tokens)] > 0
+	}
+
+	// 标记属于标签的字符
+	runes := []rune(text)
+	inTag := make([]int, len(runes)) // 所属标签序号 +1，0 表示不在标签内
+	for k, sp := range spans {
+		for i := sp.Start; i < sp.End && i < len(runes); i++ {
+			inTag[i] = k + 1
+		}
+	}
+	plain := 0
+	tagRunes := make([]int, len(spans))
+	for i, r := range runes {
+		if !isContentRune(r) {
+			continue
+		}
+		if inTag[i] == 0 {
+			plain++
+		} else {
+			tagRunes[inTag[i]-1]++
+		}
+	}
+
+	// 每个有效字符的权重
+	weight := func(i int) float32 {
+		k := inTag[i]
+		if k == 0 || !useTokens || tagRunes[k-1] == 0 {
+			return 1
+		}
+		w := float32(cum[len(tokens)]-plain) / float32(len(spans))
+		if w < 1 {
+			w = 1
+		}
+		return w / float32(tagRunes[k-1])
+	}
+	before := make([]float32, len(runes)+1)
+	for i, r := range runes {
+		before[i+1] = before[i]
+		if isContentRune(r) {
+			before[i+1] += weight(i)
+		}
+	}
+	total := before[len(runes)]
+	if total == 0 {
+		return
+	}
+
+	at := func(pos int, end bool) float32 {
+		if pos > len(runes) {
+			pos = len(runes)
+		}
+		ratio := before[pos] / total
+		if !useTokens {
+			return ratio * duration
+		}
+		raw := ratio * float32(cum[len(tokens)])
+		if end {
+			// 结束位置取覆盖最后一个字符的 token
+			raw -= 0.5
+		}
+		// 第一个累计数超过 raw 的 token
+		i := sort.Search(len(tokens), func(k int) bool { return float32(cum[k+1]) > raw })
+		if i >= len(tokens) {
+			i = len(tokens) - 1
+		}
+		if !end {
+			return timestamps[i]
+		}
+		if i+1 < len(timestamps) {
+			return timestamps[i+1]
+		}
+		return duration
+	}
+
+	for k := range spans {
+		start := at(spans[k].Start, false) - redactionPadding
+		end := at(spans[k].End, true) + redactionPadding
+		if start < 0 {
+			start = 0
+		}
+		if end > duration {
+			end = duration
+		}
+		spans[k].StartTime = start
+		spans[k].EndTime = end
+	}
+}
+
+// shiftRedactions 将片段内的位置与时间平移到整体结果中
+func shiftRedactions(spans []Redaction, runeOffset int, timeOffset float32) []Redaction {
+	shifted := make([]Redaction, len(spans))
+	for i, s := range spans {
+		s.Start += runeOffset
+		s.End += runeOffset
+		s.StartTime += timeOffset
+		s.EndTime += timeOffset
+		shifted[i] = s
+	}
+	return shifted
+}
+
+// MuteRedactions 返回音频副本，其中脱敏内容对应的时间范围被静音
+func MuteRedactions(samples []float32, sampleRate int, spans []Redaction) []float32 {
+	muted := make([]float32, len(samples))
+	copy(muted, samples)
+	for _, s := range spans {
+		start := int(s.StartTime * float32(sampleRate))
+		end := int(s.EndTime * float32(sampleRate))
+		if start < 0 {
+			start = 0
+		}
+		if end > len(muted) {
+			end = len(muted)
+		}
+		for i := start; i < end; i++ {
+			muted[i] = 0
+		}
+	}
+	return muted
+}
+
+// JoinSegments 合并说话者分离片段的文本（与逐段拼接空格的格式一致），并将各片段的脱敏位置平移到合并后的文本中
+func JoinSegments(segments []DiarizationSegment) (string, []Redaction) {
+	var b strings.Builder
+	var spans []Redaction
+	offset := 0
+	for _, seg := range segments {
+		spans = append(spans, shiftRedactions(seg.Redactions, offset, 0)...)
+		b.WriteString(seg.Text)
+		b.WriteString(" ")
+		offset += utf8.RuneCountInString(seg.Text) + 1
+	}
+	return b.String(), spans
+}
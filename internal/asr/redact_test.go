@@ -0,0 +1,107 @@
+package asr
+
+import (
+	"testing"
+)
+
+func TestRedactStage(t *testing.T) {
+	stage, err := newRedactStage(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"我的手机是13800138000，谢谢":            "我的手机是[PHONE]，谢谢",
+		"call +86 138 0013 8000 now":     "call [PHONE] now",
+		"座机 010-62345678":                "座机 [PHONE]",
+		"身份证号11010519491231002X":         "身份证号[ID_CARD]",
+		"身份证号110105194912310021":         "身份证号110105194912310021",
+		"卡号 4111 1111 1111 1111":         "卡号 [BANK_CARD]",
+		"卡号 4111 1111 1111 1112":         "卡号 4111 1111 1111 1112",
+		"邮箱 zhang.san@example.com.cn 联系": "邮箱 [EMAIL] 联系",
+		"号码13800138000 2个":               "号码[PHONE] 2个",
+		"订单号 20240101 共 3 件，0 元":         "订单号 20240101 共 3 件，0 元",
+	}
+	for in, want := range cases {
+		if got := stage.Process(in); got != want {
+			t.Errorf("Process(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	phoneOnly, err := newRedactStage([]string{RedactPhone}, map[string]string{RedactPhone: "<电话>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := phoneOnly.Process("13800138000 a@b.com"); got != "<电话> a@b.com" {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if _, err := newRedactStage([]string{"address"}, nil); err == nil {
+		t.Fatal("expected error for unknown entity")
+	}
+}
+
+func TestRedactionSpansAndTimes(t *testing.T) {
+	stage, _ := newRedactStage(nil, nil)
+	text := stage.Process("电话13800138000")
+	spans := stage.find(text)
+	if len(spans) != 1 || spans[0].Type != RedactPhone || spans[0].Start != 2 || spans[0].End != 9 {
+		t.Fatalf("unexpected spans %+v for %q", spans, text)
+	}
+
+	// 没有 token 时间戳时按有效字符（不含标点）位置线性估计：「电话PHONE」共 7 个
+	alignRedactions(spans, text, nil, nil, 7)
+	if d := spans[0].StartTime - (2 - redactionPadding); d > 1e-4 || d < -1e-4 || spans[0].EndTime != 7 {
+		t.Fatalf("unexpected linear times %+v", spans[0])
+	}
+
+	// 有 token 时间戳时映射到 token 上
+	tokens := []string{"电", "话", "一", "三", "八", "零", "零", "一", "三", "八", "零", "零", "零", "好", "的"}
+	timestamps := make([]float32, len(tokens))
+	for i := range timestamps {
+		timestamps[i] = float32(i) * 0.5
+	}
+	text = stage.Process("电话13800138000好的")
+	spans = stage.find(text)
+	alignRedactions(spans, text, tokens, timestamps, 8)
+	// 号码对应 token 2-12，即 1.0s 到 6.5s
+	if spans[0].StartTime != 1-redactionPadding || spans[0].EndTime != 6.5+redactionPadding {
+		t.Fatalf("token aligned span does not cover the number: %+v", spans[0])
+	}
+
+	muted := MuteRedactions([]float32{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, 1, []Redaction{{StartTime: 2, EndTime: 5}})
+	for i, v := range muted {
+		if want := float32(1); i >= 2 && i < 5 {
+			want = 0
+			if v != want {
+				t.Fatalf("sample %d not muted", i)
+			}
+		} else if v != want {
+			t.Fatalf("sample %d changed", i)
+		}
+	}
+}
+
+func TestValidators(t *testing.T) {
+	if !validIDCard("11010519491231002X") || !validIDCard("11010519491231002x") {
+		t.Fatal("valid id card rejected")
+	}
+	if validIDCard("110105194912310020") {
+		t.Fatal("invalid id card checksum accepted")
+	}
+	if !luhnValid("4111111111111111") || luhnValid("4111111111111112") {
+		t.Fatal("luhn check failed")
+	}
+}
+
+func TestJoinSegmentsShiftsRedactions(t *testing.T) {
+	segments := []DiarizationSegment{
+		{Text: "你好"},
+		{Text: "电话[PHONE]", Redactions: []Redaction{{Type: RedactPhone, Start: 2, End: 9, StartTime: 3, EndTime: 4}}},
+	}
+	text, spans := JoinSegments(segments)
+	if text != "你好 电话[PHONE] " {
+		t.Fatalf("unexpected text %q", text)
+	}
+	if len(spans) != 1 || spans[0].Start != 5 || spans[0].End != 12 || spans[0].StartTime != 3 {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
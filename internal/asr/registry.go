@@ -0,0 +1,839 @@
+package asr
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"airecorder/internal/config"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// DefaultModelName offline_asr / streaming_asr 主配置在注册表中的名称
+const DefaultModelName = "default"
+
+const (
+	// ModelStatusLoaded 模型已加载可用
+	ModelStatusLoaded = "loaded"
+	// ModelStatusFailed 模型加载失败
+	ModelStatusFailed = "failed"
+)
+
+// ModelInfo 模型描述及加载状态
+type ModelInfo struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`       // offline 或 streaming
+	ModelType string `json:"model_type"` // paraformer、whisper、zipformer 等
+	Default   bool   `json:"default"`
+	Status    string `json:"status"` // loaded 或 failed
+	Error     string `json:"error,omitempty"`
+	LoadedAt  string `json:"loaded_at,omitempty"`
+	Version   int    `json:"version"`            // 加载次数，每次热加载成功加 1
+	InUse     int64  `json:"in_use"`             // 当前版本被引用的次数
+	Draining  int    `json:"draining,omitempty"` // 已被替换、仍在等待引用释放的旧版本数量
+	Hotwords  bool   `json:"hotwords"`           // 是否支持热词
+}
+
+// ModelHandle 识别器的引用计数句柄。
+// 热加载后旧句柄被标记为退役，待所有在途流释放引用后才真正销毁识别器
+type ModelHandle struct {
+	name     string
+	version  int
+	offline  *sherpa.OfflineRecognizer
+	online   *sherpa.OnlineRecognizer
+	refs     int64
+	retired  bool
+	released bool
+	cleanup  func() // 销毁识别器后执行（例如删除临时热词文件）
+	mu       sync.Mutex
+}
+
+// Acquire 增加引用
+func (h *ModelHandle) Acquire() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.refs++
+}
+
+// Release 释放引用，退役且无引用时销毁识别器
+func (h *ModelHandle) Release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.refs--
+	if h.retired && h.refs <= 0 {
+		h.destroy()
+	}
+}
+
+// retire 标记退役，无引用时立即销毁
+func (h *ModelHandle) retire() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retired = true
+	if h.refs <= 0 {
+		h.destroy()
+	}
+}
+
+// drained 退役句柄是否已销毁
+func (h *ModelHandle) drained() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.released
+}
+
+func (h *ModelHandle) inUse() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.refs
+}
+
+// destroy 销毁识别器（调用方需持有 h.mu）
+func (h *ModelHandle) destroy() {
+	h.released = true
+	if h.offline != nil {
+		sherpa.DeleteOfflineRecognizer(h.offline)
+		h.offline = nil
+		slog.Info("Offline model released", "model", h.name, "version", h.version)
+	}
+	if h.online != nil {
+		sherpa.DeleteOnlineRecognizer(h.online)
+		h.online = nil
+		slog.Info("Streaming model released", "model", h.name, "version", h.version)
+	}
+	if h.cleanup != nil {
+		h.cleanup()
+		h.cleanup = nil
+	}
+}
+
+// registeredModel 注册表中的一项模型
+type registeredModel struct {
+	info      ModelInfo
+	current   *ModelHandle   // 当前版本，新请求/会话使用
+	draining  []*ModelHandle // 已退役、等待在途引用释放的旧版本
+	offline   config.OfflineASRConfig
+	streaming config.StreamingASRConfig
+}
+
+// biasedModel 带热词的识别器缓存项
+type biasedModel struct {
+	modelType string
+	name      string
+	handle    *ModelHandle
+	lastUsed  time.Time
+}
+
+// biasedBuild 进行中的带热词识别器加载，相同键的并发请求等待同一次加载
+type biasedBuild struct {
+	done chan struct{}
+	err  error
+}
+
+// maxConcurrentBiasedBuilds 同时加载的带热词识别器数量上限。
+// 每次加载都是一次完整的模型加载，限制并发避免不同热词的请求耗尽 CPU 与内存
+const maxConcurrentBiasedBuilds = 2
+
+// ModelRegistry 命名模型注册表，统一管理离线与实时识别器
+type ModelRegistry struct {
+	offline   map[string]*registeredModel
+	streaming map[string]*registeredModel
+	defaults  map[string]string // 模型类型 -> 默认模型名称
+	types     []string          // 加载的模型类型，为空表示全部
+	mu        sync.RWMutex
+	reloadMu  sync.Mutex // 串行化热加载
+
+	hotwords  *HotwordStore
+	biased    map[string]*biasedModel // 模型版本 + 热词摘要 -> 带热词的识别器
+	maxBiased int
+	building  map[string]*biasedBuild // 进行中的加载（由 biasedMu 保护）
+	buildSem  chan struct{}           // 限制同时进行的加载数
+	biasedMu  sync.Mutex
+}
+
+// NewModelRegistry 加载主配置及 models 列表中的模型，types 为空时加载全部类型
+func NewModelRegistry(cfg *config.Config, types ...string) *ModelRegistry {
+	r := &ModelRegistry{
+		offline:   make(map[string]*registeredModel),
+		streaming: make(map[string]*registeredModel),
+		defaults:  make(map[string]string),
+		types:     types,
+		hotwords:  NewHotwordStore(cfg.Hotwords),
+		biased:    make(map[string]*biasedModel),
+		maxBiased: cfg.Hotwords.MaxCachedRecognizers,
+		building:  make(map[string]*biasedBuild),
+		buildSem:  make(chan struct{}, maxConcurrentBiasedBuilds),
+	}
+	if r.maxBiased <= 0 {
+		r.maxBiased = 4
+	}
+	want := r.wantType
+
+	if want(config.ModelTypeOffline) && cfg.OfflineASR.Enabled {
+		r.loadOffline(DefaultModelName, cfg.OfflineASR)
+		r.defaults[config.ModelTypeOffline] = DefaultModelName
+	}
+	if want(config.ModelTypeStreaming) && cfg.StreamingASR.Enabled {
+		r.loadStreaming(DefaultModelName, cfg.StreamingASR)
+		r.defaults[config.ModelTypeStreaming] = DefaultModelName
+	}
+
+	explicitDefault := make(map[string]bool)
+	for _, entry := range cfg.Models {
+		if !want(entry.Type) {
+			continue
+		}
+		if entry.Name == "" || entry.Name == DefaultModelName {
+			slog.Warn("Skipping model with invalid name", "model", entry.Name, "type", entry.Type)
+			continue
+		}
+		if r.lookup(entry.Type, entry.Name) != nil {
+			slog.Warn("Skipping duplicate model", "model", entry.Name, "type", entry.Type)
+			continue
+		}
+
+		switch entry.Type {
+		case config.ModelTypeOffline:
+			r.loadOffline(entry.Name, entry.Offline)
+		case config.ModelTypeStreaming:
+			r.loadStreaming(entry.Name, entry.Streaming)
+		default:
+			slog.Warn("Skipping model with unknown type", "model", entry.Name, "type", entry.Type)
+			continue
+		}
+
+		// 显式标记 default 的模型优先；主配置未启用时使用该类型的第一个模型
+		if entry.Default && !explicitDefault[entry.Type] {
+			r.defaults[entry.Type] = entry.Name
+			explicitDefault[entry.Type] = true
+		} else if r.defaults[entry.Type] == "" {
+			r.defaults[entry.Type] = entry.Name
+		}
+	}
+
+	for t, name := range r.defaults {
+		if m := r.lookup(t, name); m != nil {
+			m.info.Default = true
+		}
+	}
+
+	return r
+}
+
+// wantType 注册表是否管理该类型的模型
+func (r *ModelRegistry) wantType(t string) bool {
+	if len(r.types) == 0 {
+		return true
+	}
+	for _, v := range r.types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// loadOffline 加载离线模型并登记（失败时记录状态，不中断）
+func (r *ModelRegistry) loadOffline(name string, oc config.OfflineASRConfig) {
+	m := &registeredModel{
+		info: ModelInfo{
+			Name: name,
+			Type: config.ModelTypeOffline,
+		},
+		offline: oc,
+	}
+	r.offline[name] = m
+	if err := m.load(); err != nil {
+		slog.Warn("Failed to load offline model", "model", name, "error", err)
+	}
+}
+
+// loadStreaming 加载实时模型并登记（失败时记录状态，不中断）
+func (r *ModelRegistry) loadStreaming(name string, sc config.StreamingASRConfig) {
+	m := &registeredModel{
+		info: ModelInfo{
+			Name: name,
+			Type: config.ModelTypeStreaming,
+		},
+		streaming: sc,
+	}
+	r.streaming[name] = m
+	if err := m.load(); err != nil {
+		slog.Warn("Failed to load streaming model", "model", name, "error", err)
+	}
+}
+
+// load 按当前配置创建识别器并设为当前版本（用于初始加载）
+func (m *registeredModel) load() error {
+	h, err := buildHandle(m.info.Type, m.info.Name, m.info.Version+1, m.offline, m.streaming)
+	if err != nil {
+		return m.loadFailed(err)
+	}
+	m.install(h)
+	return nil
+}
+
+// buildHandle 按配置创建新版本识别器。加载模型耗时较长，调用时不应持有注册表锁
+func buildHandle(modelType, name string, version int, oc config.OfflineASRConfig, sc config.StreamingASRConfig) (*ModelHandle, error) {
+	h := &ModelHandle{name: name, version: version}
+
+	switch modelType {
+	case config.ModelTypeOffline:
+		rec, err := newOfflineRecognizer(oc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load offline model %q: %w", name, err)
+		}
+		h.offline = rec
+	case config.ModelTypeStreaming:
+		rec, err := newOnlineRecognizer(sc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load streaming model %q: %w", name, err)
+		}
+		h.online = rec
+	default:
+		return nil, fmt.Errorf("unknown model type %q", modelType)
+	}
+	return h, nil
+}
+
+// install 将新版本设为当前版本，旧版本退役并在引用释放后销毁（调用方需持有注册表写锁）
+func (m *registeredModel) install(h *ModelHandle) {
+	old := m.current
+	m.current = h
+	m.info.Version = h.version
+	m.info.Status = ModelStatusLoaded
+	m.info.Error = ""
+	m.info.LoadedAt = time.Now().Format(time.RFC3339)
+	m.info.ModelType = m.modelType()
+	slog.Info("Model loaded", "type", m.info.Type, "model", m.info.Name, "version", h.version, "model_type", m.info.ModelType)
+
+	if old != nil {
+		m.draining = append(m.draining, old)
+		old.retire()
+	}
+}
+
+// supportsHotwords 模型是否支持热词
+func (m *registeredModel) supportsHotwords() bool {
+	if m.info.Type == config.ModelTypeStreaming {
+		return m.streaming.SupportsHotwords()
+	}
+	return m.offline.SupportsHotwords()
+}
+
+func (m *registeredModel) modelType() string {
+	if m.info.Type == config.ModelTypeStreaming {
+		return m.streaming.ModelType
+	}
+	return m.offline.ModelType
+}
+
+// loadFailed 记录加载失败；已有可用版本时状态保持 loaded
+func (m *registeredModel) loadFailed(err error) error {
+	m.info.ModelType = m.modelType()
+	m.info.Error = err.Error()
+	if m.current == nil {
+		m.info.Status = ModelStatusFailed
+	}
+	return err
+}
+
+// snapshot 返回模型信息（清理已销毁的退役版本）
+func (m *registeredModel) snapshot() ModelInfo {
+	remaining := m.draining[:0]
+	for _, h := range m.draining {
+		if !h.drained() {
+			remaining = append(remaining, h)
+		}
+	}
+	m.draining = remaining
+
+	info := m.info
+	info.Draining = len(m.draining)
+	info.Hotwords = m.supportsHotwords()
+	if m.current != nil {
+		info.InUse = m.current.inUse()
+	}
+	return info
+}
+
+func (r *ModelRegistry) lookup(modelType, name string) *registeredModel {
+	switch modelType {
+	case config.ModelTypeOffline:
+		return r.offline[name]
+	case config.ModelTypeStreaming:
+		return r.streaming[name]
+	}
+	return nil
+}
+
+// resolve 按名称查找模型，name 为空时使用该类型的默认模型
+func (r *ModelRegistry) resolve(modelType, name string) (*registeredModel, error) {
+	if name == "" {
+		name = r.defaults[modelType]
+		if name == "" {
+			return nil, fmt.Errorf("no %s model configured", modelType)
+		}
+	}
+
+	m := r.lookup(modelType, name)
+	if m == nil {
+		return nil, fmt.Errorf("unknown %s model: %s", modelType, name)
+	}
+	if m.current == nil {
+		return nil, fmt.Errorf("%s model %s is not available: %s", modelType, name, m.info.Error)
+	}
+	return m, nil
+}
+
+// acquire 获取模型当前版本的句柄并增加引用，调用方用完后必须 Release
+func (r *ModelRegistry) acquire(modelType, name string) (*ModelHandle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, err := r.resolve(modelType, name)
+	if err != nil {
+		return nil, err
+	}
+	m.current.Acquire()
+	return m.current, nil
+}
+
+// AcquireOffline 获取离线识别器句柄，name 为空时使用默认模型，用完后必须调用 Release
+func (r *ModelRegistry) AcquireOffline(name string) (*ModelHandle, error) {
+	return r.acquire(config.ModelTypeOffline, name)
+}
+
+// AcquireOnline 获取实时识别器句柄，name 为空时使用默认模型，会话结束后必须调用 Release
+func (r *ModelRegistry) AcquireOnline(name string) (*ModelHandle, error) {
+	return r.acquire(config.ModelTypeStreaming, name)
+}
+
+// ResolveName 校验模型是否可用并返回实际名称，name 为空时返回默认模型名称
+func (r *ModelRegistry) ResolveName(modelType, name string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, err := r.resolve(modelType, name)
+	if err != nil {
+		return "", err
+	}
+	return m.info.Name, nil
+}
+
+// reloadTarget 一项待热加载的模型
+type reloadTarget struct {
+	modelType string
+	name      string
+	offline   config.OfflineASRConfig
+	streaming config.StreamingASRConfig
+}
+
+// Reload 按新配置热加载模型，modelType/name 为空时匹配全部。
+// 新版本在旧版本旁加载，成功后立即对新请求和新会话生效；
+// 旧版本在在途请求/会话全部释放引用后销毁。加载失败的模型保留旧版本与旧配置继续服务。
+// models 列表中新增的模型会被一并登记
+func (r *ModelRegistry) Reload(newCfg *config.Config, modelType, name string) error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	targets, err := r.reloadTargets(newCfg, modelType, name)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, t := range targets {
+		slog.Info("Reloading model", "type", t.modelType, "model", t.name)
+		h, err := buildHandle(t.modelType, t.name, r.version(t.modelType, t.name)+1, t.offline, t.streaming)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		r.installReloaded(t, h, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("reload failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ReloadAll 同 Reload，但要求正在服务的模型全部加载成功后才一起生效；
+// 任一模型加载失败时销毁已加载的新版本，所有模型保持原版本与原配置。
+// 从未加载成功的模型不提供服务，加载失败时只记录新配置供后续重试，不影响其他模型生效
+func (r *ModelRegistry) ReloadAll(newCfg *config.Config, modelType, name string) error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	targets, err := r.reloadTargets(newCfg, modelType, name)
+	if err != nil {
+		return err
+	}
+
+	handles := make([]*ModelHandle, len(targets))
+	errs := make([]error, len(targets))
+	for i, t := range targets {
+		slog.Info("Reloading model", "type", t.modelType, "model", t.name)
+		handles[i], errs[i] = buildHandle(t.modelType, t.name, r.version(t.modelType, t.name)+1, t.offline, t.streaming)
+		if errs[i] != nil && r.loaded(t.modelType, t.name) {
+			for _, built := range handles[:i] {
+				if built != nil {
+					built.retire()
+				}
+			}
+			return fmt.Errorf("reload failed, no model changed: %w", errs[i])
+		}
+	}
+
+	for i, t := range targets {
+		r.installReloaded(t, handles[i], errs[i])
+	}
+	return nil
+}
+
+// loaded 模型是否有可用版本
+func (r *ModelRegistry) loaded(modelType, name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := r.lookup(modelType, name)
+	return m != nil && m.current != nil
+}
+
+// version 模型当前版本号，未登记时为 0
+func (r *ModelRegistry) version(modelType, name string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if m := r.lookup(modelType, name); m != nil {
+		return m.info.Version
+	}
+	return 0
+}
+
+// reloadTargets 新配置中与 modelType/name 匹配的模型，为空时匹配全部
+func (r *ModelRegistry) reloadTargets(newCfg *config.Config, modelType, name string) ([]reloadTarget, error) {
+	var targets []reloadTarget
+	add := func(t reloadTarget) {
+		if !r.wantType(t.modelType) || (modelType != "" && modelType != t.modelType) || (name != "" && name != t.name) {
+			return
+		}
+		targets = append(targets, t)
+	}
+	if newCfg.OfflineASR.Enabled {
+		add(reloadTarget{modelType: config.ModelTypeOffline, name: DefaultModelName, offline: newCfg.OfflineASR})
+	}
+	if newCfg.StreamingASR.Enabled {
+		add(reloadTarget{modelType: config.ModelTypeStreaming, name: DefaultModelName, streaming: newCfg.StreamingASR})
+	}
+	for _, entry := range newCfg.Models {
+		if entry.Name == "" || entry.Name == DefaultModelName {
+			continue
+		}
+		add(reloadTarget{modelType: entry.Type, name: entry.Name, offline: entry.Offline, streaming: entry.Streaming})
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no matching model in config (type=%q, name=%q)", modelType, name)
+	}
+	return targets, nil
+}
+
+// installReloaded 登记热加载结果：成功时切换到新版本与新配置；
+// 失败时已有可用版本的模型保留原配置，未加载过的模型记录新配置供后续重试
+func (r *ModelRegistry) installReloaded(t reloadTarget, h *ModelHandle, err error) {
+	r.mu.Lock()
+	m := r.lookup(t.modelType, t.name)
+	if m == nil {
+		m = &registeredModel{info: ModelInfo{Name: t.name, Type: t.modelType}}
+		if t.modelType == config.ModelTypeOffline {
+			r.offline[t.name] = m
+		} else {
+			r.streaming[t.name] = m
+		}
+		if r.defaults[t.modelType] == "" {
+			r.defaults[t.modelType] = t.name
+			m.info.Default = true
+		}
+	}
+	if err == nil || m.current == nil {
+		m.offline = t.offline
+		m.streaming = t.streaming
+	}
+	if err != nil {
+		m.loadFailed(err)
+	} else {
+		m.install(h)
+	}
+	r.mu.Unlock()
+
+	if err == nil {
+		r.dropBiased(t.modelType, t.name)
+	}
+}
+
+// HasType 是否登记了指定类型的模型
+func (r *ModelRegistry) HasType(modelType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	switch modelType {
+	case config.ModelTypeOffline:
+		return len(r.offline) > 0
+	case config.ModelTypeStreaming:
+		return len(r.streaming) > 0
+	}
+	return false
+}
+
+// FailedModels 加载失败的模型名称
+func (r *ModelRegistry) FailedModels(modelType string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	models := r.offline
+	if modelType == config.ModelTypeStreaming {
+		models = r.streaming
+	}
+	var names []string
+	for name, m := range models {
+		if m.info.Status == ModelStatusFailed {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List 返回所有模型的描述及加载状态（按类型、名称排序，默认模型在前）
+func (r *ModelRegistry) List() []ModelInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]ModelInfo, 0, len(r.offline)+len(r.streaming))
+	for _, m := range r.offline {
+		result = append(result, m.snapshot())
+	}
+	for _, m := range r.streaming {
+		result = append(result, m.snapshot())
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		if result[i].Default != result[j].Default {
+			return result[i].Default
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Close 释放所有识别器（包括仍在排空的旧版本），调用前应先关闭所有会话
+func (r *ModelRegistry) Close() {
+	r.biasedMu.Lock()
+	for key, b := range r.biased {
+		b.handle.mu.Lock()
+		b.handle.destroy()
+		b.handle.mu.Unlock()
+		delete(r.biased, key)
+	}
+	r.biasedMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	closeAll := func(models map[string]*registeredModel) {
+		for name, m := range models {
+			handles := append(m.draining, m.current)
+			for _, h := range handles {
+				if h == nil {
+					continue
+				}
+				h.mu.Lock()
+				h.destroy()
+				h.mu.Unlock()
+			}
+			delete(models, name)
+		}
+	}
+	closeAll(r.offline)
+	closeAll(r.streaming)
+}
+
+// Hotwords 返回命名热词表存储，未启用热词时为 nil
+func (r *ModelRegistry) Hotwords() *HotwordStore {
+	return r.hotwords
+}
+
+// ResolveHotwords 合并命名热词表与请求中的热词
+func (r *ModelRegistry) ResolveHotwords(listName string, inline []Hotword) ([]Hotword, error) {
+	return r.hotwords.Resolve(listName, inline)
+}
+
+// CheckHotwords 检查模型是否支持热词，name 为空时检查默认模型
+func (r *ModelRegistry) CheckHotwords(modelType, name string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, err := r.resolve(modelType, name)
+	if err != nil {
+		return err
+	}
+	if !m.supportsHotwords() {
+		return fmt.Errorf("%s model %s (model_type=%s) does not support hotwords, a transducer model is required", modelType, m.info.Name, m.modelType())
+	}
+	return nil
+}
+
+// acquireWithHotwords 获取带热词的识别器句柄，hotwords 为空时等同 acquire。
+// sherpa-onnx 的 Go 接口不支持按流设置热词，因此为每个「模型版本 + 热词集合」
+// 单独创建识别器并按最近使用缓存，超出 max_cached_recognizers 时淘汰最久未用的一个
+func (r *ModelRegistry) acquireWithHotwords(modelType, name string, hotwords []Hotword) (*ModelHandle, error) {
+	if len(hotwords) == 0 {
+		return r.acquire(modelType, name)
+	}
+
+	if err := r.CheckHotwords(modelType, name); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	m, err := r.resolve(modelType, name)
+	if err != nil {
+		r.mu.RUnlock()
+		return nil, err
+	}
+	// 在锁内复制配置与版本号，热加载替换模型后缓存键随之变化
+	modelName, version, oc, sc := m.info.Name, m.info.Version, m.offline, m.streaming
+	r.mu.RUnlock()
+
+	key := fmt.Sprintf("%s/%s/v%d/%s", modelType, modelName, version, hotwordsKey(hotwords))
+	return r.cachedBiased(key, modelType, modelName, func() (*ModelHandle, error) {
+		return buildBiasedHandle(modelType, modelName, version, oc, sc, hotwords)
+	})
+}
+
+// cachedBiased 从缓存获取带热词的识别器，未命中时调用 build 加载。
+// 相同键的并发请求只加载一次，不同键的加载最多同时进行 maxConcurrentBiasedBuilds 个
+func (r *ModelRegistry) cachedBiased(key, modelType, modelName string, build func() (*ModelHandle, error)) (*ModelHandle, error) {
+	for {
+		r.biasedMu.Lock()
+		if b, ok := r.biased[key]; ok {
+			b.handle.Acquire()
+			b.lastUsed = time.Now()
+			r.biasedMu.Unlock()
+			return b.handle, nil
+		}
+		if pending, ok := r.building[key]; ok {
+			r.biasedMu.Unlock()
+			<-pending.done
+			if pending.err != nil {
+				return nil, pending.err
+			}
+			// 加载完成后重新查找缓存
+			continue
+		}
+		pending := &biasedBuild{done: make(chan struct{})}
+		r.building[key] = pending
+		r.biasedMu.Unlock()
+
+		// 加载模型耗时较长，不持有锁
+		r.buildSem <- struct{}{}
+		h, err := build()
+		<-r.buildSem
+
+		r.biasedMu.Lock()
+		delete(r.building, key)
+		pending.err = err
+		close(pending.done)
+		if err != nil {
+			r.biasedMu.Unlock()
+			return nil, err
+		}
+		h.Acquire()
+		r.biased[key] = &biasedModel{modelType: modelType, name: modelName, handle: h, lastUsed: time.Now()}
+		for len(r.biased) > r.maxBiased {
+			r.evictBiased()
+		}
+		r.biasedMu.Unlock()
+		return h, nil
+	}
+}
+
+// AcquireOfflineWithHotwords 获取带热词的离线识别器句柄，用完后必须调用 Release
+func (r *ModelRegistry) AcquireOfflineWithHotwords(name string, hotwords []Hotword) (*ModelHandle, error) {
+	return r.acquireWithHotwords(config.ModelTypeOffline, name, hotwords)
+}
+
+// AcquireOnlineWithHotwords 获取带热词的实时识别器句柄，会话结束后必须调用 Release
+func (r *ModelRegistry) AcquireOnlineWithHotwords(name string, hotwords []Hotword) (*ModelHandle, error) {
+	return r.acquireWithHotwords(config.ModelTypeStreaming, name, hotwords)
+}
+
+// evictBiased 淘汰最久未使用的带热词识别器（调用方需持有 biasedMu）
+func (r *ModelRegistry) evictBiased() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, b := range r.biased {
+		if oldestKey == "" || b.lastUsed.Before(oldest) {
+			oldestKey, oldest = key, b.lastUsed
+		}
+	}
+	if oldestKey != "" {
+		r.biased[oldestKey].handle.retire()
+		delete(r.biased, oldestKey)
+	}
+}
+
+// dropBiased 模型热加载后淘汰基于旧版本的带热词识别器
+func (r *ModelRegistry) dropBiased(modelType, name string) {
+	r.biasedMu.Lock()
+	defer r.biasedMu.Unlock()
+
+	for key, b := range r.biased {
+		if b.modelType == modelType && b.name == name {
+			b.handle.retire()
+			delete(r.biased, key)
+		}
+	}
+}
+
+// buildBiasedHandle 写出临时热词文件并以 modified_beam_search 创建识别器，识别器销毁时删除热词文件
+func buildBiasedHandle(modelType, name string, version int, oc config.OfflineASRConfig, sc config.StreamingASRConfig, hotwords []Hotword) (*ModelHandle, error) {
+	f, err := os.CreateTemp("", "airecorder-hotwords-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hotwords file: %w", err)
+	}
+	_, err = f.WriteString(formatHotwords(hotwords))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to write hotwords file: %w", err)
+	}
+
+	// 热词只在 modified_beam_search 下生效
+	oc.HotwordsFile, sc.HotwordsFile = f.Name(), f.Name()
+	oc.DecodingMethod, sc.DecodingMethod = "modified_beam_search", "modified_beam_search"
+	if oc.MaxActivePaths <= 0 {
+		oc.MaxActivePaths = 4
+	}
+	if sc.MaxActivePaths <= 0 {
+		sc.MaxActivePaths = 4
+	}
+
+	slog.Info("Loading model with hotwords", "type", modelType, "model", name, "version", version, "hotwords", len(hotwords))
+	h, err := buildHandle(modelType, name, version, oc, sc)
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	path := f.Name()
+	h.cleanup = func() { os.Remove(path) }
+	return h, nil
+}
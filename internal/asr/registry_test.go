@@ -0,0 +1,199 @@
+package asr
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"airecorder/internal/config"
+)
+
+func TestModelHandleDrainsAfterRelease(t *testing.T) {
+	m := &registeredModel{info: ModelInfo{Name: "default", Type: "offline"}}
+
+	v1 := &ModelHandle{name: "default", version: 1}
+	m.install(v1)
+
+	// 在途请求占用 v1
+	v1.Acquire()
+
+	v2 := &ModelHandle{name: "default", version: 2}
+	m.install(v2)
+
+	if m.current != v2 {
+		t.Fatal("expected v2 to become current")
+	}
+	if v1.drained() {
+		t.Fatal("v1 must not be released while still referenced")
+	}
+	if info := m.snapshot(); info.Version != 2 || info.Draining != 1 {
+		t.Fatalf("unexpected snapshot during drain: %+v", info)
+	}
+
+	v1.Release()
+	if !v1.drained() {
+		t.Fatal("v1 should be released after last reference")
+	}
+	if info := m.snapshot(); info.Draining != 0 {
+		t.Fatalf("expected no draining versions, got %+v", info)
+	}
+}
+
+func TestModelHandleRetiredWithoutReferences(t *testing.T) {
+	m := &registeredModel{info: ModelInfo{Name: "fast", Type: "streaming"}}
+
+	v1 := &ModelHandle{name: "fast", version: 1}
+	m.install(v1)
+	m.install(&ModelHandle{name: "fast", version: 2})
+
+	if !v1.drained() {
+		t.Fatal("unreferenced handle should be released immediately on retire")
+	}
+}
+
+func TestLoadFailedKeepsServingVersion(t *testing.T) {
+	m := &registeredModel{info: ModelInfo{Name: "default", Type: "offline"}}
+	m.install(&ModelHandle{name: "default", version: 1})
+
+	m.loadFailed(errors.New("missing encoder"))
+	if info := m.snapshot(); info.Status != ModelStatusLoaded || info.Error == "" {
+		t.Fatalf("failed reload should keep previous version loaded: %+v", info)
+	}
+}
+
+func TestFailedModels(t *testing.T) {
+	loaded := &registeredModel{info: ModelInfo{Name: "default", Type: "offline"}}
+	loaded.install(&ModelHandle{name: "default", version: 1})
+	broken := &registeredModel{info: ModelInfo{Name: "whisper", Type: "offline"}}
+	broken.loadFailed(errors.New("missing encoder"))
+
+	r := &ModelRegistry{
+		offline:   map[string]*registeredModel{"default": loaded, "whisper": broken},
+		streaming: map[string]*registeredModel{},
+	}
+	if got := r.FailedModels("offline"); len(got) != 1 || got[0] != "whisper" {
+		t.Fatalf("FailedModels(offline) = %v, want [whisper]", got)
+	}
+	if got := r.FailedModels("streaming"); len(got) != 0 {
+		t.Fatalf("FailedModels(streaming) = %v, want none", got)
+	}
+}
+
+func newBiasedTestRegistry() *ModelRegistry {
+	return &ModelRegistry{
+		biased:    make(map[string]*biasedModel),
+		maxBiased: 4,
+		building:  make(map[string]*biasedBuild),
+		buildSem:  make(chan struct{}, maxConcurrentBiasedBuilds),
+	}
+}
+
+func TestCachedBiasedBuildsOncePerKey(t *testing.T) {
+	r := newBiasedTestRegistry()
+	var builds atomic.Int32
+	release := make(chan struct{})
+	build := func() (*ModelHandle, error) {
+		builds.Add(1)
+		<-release
+		return &ModelHandle{name: "default", version: 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	handles := make([]*ModelHandle, 5)
+	for i := range handles {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h, err := r.cachedBiased("offline/default/v1/a", "offline", "default", build)
+			if err != nil {
+				t.Error(err)
+			}
+			handles[i] = h
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := builds.Load(); n != 1 {
+		t.Fatalf("expected a single build for concurrent requests, got %d", n)
+	}
+	for _, h := range handles {
+		if h != handles[0] {
+			t.Fatal("all requests should share the same handle")
+		}
+	}
+	if refs := handles[0].inUse(); refs != 5 {
+		t.Fatalf("expected 5 references, got %d", refs)
+	}
+}
+
+func TestCachedBiasedLimitsConcurrentBuilds(t *testing.T) {
+	r := newBiasedTestRegistry()
+	var running, peak atomic.Int32
+	build := func() (*ModelHandle, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return &ModelHandle{}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := r.cachedBiased(fmt.Sprintf("key-%d", i), "offline", "default", build); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > maxConcurrentBiasedBuilds {
+		t.Fatalf("expected at most %d concurrent builds, got %d", maxConcurrentBiasedBuilds, p)
+	}
+	if len(r.biased) != r.maxBiased {
+		t.Fatalf("expected cache bounded to %d, got %d", r.maxBiased, len(r.biased))
+	}
+}
+
+func TestCachedBiasedSharesBuildError(t *testing.T) {
+	r := newBiasedTestRegistry()
+	_, err := r.cachedBiased("key", "offline", "default", func() (*ModelHandle, error) {
+		return nil, errors.New("missing encoder")
+	})
+	if err == nil || len(r.building) != 0 {
+		t.Fatalf("expected build error and no pending builds, got %v (%d pending)", err, len(r.building))
+	}
+}
+
+func TestReloadAllKeepsPreviousVersionOnFailure(t *testing.T) {
+	oldCfg := config.StreamingASRConfig{Enabled: true, ModelType: "zipformer", Rule1MinTrailingSilence: 2.4}
+	m := &registeredModel{info: ModelInfo{Name: DefaultModelName, Type: config.ModelTypeStreaming}, streaming: oldCfg}
+	m.install(&ModelHandle{name: DefaultModelName, version: 1})
+	r := &ModelRegistry{
+		offline:   map[string]*registeredModel{},
+		streaming: map[string]*registeredModel{DefaultModelName: m},
+		defaults:  map[string]string{config.ModelTypeStreaming: DefaultModelName},
+	}
+
+	newCfg := &config.Config{StreamingASR: oldCfg}
+	newCfg.StreamingASR.ModelsDir = t.TempDir() // 模型文件缺失，加载失败
+	newCfg.StreamingASR.Rule1MinTrailingSilence = 1.2
+	if err := r.ReloadAll(newCfg, config.ModelTypeStreaming, ""); err == nil {
+		t.Fatal("expected reload error for missing model files")
+	}
+	if m.info.Version != 1 || m.streaming.Rule1MinTrailingSilence != 2.4 {
+		t.Fatalf("failed reload changed the model: version=%d rule1=%v", m.info.Version, m.streaming.Rule1MinTrailingSilence)
+	}
+}
@@ -0,0 +1,95 @@
+package asr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// defaultResumeBuffer 断线重连时最多保留的未确认消息条数
+const defaultResumeBuffer = 256
+
+// ReplayMessage 等待客户端确认的已发送消息
+type ReplayMessage struct {
+	Seq     int64
+	Payload []byte
+}
+
+// replayBuffer 会话的消息序号与未确认消息。序号在整个会话内递增，跨重连保持；
+// 客户端确认某个序号后，该序号及之前的消息不再重发
+type replayBuffer struct {
+	seq     int64
+	acked   int64
+	pending []ReplayMessage
+	limit   int
+	mu      sync.Mutex
+}
+
+func newReplayBuffer(limit int) *replayBuffer {
+	if limit <= 0 {
+		limit = defaultResumeBuffer
+	}
+	return &replayBuffer{limit: limit}
+}
+
+// next 分配下一个序号
+func (b *replayBuffer) next() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	return b.seq
+}
+
+// keep 保留已发送的消息直到被确认，超出上限时丢弃最早的消息
+func (b *replayBuffer) keep(seq int64, payload []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if seq <= b.acked {
+		return
+	}
+	b.pending = append(b.pending, ReplayMessage{Seq: seq, Payload: payload})
+	if over := len(b.pending) - b.limit; over > 0 {
+		b.pending = append(b.pending[:0], b.pending[over:]...)
+	}
+}
+
+// ack 确认 seq 及之前的消息，超过已分配序号的确认按已分配序号处理
+func (b *replayBuffer) ack(seq int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if seq > b.seq {
+		seq = b.seq
+	}
+	if seq <= b.acked {
+		return
+	}
+	b.acked = seq
+	i := 0
+	for i < len(b.pending) && b.pending[i].Seq <= seq {
+		i++
+	}
+	b.pending = append(b.pending[:0], b.pending[i:]...)
+}
+
+// unacked 按序号返回尚未确认的消息
+func (b *replayBuffer) unacked() []ReplayMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]ReplayMessage(nil), b.pending...)
+}
+
+// last 最近分配的序号
+func (b *replayBuffer) last() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq
+}
+
+// newResumeToken 生成重连令牌
+func newResumeToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,52 @@
+package asr
+
+import "testing"
+
+func TestReplayBufferAck(t *testing.T) {
+	b := newReplayBuffer(0)
+	for i := 0; i < 4; i++ {
+		seq := b.next()
+		if seq == 2 {
+			continue // 部分结果不保留
+		}
+		b.keep(seq, []byte{byte(seq)})
+	}
+
+	b.ack(1)
+	got := b.unacked()
+	if len(got) != 2 || got[0].Seq != 3 || got[1].Seq != 4 {
+		t.Fatalf("unacked after ack(1) = %+v, want seq 3 and 4", got)
+	}
+
+	// 重复或过时的确认不影响结果，超过已分配序号的确认视为全部确认
+	b.ack(1)
+	if len(b.unacked()) != 2 {
+		t.Fatalf("stale ack should be ignored")
+	}
+	b.ack(100)
+	if len(b.unacked()) != 0 {
+		t.Fatalf("all messages should be acknowledged")
+	}
+	if seq := b.next(); seq != 5 {
+		t.Fatalf("next seq = %d, want 5", seq)
+	}
+}
+
+func TestReplayBufferLimit(t *testing.T) {
+	b := newReplayBuffer(2)
+	for i := 0; i < 3; i++ {
+		seq := b.next()
+		b.keep(seq, nil)
+	}
+	got := b.unacked()
+	if len(got) != 2 || got[0].Seq != 2 {
+		t.Fatalf("buffer should keep the newest 2 messages, got %+v", got)
+	}
+}
+
+func TestResumeTokenUnique(t *testing.T) {
+	a, b := newResumeToken(), newResumeToken()
+	if len(a) != 48 || a == b {
+		t.Fatalf("tokens should be random 48-char hex strings, got %q and %q", a, b)
+	}
+}
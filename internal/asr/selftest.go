@@ -0,0 +1,59 @@
+package asr
+
+import (
+	"fmt"
+
+	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
+)
+
+// selfTestSamples 自检使用的静音长度（0.5 秒，16kHz）
+const selfTestSamples = 8000
+
+// SelfTest 用默认模型解码一小段静音，验证识别器可以正常工作（不计入统计）
+func (m *OfflineASRManager) SelfTest() error {
+	// 先占用识别器再加解码锁，与 decode 的加锁顺序一致
+	recognizer, _, release, err := m.acquireRecognizer(RecognizeOptions{})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream := sherpa.NewOfflineStream(recognizer)
+	if stream == nil {
+		return fmt.Errorf("failed to create stream")
+	}
+	defer sherpa.DeleteOfflineStream(stream)
+
+	stream.AcceptWaveform(16000, make([]float32, selfTestSamples))
+	recognizer.Decode(stream)
+	if stream.GetResult() == nil {
+		return fmt.Errorf("failed to get recognition result")
+	}
+	return nil
+}
+
+// SelfTest 用默认模型解码一小段静音，验证识别器可以正常工作（不创建会话，不计入统计）
+func (m *StreamingASRManager) SelfTest() error {
+	handle, err := m.models.AcquireOnline("")
+	if err != nil {
+		return err
+	}
+	defer handle.Release()
+
+	recognizer := handle.online
+	stream := sherpa.NewOnlineStream(recognizer)
+	if stream == nil {
+		return fmt.Errorf("failed to create stream")
+	}
+	defer sherpa.DeleteOnlineStream(stream)
+
+	stream.AcceptWaveform(16000, make([]float32, selfTestSamples))
+	for recognizer.IsReady(stream) {
+		recognizer.Decode(stream)
+	}
+	recognizer.GetResult(stream)
+	return nil
+}
@@ -0,0 +1,111 @@
+package asr
+
+import (
+	"time"
+)
+
+// SessionInfo 实时会话概要（管理接口）
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	Model        string    `json:"model"`
+	Status       string    `json:"status"` // active、detached（等待重连）或 closing（已取消，等待连接退出）
+	ClientIP     string    `json:"client_ip"`
+	APIKey       string    `json:"api_key,omitempty"` // 打码后的 API Key
+	StartedAt    time.Time `json:"started_at"`
+	LastActivity time.Time `json:"last_activity"`
+	AudioSeconds float64   `json:"audio_seconds"` // 已接收的音频时长
+	Segments     int       `json:"segments"`      // 已输出的整句数
+	LastPartial  string    `json:"last_partial"`  // 当前句最近的中间结果
+	AvgRTF       float64   `json:"avg_rtf"`       // 平均解码实时率（解码耗时 / 音频时长）
+	Recording    bool      `json:"recording"`     // 正在录音存档
+}
+
+// SessionDetail 实时会话详情（管理接口）
+type SessionDetail struct {
+	SessionInfo
+	LastResult     string          `json:"last_result"` // 最近的整句结果
+	Pipeline       string          `json:"pipeline"`
+	Stages         map[string]bool `json:"stages,omitempty"` // 本会话覆盖的阶段开关
+	StablePrefix   bool            `json:"stable_prefix"`
+	Diarization    bool            `json:"diarization"`
+	RedactionSpans bool            `json:"redaction_spans"`
+	LastSeq        int64           `json:"last_seq"`         // 最近发送的消息序号
+	Unacked        int             `json:"unacked_messages"` // 客户端尚未确认的消息数
+	Resumes        int             `json:"resumes"`          // 断线重连次数
+	CloseReason    string          `json:"close_reason,omitempty"`
+}
+
+// info 会话概要，调用方需持有管理器的锁（读取 detached）
+func (s *StreamingASRSession) info() SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.infoLocked()
+}
+
+func (s *StreamingASRSession) infoLocked() SessionInfo {
+	status := "active"
+	switch {
+	case s.closeReason != "":
+		status = "closing"
+	case s.detached:
+		status = "detached"
+	}
+
+	audioSeconds := float64(s.samples) / 16000
+	var rtf float64
+	if audioSeconds > 0 {
+		rtf = s.decodeTime.Seconds() / audioSeconds
+	}
+	return SessionInfo{
+		ID:           s.ID,
+		Model:        s.Model,
+		Status:       status,
+		ClientIP:     s.clientIP,
+		APIKey:       s.apiKey,
+		StartedAt:    s.started,
+		LastActivity: s.lastActivity,
+		AudioSeconds: audioSeconds,
+		Segments:     s.segment,
+		LastPartial:  s.lastPartial,
+		AvgRTF:       rtf,
+		Recording:    s.recording != nil,
+	}
+}
+
+// detail 会话详情，调用方需持有管理器的锁（读取 detached 与 resumes）
+func (s *StreamingASRSession) detail() SessionDetail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pipeline := s.textOpts.Pipeline
+	if pipeline == "" && s.pipelines != nil {
+		pipeline = s.pipelines.Default()
+	}
+	d := SessionDetail{
+		SessionInfo:    s.infoLocked(),
+		LastResult:     s.lastResult,
+		Pipeline:       pipeline,
+		Stages:         s.textOpts.Stages,
+		StablePrefix:   s.stabilizer != nil,
+		Diarization:    s.embedder != nil,
+		RedactionSpans: s.redactionSpans,
+		Resumes:        s.resumes,
+		CloseReason:    s.closeReason,
+	}
+	if s.replay != nil {
+		d.LastSeq = s.replay.last()
+		d.Unacked = len(s.replay.unacked())
+	}
+	return d
+}
+
+// maskSecret 打码密钥，只保留首尾各 4 个字符
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + "****" + secret[len(secret)-4:]
+}
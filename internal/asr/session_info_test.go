@@ -0,0 +1,57 @@
+package asr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionInfo(t *testing.T) {
+	m, session := newTestSessionManager("s1")
+	session.Model = "zipformer"
+	session.clientIP = "10.0.0.8"
+	session.apiKey = maskSecret("sk-1234567890abcdef")
+	session.samples = 32000
+	session.decodeTime = 500 * time.Millisecond
+	session.segment = 3
+	session.lastPartial = "今天天气"
+	session.lastResult = "你好。"
+	session.replay.keep(session.NextSeq(), nil)
+
+	list := m.ListSessions()
+	if len(list) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(list))
+	}
+	info := list[0]
+	if info.Status != "active" || info.ClientIP != "10.0.0.8" || info.APIKey != "sk-1****cdef" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.AudioSeconds != 2 || info.AvgRTF != 0.25 || info.Segments != 3 || info.LastPartial != "今天天气" {
+		t.Fatalf("unexpected audio stats: %+v", info)
+	}
+
+	detail, ok := m.GetSession("s1")
+	if !ok || detail.LastResult != "你好。" || detail.LastSeq != 1 || detail.Unacked != 1 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if _, ok := m.GetSession("missing"); ok {
+		t.Fatal("missing session should not be found")
+	}
+
+	session.detached = true
+	if got := m.ListSessions()[0].Status; got != "detached" {
+		t.Fatalf("status = %q, want detached", got)
+	}
+	session.cancel(CloseReasonAdmin)
+	if got := m.ListSessions()[0].Status; got != "closing" {
+		t.Fatalf("status = %q, want closing", got)
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	cases := map[string]string{"": "", "short": "****", "abcd1234efgh": "abcd****efgh"}
+	for in, want := range cases {
+		if got := maskSecret(in); got != want {
+			t.Fatalf("maskSecret(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
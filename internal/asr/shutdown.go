@@ -0,0 +1,160 @@
+package asr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"airecorder/internal/audio"
+)
+
+// ErrShuttingDown 服务正在关闭，不再接收新任务或会话
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// drainPollInterval 等待任务或会话结束时的检查间隔
+const drainPollInterval = 100 * time.Millisecond
+
+// UnfinishedTask 关闭时未能完成的离线任务
+type UnfinishedTask struct {
+	ID                string    `json:"id"`
+	RequestID         string    `json:"request_id,omitempty"`
+	Status            string    `json:"status"` // 中止前的状态：pending 或 processing
+	SubmitTime        time.Time `json:"submit_time"`
+	AudioDurationSec  float32   `json:"audio_duration_sec"`
+	SampleRate        int       `json:"sample_rate"`
+	Model             string    `json:"model,omitempty"`
+	Language          string    `json:"language,omitempty"`
+	Pipeline          string    `json:"pipeline,omitempty"`
+	EnableDiarization bool      `json:"enable_diarization"`
+	Progress          float32   `json:"progress"`
+	Audio             string    `json:"audio,omitempty"` // 保存的音频文件（相对于保存目录）
+}
+
+// finished 任务处理结束（或未能入队）后取消登记
+func (tq *TaskQueue) finished(task *ASRTask) {
+	tq.storeMu.Lock()
+	delete(tq.active, task.ID)
+	tq.storeMu.Unlock()
+}
+
+// ActiveTasks 已入队且尚未处理结束的任务数
+func (tq *TaskQueue) ActiveTasks() int {
+	tq.storeMu.RLock()
+	defer tq.storeMu.RUnlock()
+	return len(tq.active)
+}
+
+// Drain 停止接收新任务，等待排队与处理中的任务完成。ctx 结束时仍未完成的任务以
+// ErrShuttingDown 中止（处理中的长音频在当前分块结束后停止），dir 不为空时将其音频与参数
+// 保存到该目录以便重新提交。返回未完成的任务
+func (tq *TaskQueue) Drain(ctx context.Context, dir string) []UnfinishedTask {
+	tq.mu.Lock()
+	tq.closing = true
+	tq.mu.Unlock()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for tq.ActiveTasks() > 0 {
+		select {
+		case <-ctx.Done():
+			return tq.abortActive(dir)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// abortActive 中止全部未完成的任务，按提交时间排序返回
+func (tq *TaskQueue) abortActive(dir string) []UnfinishedTask {
+	tq.storeMu.RLock()
+	tasks := make([]*ASRTask, 0, len(tq.active))
+	for _, task := range tq.active {
+		tasks = append(tasks, task)
+	}
+	tq.storeMu.RUnlock()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].SubmitTime.Before(tasks[j].SubmitTime) })
+
+	unfinished := make([]UnfinishedTask, 0, len(tasks))
+	for _, task := range tasks {
+		info := UnfinishedTask{
+			ID:                task.ID,
+			RequestID:         task.RequestID,
+			Status:            task.GetStatus().String(),
+			SubmitTime:        task.SubmitTime,
+			SampleRate:        task.SampleRate,
+			Model:             task.Model,
+			Language:          task.Language,
+			Pipeline:          task.Text.Pipeline,
+			EnableDiarization: task.EnableDiar,
+			Progress:          task.GetProgress(),
+		}
+		if task.SampleRate > 0 {
+			info.AudioDurationSec = float32(len(task.Samples)) / float32(task.SampleRate)
+		}
+		if !task.abort(ErrShuttingDown) {
+			continue // 恰好已完成
+		}
+		atomic.AddInt64(&tq.stats.failedTasks, 1)
+		if dir != "" {
+			if err := saveUnfinishedTask(dir, task, &info); err != nil {
+				slog.Error("Failed to save unfinished task", "task_id", task.ID, "error", err)
+			}
+		}
+		slog.Warn("Task aborted by shutdown", "task_id", task.ID, "request_id", task.RequestID,
+			"status", info.Status, "progress", info.Progress, "saved", info.Audio != "")
+		unfinished = append(unfinished, info)
+	}
+	return unfinished
+}
+
+// saveUnfinishedTask 将任务音频保存为 <id>.wav，参数保存为 <id>.json
+func saveUnfinishedTask(dir string, task *ASRTask, info *UnfinishedTask) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	audioName := task.ID + ".wav"
+	if err := os.WriteFile(filepath.Join(dir, audioName), audio.EncodeWAV(task.Samples, task.SampleRate), 0o644); err != nil {
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+	info.Audio = audioName
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, task.ID+".json"), data, 0o644)
+}
+
+// Shutdown 停止创建与恢复会话，通知所有连接以 CloseReasonShutdown 断开（等待重连的会话直接关闭），
+// 并等待连接处理方释放会话直到 ctx 结束。返回仍未关闭的会话数
+func (m *StreamingASRManager) Shutdown(ctx context.Context) int {
+	m.mu.Lock()
+	m.draining = true
+	for _, session := range m.sessions {
+		session.cancel(CloseReasonShutdown)
+		if session.detached {
+			m.closeLocked(session)
+		}
+	}
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		remaining := int(m.ActiveSessions())
+		if remaining == 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return remaining
+		case <-ticker.C:
+		}
+	}
+}
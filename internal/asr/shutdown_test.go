@@ -0,0 +1,113 @@
+package asr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newDrainTestQueue() *TaskQueue {
+	return &TaskQueue{
+		queue:     make(chan *ASRTask, 4),
+		taskStore: make(map[string]*ASRTask),
+		active:    make(map[string]*ASRTask),
+	}
+}
+
+func TestDrainAbortsAndSavesUnfinishedTasks(t *testing.T) {
+	tq := newDrainTestQueue()
+	task := NewASRTask(make([]float32, 16000), 16000, nil, false)
+	task.RequestID = "req-1"
+	task.Model = "paraformer"
+	if err := tq.Submit(task); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dir := t.TempDir()
+	unfinished := tq.Drain(ctx, dir)
+
+	if len(unfinished) != 1 || unfinished[0].ID != task.ID || unfinished[0].Status != "pending" {
+		t.Fatalf("unexpected unfinished tasks: %+v", unfinished)
+	}
+	if task.GetStatus() != TaskStatusFailed || !errors.Is(task.Error, ErrShuttingDown) {
+		t.Fatalf("task should be aborted, status=%v error=%v", task.GetStatus(), task.Error)
+	}
+	if result := task.Wait(); !errors.Is(result.Error, ErrShuttingDown) {
+		t.Fatalf("waiting request should receive shutdown error, got %v", result.Error)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, task.ID+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved UnfinishedTask
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.RequestID != "req-1" || saved.Model != "paraformer" || saved.Audio != task.ID+".wav" || saved.AudioDurationSec != 1 {
+		t.Fatalf("unexpected saved task: %+v", saved)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, saved.Audio)); err != nil || fi.Size() != 44+16000*2 {
+		t.Fatalf("audio not saved: %v", err)
+	}
+
+	if err := tq.Submit(NewASRTask(nil, 16000, nil, false)); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("submit after drain should fail, got %v", err)
+	}
+}
+
+func TestDrainWaitsForActiveTasks(t *testing.T) {
+	tq := newDrainTestQueue()
+	task := NewASRTask(make([]float32, 160), 16000, nil, false)
+	if err := tq.Submit(task); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟 worker 处理完成
+	go func() {
+		<-tq.queue
+		task.Complete(&ASRTaskResult{Text: "done"})
+		tq.finished(task)
+	}()
+
+	if unfinished := tq.Drain(context.Background(), ""); len(unfinished) != 0 {
+		t.Fatalf("expected no unfinished tasks, got %+v", unfinished)
+	}
+	if task.GetStatus() != TaskStatusCompleted {
+		t.Fatalf("task should complete, got %v", task.GetStatus())
+	}
+}
+
+func TestCompleteKeepsCancelledTask(t *testing.T) {
+	task := NewASRTask(nil, 16000, nil, false)
+	task.Cancel()
+	task.Complete(&ASRTaskResult{Text: "late result"})
+	if task.GetStatus() != TaskStatusFailed || task.Result != nil {
+		t.Fatalf("cancelled task should stay cancelled, status=%v", task.GetStatus())
+	}
+}
+
+func TestStreamingShutdownClosesSessions(t *testing.T) {
+	m, session := newTestSessionManager("s1")
+
+	// 模拟连接处理方：会话被取消后关闭会话
+	go func() {
+		<-session.Done()
+		m.CloseSession(session.ID)
+	}()
+
+	if remaining := m.Shutdown(context.Background()); remaining != 0 {
+		t.Fatalf("remaining sessions = %d, want 0", remaining)
+	}
+	if session.CloseReason() != CloseReasonShutdown {
+		t.Fatalf("close reason = %q, want %q", session.CloseReason(), CloseReasonShutdown)
+	}
+	if _, err := m.ResumeSession("any"); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("resume during shutdown should fail, got %v", err)
+	}
+}
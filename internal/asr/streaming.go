@@ -1,13 +1,18 @@
 package asr
 
 import (
+	"crypto/subtle"
 	"fmt"
-	"log"
-	"path/filepath"
+	"log/slog"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
+	"unicode"
 
 	"airecorder/internal/config"
+	"airecorder/internal/metrics"
+	"airecorder/internal/recording"
 
 	"github.com/google/uuid"
 	sherpa "github.com/k2-fsa/sherpa-onnx-go/sherpa_onnx"
@@ -15,20 +20,78 @@ import (
 
 // StreamingASRSession 实时识别会话
 type StreamingASRSession struct {
-	ID          string
-	Recognizer  *sherpa.OnlineRecognizer
-	Stream      *sherpa.OnlineStream
-	Punctuation *PunctuationManager
-	mu          sync.Mutex
+	ID             string
+	Model          string
+	Recognizer     *sherpa.OnlineRecognizer
+	Stream         *sherpa.OnlineStream
+	Punctuation    *PunctuationManager
+	pipelines      *TextPipelines
+	textOpts       TextOptions
+	partialOpts    TextOptions             // 中间结果使用的后处理参数（不加标点）
+	stabilizer     *partialStabilizer      // 为 nil 时不计算稳定前缀
+	model          *ModelHandle            // 会话占用的模型版本，关闭会话时释放
+	samples        int64                   // 已接收的样本数
+	segStart       int64                   // 当前句开始时的样本数
+	embedder       *SpeakerEmbedder        // 为 nil 时不标注说话人
+	speakers       *OnlineSpeakerClusterer // 会话内的在线说话人聚类
+	segAudio       []float32               // 当前句的音频（用于提取说话人特征）
+	redactionSpans bool                    // 结果附带脱敏位置
+	segment        int                     // 已输出的整句数（跨重连保持）
+	replay         *replayBuffer           // 消息序号与未确认消息
+	resumeToken    string                  // 重连令牌，每次重连后更换（由管理器加锁访问）
+	detached       bool                    // 连接已断开，等待重连
+	detachTimer    *time.Timer             // 重连宽限期结束时关闭会话
+	started        time.Time               // 会话创建时间
+	done           chan struct{}           // 会话被取消时关闭，连接处理方据此断开连接
+	cancelOnce     sync.Once
+	closeReason    string               // 取消原因
+	released       bool                 // 识别流已释放
+	clientIP       string               // 客户端地址
+	apiKey         string               // 客户端 API Key（已打码）
+	lastActivity   time.Time            // 最近收到客户端消息的时间
+	decodeTime     time.Duration        // 累计解码耗时，用于计算实时率
+	lastPartial    string               // 最近的中间结果
+	lastResult     string               // 最近的整句结果
+	resumes        int                  // 断线重连次数（由管理器加锁访问）
+	audioFrames    *int64               // 管理器的音频帧计数
+	hub            *watchHub            // 旁观者订阅
+	recording      *recording.Recording // 录音，未录音时为 nil
+	mu             sync.Mutex
 }
 
-// ProcessAudio 处理音频数据并返回识别结果
-func (s *StreamingASRSession) ProcessAudio(samples []float32) (string, bool, error) {
+// StreamingResult 一次解码后的识别结果
+type StreamingResult struct {
+	Text         string // 端点时为完整后处理（含标点）的整句结果，否则为不加标点的中间结果
+	StablePrefix string // 中间结果中已稳定、后续不太会再变化的前缀（需开启稳定前缀）
+	IsEndpoint   bool
+}
+
+// ProcessAudio 处理音频数据并返回识别结果。中间结果不加标点，
+// 标点模型只在端点处对整句执行一次，避免每个中间结果都占用共享的标点模型并导致标点闪烁
+func (s *StreamingASRSession) ProcessAudio(samples []float32) (StreamingResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.released {
+		return StreamingResult{}, fmt.Errorf("session closed")
+	}
+
 	// 接受音频数据
+	begin := time.Now()
+	if s.audioFrames != nil {
+		atomic.AddInt64(s.audioFrames, 1)
+	}
 	s.Stream.AcceptWaveform(16000, samples)
+	s.samples += int64(len(samples))
+	if s.recording != nil {
+		s.recording.WriteAudio(samples)
+	}
+	if s.embedder != nil {
+		s.segAudio = append(s.segAudio, samples...)
+		if over := len(s.segAudio) - maxSpeakerSegmentSec*16000; over > 0 {
+			s.segAudio = append(s.segAudio[:0], s.segAudio[over:]...)
+		}
+	}
 
 	// 解码
 	for s.Recognizer.IsReady(s.Stream) {
@@ -36,18 +99,30 @@ func (s *StreamingASRSession) ProcessAudio(samples []float32) (string, bool, err
 	}
 
 	// 获取结果
-	result := s.Recognizer.GetResult(s.Stream)
-	text := result.Text
-
-	// 如果启用了标点符号且文本不为空，添加标点符号
-	if text != "" && s.Punctuation != nil {
-		text = s.Punctuation.AddPunctuation(text)
+	raw := s.Recognizer.GetResult(s.Stream).Text
+	elapsed := time.Since(begin)
+	s.decodeTime += elapsed
+	if seconds := float64(len(samples)) / 16000; seconds > 0 {
+		metrics.AudioSeconds.Add(seconds, "streaming", s.Model)
+		metrics.DecodeRTF.Observe(elapsed.Seconds()/seconds, "streaming", s.Model)
 	}
 
 	// 检查是否是端点
-	isEndpoint := s.Recognizer.IsEndpoint(s.Stream)
+	if s.Recognizer.IsEndpoint(s.Stream) {
+		result := StreamingResult{Text: s.pipelines.Process(raw, s.textOpts), IsEndpoint: true}
+		if result.Text != "" {
+			s.lastResult = result.Text
+		}
+		s.lastPartial = ""
+		return result, nil
+	}
 
-	return text, isEndpoint, nil
+	result := StreamingResult{Text: s.pipelines.Process(raw, s.partialOpts)}
+	if s.stabilizer != nil {
+		result.StablePrefix = s.stabilizer.update(result.Text)
+	}
+	s.lastPartial = result.Text
+	return result, nil
 }
 
 // Reset 重置会话
@@ -55,103 +130,404 @@ func (s *StreamingASRSession) Reset() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.released {
+		return
+	}
 	s.Recognizer.Reset(s.Stream)
+	s.segStart = s.samples
+	s.segAudio = s.segAudio[:0]
+	s.stabilizer.reset()
+}
+
+// AssignSpeaker 为刚结束的一句标注说话人，需在 Reset 之前调用；
+// 未开启说话人标注时返回 false，音频过短无法判断时返回 -1
+func (s *StreamingASRSession) AssignSpeaker() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.embedder == nil {
+		return 0, false
+	}
+	if s.released {
+		return -1, true
+	}
+	emb, err := s.embedder.Compute(s.segAudio, 16000)
+	if err != nil {
+		emb = nil
+	}
+	return s.speakers.Assign(emb), true
+}
+
+// SpeakerSummary 用整个会话的说话人特征重新聚类后的各句说话人，未开启说话人标注时返回 false
+func (s *StreamingASRSession) SpeakerSummary() ([]SegmentSpeaker, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.embedder == nil {
+		return nil, false
+	}
+	return s.speakers.Refine(), true
+}
+
+// Redactions 当前句结果中的脱敏位置，时间按本句音频线性估计（相对会话开始），需在 Reset 之前调用；
+// 创建会话时未要求脱敏位置时返回 nil
+func (s *StreamingASRSession) Redactions(text string) []Redaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.redactionSpans {
+		return nil
+	}
+	spans := s.pipelines.Redactions(text, s.textOpts)
+	alignRedactions(spans, text, nil, nil, float32(s.samples-s.segStart)/16000)
+	return shiftRedactions(spans, 0, float32(s.segStart)/16000)
+}
+
+// Touch 记录收到客户端消息
+func (s *StreamingASRSession) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastActivity = time.Now()
+}
+
+// StartedAt 会话创建时间
+func (s *StreamingASRSession) StartedAt() time.Time {
+	return s.started
+}
+
+// Done 会话被取消（如管理员关闭）时关闭的通道
+func (s *StreamingASRSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// CloseReason 会话被取消的原因，未取消时为空
+func (s *StreamingASRSession) CloseReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeReason
+}
+
+// cancel 通知连接处理方结束会话，识别流由其退出后关闭会话时释放
+func (s *StreamingASRSession) cancel(reason string) {
+	s.cancelOnce.Do(func() {
+		s.mu.Lock()
+		s.closeReason = reason
+		s.mu.Unlock()
+		close(s.done)
+	})
+}
+
+// release 释放识别流与模型。持有会话锁，不会与正在进行的解码同时发生，之后的调用直接返回错误
+func (s *StreamingASRSession) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return
+	}
+	s.released = true
+	if s.Stream != nil {
+		sherpa.DeleteOnlineStream(s.Stream)
+		s.Stream = nil
+	}
+	if s.model != nil {
+		s.model.Release()
+	}
+	if s.recording != nil {
+		s.recording.Close()
+	}
+}
+
+// NextSegment 开始新的一句并返回其序号（从 1 开始）
+func (s *StreamingASRSession) NextSegment() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.segment++
+	return s.segment
+}
+
+// Segment 已输出的整句数，中间结果属于下一句之前的这一序号
+func (s *StreamingASRSession) Segment() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.segment
+}
+
+// NextSeq 为下一条发送的消息分配序号
+func (s *StreamingASRSession) NextSeq() int64 {
+	return s.replay.next()
+}
+
+// LastSeq 最近分配的消息序号
+func (s *StreamingASRSession) LastSeq() int64 {
+	return s.replay.last()
+}
+
+// Keep 保留已发送的消息，客户端确认前断线重连时会重发
+func (s *StreamingASRSession) Keep(seq int64, payload []byte) {
+	s.replay.keep(seq, payload)
+}
+
+// Ack 客户端确认已收到 seq 及之前的消息
+func (s *StreamingASRSession) Ack(seq int64) {
+	s.replay.ack(seq)
+}
+
+// Unacked 尚未确认的消息，重连后按序重发
+func (s *StreamingASRSession) Unacked() []ReplayMessage {
+	return s.replay.unacked()
 }
 
 // StreamingASRManager 实时识别管理器
 type StreamingASRManager struct {
 	config      *config.Config
-	recognizer  *sherpa.OnlineRecognizer
+	models      *ModelRegistry
+	ownsModels  bool // 注册表由本管理器创建，关闭时一并释放
 	punctuation *PunctuationManager
+	pipelines   *TextPipelines
+	embedder    *SpeakerEmbedder // 实时说话人标注，未启用时为 nil
+	hub         *watchHub        // 会话旁观事件
+	recordings  *recording.Store // 会话录音存档，未启用时为 nil
 	sessions    map[string]*StreamingASRSession
+	draining    bool         // 服务正在关闭，不再创建或恢复会话（由 mu 保护）
+	maxSessions atomic.Int64 // concurrency.max_streaming_sessions，可运行中调整
 	mu          sync.RWMutex
 	stats       struct {
 		activeSessions   int64
 		totalSessions    int64
 		totalAudioFrames int64
+		resumedSessions  int64
+		expiredSessions  int64
+		idleTimeouts     int64
+		maxDurations     int64
+		keepaliveLosses  int64
+		adminCloses      int64
 	}
 }
 
+// SessionOptions 创建会话的可选参数
+type SessionOptions struct {
+	Model          string      // 指定模型名称，为空时使用默认模型
+	Hotwords       []Hotword   // 会话热词（需 transducer 模型）
+	Text           TextOptions // 文本后处理流水线及阶段开关
+	StablePrefix   bool        // 中间结果附带稳定前缀
+	Diarization    bool        // 为每句结果标注说话人（需 speaker_diarization.streaming.enabled）
+	RedactionSpans bool        // 整句结果附带脱敏位置（需 redact 阶段）
+	ClientIP       string      // 客户端地址
+	APIKey         string      // 客户端 API Key，仅保存打码后的值用于展示
+	Record         bool        // 录音存档（需 recording.enabled），recording.api_keys 中的 API Key 总是录音
+}
+
 // NewStreamingASRManager 创建实时识别管理器
-func NewStreamingASRManager(cfg *config.Config) *StreamingASRManager {
-	log.Println("Initializing Streaming ASR Manager...")
+func NewStreamingASRManager(cfg *config.Config) (*StreamingASRManager, error) {
+	models := NewModelRegistry(cfg, config.ModelTypeStreaming)
+	m, err := NewStreamingASRManagerWithRegistry(cfg, models)
+	if err != nil {
+		models.Close()
+		return nil, err
+	}
+	m.ownsModels = true
+	return m, nil
+}
+
+// NewStreamingASRManagerWithRegistry 使用共享的模型注册表创建实时识别管理器，
+// 默认模型不可用或文本流水线配置错误时返回错误
+func NewStreamingASRManagerWithRegistry(cfg *config.Config, models *ModelRegistry) (*StreamingASRManager, error) {
+	slog.Info("Initializing Streaming ASR Manager...")
+
+	// 检查默认识别器
+	name, err := models.ResolveName(config.ModelTypeStreaming, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create online recognizer: %w", err)
+	}
+	slog.Info("Default streaming model", "model", name)
+
+	// 创建标点符号管理器
+	punctMgr := NewPunctuationManager(cfg)
+
+	pipelines, err := NewTextPipelines(cfg, punctMgr)
+	if err != nil {
+		punctMgr.Close()
+		return nil, fmt.Errorf("failed to create text pipelines: %w", err)
+	}
+
+	slog.Info("Streaming ASR Manager initialized successfully")
+
+	m := &StreamingASRManager{
+		config:      cfg,
+		models:      models,
+		punctuation: punctMgr,
+		pipelines:   pipelines,
+		embedder:    NewSpeakerEmbedder(cfg),
+		hub:         newWatchHub(),
+		recordings:  recording.NewStore(cfg),
+		sessions:    make(map[string]*StreamingASRSession),
+	}
+	m.maxSessions.Store(int64(cfg.Concurrency.MaxStreamingSessions))
+	return m, nil
+}
+
+// MaxSessions 最大并发会话数
+func (m *StreamingASRManager) MaxSessions() int {
+	return int(m.maxSessions.Load())
+}
+
+// SetMaxSessions 调整最大并发会话数。调小时已有会话不受影响，活跃会话数降到上限以下后才接受新会话
+func (m *StreamingASRManager) SetMaxSessions(n int) {
+	m.maxSessions.Store(int64(n))
+}
 
-	// 构建模型路径
-	modelsDir := cfg.StreamingASR.ModelsDir
-	encoderPath := filepath.Join(modelsDir, cfg.StreamingASR.Encoder)
-	decoderPath := filepath.Join(modelsDir, cfg.StreamingASR.Decoder)
-	joinerPath := filepath.Join(modelsDir, cfg.StreamingASR.Joiner)
-	tokensPath := filepath.Join(modelsDir, cfg.StreamingASR.Tokens)
+// sherpaOnlineModelTypes model_type 与 sherpa-onnx 模型类型提示的对应关系，
+// 未列出的类型留空由 sherpa-onnx 根据已填写的模型自动判断
+var sherpaOnlineModelTypes = map[string]string{
+	config.StreamingModelZipformer:  "zipformer",
+	config.StreamingModelZipformer2: "zipformer2",
+	config.StreamingModelConformer:  "conformer",
+	config.StreamingModelLSTM:       "lstm",
+	config.StreamingModelNemoCTC:    "nemo_ctc",
+}
+
+// newOnlineRecognizer 根据模型配置创建实时识别器，模型文件缺失时返回列出缺失文件的错误
+func newOnlineRecognizer(sc config.StreamingASRConfig) (*sherpa.OnlineRecognizer, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	files, _ := sc.ModelFiles()
+	path := make(map[string]string, len(files))
+	for _, f := range files {
+		path[f.Field] = f.Path
+	}
 
 	// 创建识别器配置
 	recognizerConfig := sherpa.OnlineRecognizerConfig{}
 
 	// 特征配置
-	recognizerConfig.FeatConfig.SampleRate = cfg.StreamingASR.SampleRate
-	recognizerConfig.FeatConfig.FeatureDim = cfg.StreamingASR.FeatureDim
+	recognizerConfig.FeatConfig.SampleRate = sc.SampleRate
+	recognizerConfig.FeatConfig.FeatureDim = sc.FeatureDim
 
 	// 模型配置
-	recognizerConfig.ModelConfig.Transducer.Encoder = encoderPath
-	recognizerConfig.ModelConfig.Transducer.Decoder = decoderPath
-	recognizerConfig.ModelConfig.Transducer.Joiner = joinerPath
-	recognizerConfig.ModelConfig.Tokens = tokensPath
-	recognizerConfig.ModelConfig.NumThreads = cfg.StreamingASR.NumThreads
+	switch sc.ModelType {
+	case config.StreamingModelParaformer:
+		recognizerConfig.ModelConfig.Paraformer.Encoder = path["paraformer.encoder"]
+		recognizerConfig.ModelConfig.Paraformer.Decoder = path["paraformer.decoder"]
+	case config.StreamingModelZipformer2CTC:
+		recognizerConfig.ModelConfig.Zipformer2Ctc.Model = path["zipformer2_ctc.model"]
+	case config.StreamingModelNemoCTC:
+		recognizerConfig.ModelConfig.NemoCtc.Model = path["nemo_ctc.model"]
+	default:
+		recognizerConfig.ModelConfig.Transducer.Encoder = path["encoder"]
+		recognizerConfig.ModelConfig.Transducer.Decoder = path["decoder"]
+		recognizerConfig.ModelConfig.Transducer.Joiner = path["joiner"]
+	}
+	recognizerConfig.ModelConfig.Tokens = path["tokens"]
+	recognizerConfig.ModelConfig.NumThreads = sc.NumThreads
 	recognizerConfig.ModelConfig.Provider = "cpu"
 	recognizerConfig.ModelConfig.Debug = 0
-	recognizerConfig.ModelConfig.ModelType = cfg.StreamingASR.ModelType
+	recognizerConfig.ModelConfig.ModelType = sherpaOnlineModelTypes[sc.ModelType]
+	recognizerConfig.ModelConfig.ModelingUnit = sc.ModelingUnit
+	recognizerConfig.ModelConfig.BpeVocab = config.ResolveModelPath(sc.ModelsDir, sc.BpeVocab)
+
+	// 解码配置，热词仅对 transducer 生效
+	recognizerConfig.DecodingMethod = sc.DecodingMethod
+	recognizerConfig.MaxActivePaths = sc.MaxActivePaths
+	if sc.SupportsHotwords() {
+		recognizerConfig.HotwordsFile = config.ResolveModelPath(sc.ModelsDir, sc.HotwordsFile)
+		recognizerConfig.HotwordsScore = sc.HotwordsScore
+	}
 
 	// 端点检测配置
-	if cfg.StreamingASR.EnableEndpoint {
-		recognizerConfig.Rule1MinTrailingSilence = cfg.StreamingASR.Rule1MinTrailingSilence
-		recognizerConfig.Rule2MinTrailingSilence = cfg.StreamingASR.Rule2MinTrailingSilence
-		recognizerConfig.Rule3MinUtteranceLength = cfg.StreamingASR.Rule3MinUtteranceLength
+	if sc.EnableEndpoint {
+		recognizerConfig.Rule1MinTrailingSilence = sc.Rule1MinTrailingSilence
+		recognizerConfig.Rule2MinTrailingSilence = sc.Rule2MinTrailingSilence
+		recognizerConfig.Rule3MinUtteranceLength = sc.Rule3MinUtteranceLength
 		recognizerConfig.EnableEndpoint = 1
 	} else {
 		recognizerConfig.EnableEndpoint = 0
 	}
 
-	// 创建识别器
 	recognizer := sherpa.NewOnlineRecognizer(&recognizerConfig)
 	if recognizer == nil {
-		log.Fatal("Failed to create online recognizer")
+		return nil, fmt.Errorf("sherpa-onnx failed to create recognizer (model_type=%s)", sc.ModelType)
 	}
+	return recognizer, nil
+}
 
-	log.Println("Streaming ASR Manager initialized successfully")
+// CreateSession 创建新的识别会话（使用默认模型）
+func (m *StreamingASRManager) CreateSession() (*StreamingASRSession, error) {
+	return m.CreateSessionWithOptions(SessionOptions{})
+}
 
-	// 创建标点符号管理器
-	punctMgr := NewPunctuationManager(cfg)
+// CreateSessionWithOptions 按指定参数创建新的识别会话
+func (m *StreamingASRManager) CreateSessionWithOptions(opts SessionOptions) (*StreamingASRSession, error) {
+	if opts.Diarization && m.embedder == nil {
+		return nil, fmt.Errorf("live speaker diarization is not enabled")
+	}
 
-	return &StreamingASRManager{
-		config:      cfg,
-		recognizer:  recognizer,
-		punctuation: punctMgr,
-		sessions:    make(map[string]*StreamingASRSession),
+	// 占用模型当前版本，热加载后本会话继续使用旧版本直到结束。
+	// 带热词时可能需要加载新识别器，因此在加锁前完成
+	handle, err := m.models.AcquireOnlineWithHotwords(opts.Model, opts.Hotwords)
+	if err != nil {
+		return nil, err
 	}
-}
 
-// CreateSession 创建新的识别会话
-func (m *StreamingASRManager) CreateSession() (*StreamingASRSession, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.draining {
+		handle.Release()
+		return nil, ErrShuttingDown
+	}
+
 	// 检查并发限制
-	if int(atomic.LoadInt64(&m.stats.activeSessions)) >= m.config.Concurrency.MaxStreamingSessions {
+	if atomic.LoadInt64(&m.stats.activeSessions) >= m.maxSessions.Load() {
+		handle.Release()
 		return nil, fmt.Errorf("maximum concurrent sessions reached")
 	}
+	recognizer := handle.online
+	modelName := handle.name
 
 	sessionID := uuid.New().String()
 
 	// 创建流
-	stream := sherpa.NewOnlineStream(m.recognizer)
+	stream := sherpa.NewOnlineStream(recognizer)
 	if stream == nil {
+		handle.Release()
 		return nil, fmt.Errorf("failed to create stream")
 	}
 
 	session := &StreamingASRSession{
-		ID:          sessionID,
-		Recognizer:  m.recognizer,
-		Stream:      stream,
-		Punctuation: m.punctuation,
+		ID:             sessionID,
+		Model:          modelName,
+		Recognizer:     recognizer,
+		Stream:         stream,
+		Punctuation:    m.punctuation,
+		pipelines:      m.pipelines,
+		textOpts:       opts.Text,
+		partialOpts:    partialTextOptions(opts.Text),
+		model:          handle,
+		redactionSpans: opts.RedactionSpans,
+		replay:         newReplayBuffer(m.config.StreamingASR.ResumeBuffer),
+		resumeToken:    newResumeToken(),
+		started:        time.Now(),
+		clientIP:       opts.ClientIP,
+		apiKey:         maskSecret(opts.APIKey),
+		audioFrames:    &m.stats.totalAudioFrames,
+		hub:            m.hub,
+		done:           make(chan struct{}),
+	}
+	session.lastActivity = session.started
+	if opts.StablePrefix {
+		session.stabilizer = newPartialStabilizer(m.config.StreamingASR.StablePrefixPartials)
+	}
+	if opts.Diarization {
+		session.embedder = m.embedder
+		session.speakers = NewOnlineSpeakerClusterer(m.embedder.cfg.Threshold, m.embedder.cfg.MaxSpeakers)
+	}
+
+	if opts.Record || m.recordings.RecordsKey(opts.APIKey) {
+		if err := m.startRecordingLocked(session); err != nil {
+			slog.Warn("Failed to record session", "session_id", sessionID, "error", err)
+		}
 	}
 
 	m.sessions[sessionID] = session
@@ -159,22 +535,253 @@ func (m *StreamingASRManager) CreateSession() (*StreamingASRSession, error) {
 	atomic.AddInt64(&m.stats.activeSessions, 1)
 	atomic.AddInt64(&m.stats.totalSessions, 1)
 
-	log.Printf("Created streaming session: %s (active: %d)", sessionID, atomic.LoadInt64(&m.stats.activeSessions))
+	slog.Info("Created streaming session", "session_id", sessionID, "model", modelName,
+		"client_ip", opts.ClientIP, "active", atomic.LoadInt64(&m.stats.activeSessions))
 
 	return session, nil
 }
 
+// StartRecording 开始为会话录音存档，之后收到的音频与识别结果写入录音，已在录音时不重复开始
+func (m *StreamingASRManager) StartRecording(sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("session not found")
+	}
+	return m.startRecordingLocked(session)
+}
+
+// startRecordingLocked 调用方需持有 m.mu
+func (m *StreamingASRManager) startRecordingLocked(session *StreamingASRSession) error {
+	if m.recordings == nil {
+		return fmt.Errorf("recording is not enabled")
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.recording != nil {
+		return nil
+	}
+	rec, err := m.recordings.Start(session.ID, map[string]string{
+		"session_id": session.ID,
+		"model":      session.Model,
+		"client_ip":  session.clientIP,
+		"api_key":    session.apiKey,
+	})
+	if err != nil {
+		return err
+	}
+	session.recording = rec
+	return nil
+}
+
+// Recordings 会话录音存档，未启用时为 nil
+func (m *StreamingASRManager) Recordings() *recording.Store {
+	return m.recordings
+}
+
+// ResolvePipeline 检查文本后处理流水线是否存在，为空表示默认流水线
+func (m *StreamingASRManager) ResolvePipeline(name string) error {
+	_, err := m.pipelines.Get(name)
+	return err
+}
+
+// ResolveHotwords 合并命名热词表与请求中的热词
+func (m *StreamingASRManager) ResolveHotwords(listName string, inline []Hotword) ([]Hotword, error) {
+	return m.models.ResolveHotwords(listName, inline)
+}
+
+// SetSessionHotwords 为会话切换到带指定热词的识别器。
+// 切换会重建识别流，尚未输出的识别状态将被丢弃，应在发送音频前调用
+func (m *StreamingASRManager) SetSessionHotwords(sessionID string, hotwords []Hotword) error {
+	m.mu.RLock()
+	session, exists := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("session not found")
+	}
+
+	handle, err := m.models.AcquireOnlineWithHotwords(session.Model, hotwords)
+	if err != nil {
+		return err
+	}
+	stream := sherpa.NewOnlineStream(handle.online)
+	if stream == nil {
+		handle.Release()
+		return fmt.Errorf("failed to create stream")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.sessions[sessionID]; !exists {
+		sherpa.DeleteOnlineStream(stream)
+		handle.Release()
+		return fmt.Errorf("session not found")
+	}
+
+	session.mu.Lock()
+	if session.released {
+		session.mu.Unlock()
+		sherpa.DeleteOnlineStream(stream)
+		handle.Release()
+		return fmt.Errorf("session closed")
+	}
+	oldStream, oldHandle := session.Stream, session.model
+	session.Recognizer = handle.online
+	session.Stream = stream
+	session.model = handle
+	session.segStart = session.samples
+	session.segAudio = session.segAudio[:0]
+	session.stabilizer.reset()
+	session.mu.Unlock()
+
+	sherpa.DeleteOnlineStream(oldStream)
+	oldHandle.Release()
+
+	slog.Info("Streaming session switched hotwords", "session_id", sessionID, "hotwords", len(hotwords), "model", session.Model)
+	return nil
+}
+
 // CloseSession 关闭识别会话
 func (m *StreamingASRManager) CloseSession(sessionID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if session, exists := m.sessions[sessionID]; exists {
-		sherpa.DeleteOnlineStream(session.Stream)
-		delete(m.sessions, sessionID)
-		atomic.AddInt64(&m.stats.activeSessions, -1)
-		log.Printf("Closed streaming session: %s (active: %d)", sessionID, atomic.LoadInt64(&m.stats.activeSessions))
+		m.closeLocked(session)
+		slog.Info("Closed streaming session", "session_id", sessionID, "active", atomic.LoadInt64(&m.stats.activeSessions))
+	}
+}
+
+// closeLocked 释放会话资源，调用方需持有 m.mu
+func (m *StreamingASRManager) closeLocked(session *StreamingASRSession) {
+	if session.detachTimer != nil {
+		session.detachTimer.Stop()
+	}
+	session.release()
+	if m.hub != nil {
+		m.hub.closeSession(session.ID)
+	}
+	delete(m.sessions, session.ID)
+	atomic.AddInt64(&m.stats.activeSessions, -1)
+}
+
+// ResumeToken 会话当前的重连令牌
+func (m *StreamingASRManager) ResumeToken(session *StreamingASRSession) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return session.resumeToken
+}
+
+// 服务端主动结束连接的原因
+const (
+	CloseReasonIdleTimeout = "idle_timeout" // 长时间未收到音频
+	CloseReasonMaxDuration = "max_duration" // 超过最长连接时长
+	CloseReasonKeepalive   = "keepalive"    // 未收到 pong，连接可能已半开
+	CloseReasonAdmin       = "admin"        // 管理员关闭
+	CloseReasonShutdown    = "shutdown"     // 服务关闭
+)
+
+// SessionLimits 实时连接的保活与时长限制，为 0 的项不生效
+type SessionLimits struct {
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	IdleTimeout  time.Duration
+	MaxDuration  time.Duration
+}
+
+// SessionLimits 按 streaming_asr 配置返回连接限制
+func (m *StreamingASRManager) SessionLimits() SessionLimits {
+	sc := m.config.StreamingASR
+	return SessionLimits{
+		PingInterval: time.Duration(sc.PingIntervalSec) * time.Second,
+		PongTimeout:  time.Duration(sc.PongTimeoutSec) * time.Second,
+		IdleTimeout:  time.Duration(sc.IdleTimeoutSec) * time.Second,
+		MaxDuration:  time.Duration(sc.MaxSessionSec) * time.Second,
+	}
+}
+
+// RecordClose 统计服务端主动结束连接的原因
+func (m *StreamingASRManager) RecordClose(reason string) {
+	switch reason {
+	case CloseReasonIdleTimeout:
+		atomic.AddInt64(&m.stats.idleTimeouts, 1)
+	case CloseReasonMaxDuration:
+		atomic.AddInt64(&m.stats.maxDurations, 1)
+	case CloseReasonKeepalive:
+		atomic.AddInt64(&m.stats.keepaliveLosses, 1)
+	case CloseReasonAdmin:
+		atomic.AddInt64(&m.stats.adminCloses, 1)
+	}
+}
+
+// ResumeEnabled 是否开启断线重连（streaming_asr.resume_grace_sec > 0）
+func (m *StreamingASRManager) ResumeEnabled() bool {
+	return m.config.StreamingASR.ResumeGraceSec > 0
+}
+
+// DetachSession 连接意外断开时保留会话等待重连（streaming_asr.resume_grace_sec），
+// 宽限期内未重连则关闭会话。未开启重连或会话不存在时返回 false，调用方应直接关闭会话
+func (m *StreamingASRManager) DetachSession(sessionID string) bool {
+	grace := time.Duration(m.config.StreamingASR.ResumeGraceSec) * time.Second
+	if grace <= 0 {
+		return false
 	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists || session.CloseReason() != "" {
+		return false
+	}
+	session.detached = true
+	session.detachTimer = time.AfterFunc(grace, func() {
+		m.expireSession(session)
+	})
+	slog.Info("Streaming session detached, waiting for reconnect", "session_id", sessionID, "grace", grace.String())
+	return true
+}
+
+// expireSession 重连宽限期结束，会话仍未重连时关闭
+func (m *StreamingASRManager) expireSession(session *StreamingASRSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sessions[session.ID] != session || !session.detached {
+		return
+	}
+	m.closeLocked(session)
+	atomic.AddInt64(&m.stats.expiredSessions, 1)
+	slog.Info("Streaming session expired without reconnect", "session_id", session.ID, "active", atomic.LoadInt64(&m.stats.activeSessions))
+}
+
+// ResumeSession 按重连令牌重新接管已断开的会话，成功后令牌更换为新值
+func (m *StreamingASRManager) ResumeSession(token string) (*StreamingASRSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.draining {
+		return nil, ErrShuttingDown
+	}
+	for _, session := range m.sessions {
+		if subtle.ConstantTimeCompare([]byte(session.resumeToken), []byte(token)) != 1 {
+			continue
+		}
+		if !session.detached {
+			return nil, fmt.Errorf("session is still connected")
+		}
+		session.detachTimer.Stop()
+		session.detachTimer = nil
+		session.detached = false
+		session.resumeToken = newResumeToken()
+		session.resumes++
+		atomic.AddInt64(&m.stats.resumedSessions, 1)
+		slog.Info("Streaming session resumed", "session_id", session.ID, "resumes", session.resumes)
+		return session, nil
+	}
+	return nil, fmt.Errorf("invalid or expired resume token")
 }
 
 // GetStats 获取统计信息
@@ -183,25 +790,49 @@ func (m *StreamingASRManager) GetStats() map[string]interface{} {
 		"active_sessions":    atomic.LoadInt64(&m.stats.activeSessions),
 		"total_sessions":     atomic.LoadInt64(&m.stats.totalSessions),
 		"total_audio_frames": atomic.LoadInt64(&m.stats.totalAudioFrames),
+		"resumed_sessions":   atomic.LoadInt64(&m.stats.resumedSessions),
+		"expired_sessions":   atomic.LoadInt64(&m.stats.expiredSessions),
+		"close_reasons": map[string]int64{
+			CloseReasonIdleTimeout: atomic.LoadInt64(&m.stats.idleTimeouts),
+			CloseReasonMaxDuration: atomic.LoadInt64(&m.stats.maxDurations),
+			CloseReasonKeepalive:   atomic.LoadInt64(&m.stats.keepaliveLosses),
+			CloseReasonAdmin:       atomic.LoadInt64(&m.stats.adminCloses),
+		},
 	}
 }
 
-// ListSessions 返回当前活跃会话列表
-func (m *StreamingASRManager) ListSessions() []map[string]interface{} {
+// ActiveSessions 当前会话数（含等待重连的会话）
+func (m *StreamingASRManager) ActiveSessions() int64 {
+	return atomic.LoadInt64(&m.stats.activeSessions)
+}
+
+// ListSessions 返回当前会话列表，按创建时间排序
+func (m *StreamingASRManager) ListSessions() []SessionInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	result := make([]map[string]interface{}, 0, len(m.sessions))
-	for id := range m.sessions {
-		result = append(result, map[string]interface{}{
-			"id":     id,
-			"status": "active",
-		})
+	result := make([]SessionInfo, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		result = append(result, session.info())
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.Before(result[j].StartedAt) })
 	return result
 }
 
-// CloseSessionByAdmin 由管理员强制关闭指定会话
+// GetSession 返回指定会话的详细信息
+func (m *StreamingASRManager) GetSession(sessionID string) (SessionDetail, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return SessionDetail{}, false
+	}
+	return session.detail(), true
+}
+
+// CloseSessionByAdmin 由管理员强制关闭指定会话。有连接的会话只通知连接处理方断开，
+// 识别流在其退出后释放；等待重连的会话直接关闭
 func (m *StreamingASRManager) CloseSessionByAdmin(sessionID string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -210,10 +841,14 @@ func (m *StreamingASRManager) CloseSessionByAdmin(sessionID string) bool {
 	if !exists {
 		return false
 	}
-	sherpa.DeleteOnlineStream(session.Stream)
-	delete(m.sessions, sessionID)
-	atomic.AddInt64(&m.stats.activeSessions, -1)
-	log.Printf("Admin closed streaming session: %s (active: %d)", sessionID, atomic.LoadInt64(&m.stats.activeSessions))
+	session.cancel(CloseReasonAdmin)
+	if session.detached {
+		m.closeLocked(session)
+		m.RecordClose(CloseReasonAdmin)
+		slog.Info("Admin closed detached streaming session", "session_id", sessionID, "active", atomic.LoadInt64(&m.stats.activeSessions))
+		return true
+	}
+	slog.Info("Admin closing streaming session", "session_id", sessionID)
 	return true
 }
 
@@ -222,21 +857,88 @@ func (m *StreamingASRManager) Close() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	log.Println("Closing Streaming ASR Manager...")
+	slog.Info("Closing Streaming ASR Manager...")
 
 	// 关闭所有会话
-	for sessionID, session := range m.sessions {
-		sherpa.DeleteOnlineStream(session.Stream)
-		delete(m.sessions, sessionID)
+	for _, session := range m.sessions {
+		m.closeLocked(session)
 	}
 
 	// 关闭标点符号管理器
 	if m.punctuation != nil {
 		m.punctuation.Close()
 	}
+	m.embedder.Close()
+	m.recordings.Close()
 
-	// 删除识别器
-	sherpa.DeleteOnlineRecognizer(m.recognizer)
+	// 释放模型
+	if m.ownsModels {
+		m.models.Close()
+	}
+
+	slog.Info("Streaming ASR Manager closed")
+}
+
+// partialTextOptions 中间结果的后处理参数：在会话参数基础上关闭标点
+func partialTextOptions(opts TextOptions) TextOptions {
+	partial := TextOptions{Pipeline: opts.Pipeline, Stages: make(map[string]bool, len(opts.Stages)+1)}
+	for k, v := range opts.Stages {
+		partial.Stages[k] = v
+	}
+	partial.Stages[StagePunctuation] = false
+	return partial
+}
+
+// partialStabilizer 计算中间结果的稳定前缀：最近 window 个中间结果的公共前缀。
+// 英文在单词中间截断时退回到上一个空格，避免半个单词被当作稳定内容
+type partialStabilizer struct {
+	window  int
+	history [][]rune
+}
+
+func newPartialStabilizer(window int) *partialStabilizer {
+	if window <= 0 {
+		window = 3
+	}
+	return &partialStabilizer{window: window}
+}
+
+// update 记录新的中间结果并返回稳定前缀，历史不足 window 个时返回空
+func (p *partialStabilizer) update(text string) string {
+	p.history = append(p.history, []rune(text))
+	if len(p.history) > p.window {
+		p.history = p.history[1:]
+	}
+	if len(p.history) < p.window {
+		return ""
+	}
+
+	current := p.history[len(p.history)-1]
+	n := len(current)
+	for _, h := range p.history[:len(p.history)-1] {
+		i := 0
+		for i < n && i < len(h) && h[i] == current[i] {
+			i++
+		}
+		n = i
+	}
+
+	// 英文单词被截断时退回到单词边界
+	if n > 0 && n < len(current) && isWordRune(current[n-1]) && isWordRune(current[n]) {
+		for n > 0 && isWordRune(current[n-1]) {
+			n--
+		}
+	}
+	return string(current[:n])
+}
+
+// reset 开始新的一句
+func (p *partialStabilizer) reset() {
+	if p != nil {
+		p.history = p.history[:0]
+	}
+}
 
-	log.Println("Streaming ASR Manager closed")
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'')
 }
@@ -0,0 +1,118 @@
+package asr
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"airecorder/internal/config"
+)
+
+func TestPartialStabilizer(t *testing.T) {
+	p := newPartialStabilizer(3)
+	steps := []struct {
+		text, stable string
+	}{
+		{"今天", ""},
+		{"今天天", ""},
+		{"今天天气", "今天"},
+		{"今天天气很", "今天天"},
+		{"今天天汽很好", "今天天"},
+		{"今天天气很好", "今天天"},
+	}
+	for i, st := range steps {
+		if got := p.update(st.text); got != st.stable {
+			t.Fatalf("step %d: stable prefix of %q = %q, want %q", i, st.text, got, st.stable)
+		}
+	}
+
+	p.reset()
+	if got := p.update("hello"); got != "" {
+		t.Fatalf("history should be cleared after reset, got %q", got)
+	}
+}
+
+func TestPartialStabilizerWordBoundary(t *testing.T) {
+	p := newPartialStabilizer(2)
+	p.update("hello wor")
+	if got := p.update("hello world"); got != "hello " {
+		t.Fatalf("stable prefix should stop at word boundary, got %q", got)
+	}
+}
+
+func TestPartialTextOptionsSkipPunctuation(t *testing.T) {
+	opts := TextOptions{Pipeline: "meeting", Stages: map[string]bool{StageITN: true}}
+	partial := partialTextOptions(opts)
+	if partial.Pipeline != "meeting" || !partial.Stages[StageITN] || partial.Stages[StagePunctuation] {
+		t.Fatalf("unexpected partial options %+v", partial)
+	}
+	if _, ok := opts.Stages[StagePunctuation]; ok {
+		t.Fatal("session options must not be modified")
+	}
+}
+
+// newTestSessionManager 不加载模型的管理器，注册一个没有识别流的会话
+func newTestSessionManager(id string) (*StreamingASRManager, *StreamingASRSession) {
+	m := &StreamingASRManager{config: &config.Config{}, sessions: make(map[string]*StreamingASRSession), hub: newWatchHub()}
+	session := &StreamingASRSession{ID: id, replay: newReplayBuffer(0), done: make(chan struct{}), hub: m.hub}
+	m.sessions[id] = session
+	atomic.AddInt64(&m.stats.activeSessions, 1)
+	return m, session
+}
+
+func TestCloseSessionByAdminWaitsForHandler(t *testing.T) {
+	m, session := newTestSessionManager("s1")
+
+	// 模拟连接处理方：持续在会话锁内使用识别状态，会话被取消后关闭会话
+	handlerDone := make(chan struct{})
+	go func() {
+		defer close(handlerDone)
+		defer m.CloseSession(session.ID)
+		for {
+			select {
+			case <-session.Done():
+				return
+			default:
+			}
+			session.mu.Lock()
+			if session.released {
+				t.Error("stream released while handler is still using it")
+			}
+			session.samples += 160
+			session.mu.Unlock()
+			session.NextSeq()
+		}
+	}()
+
+	if !m.CloseSessionByAdmin(session.ID) {
+		t.Fatal("CloseSessionByAdmin should find the session")
+	}
+	<-handlerDone
+
+	if !session.released || len(m.sessions) != 0 {
+		t.Fatal("session should be released after the handler exits")
+	}
+	if session.CloseReason() != CloseReasonAdmin {
+		t.Fatalf("close reason = %q, want %q", session.CloseReason(), CloseReasonAdmin)
+	}
+	if _, err := session.ProcessAudio(make([]float32, 160)); err == nil {
+		t.Fatal("ProcessAudio on a released session should fail")
+	}
+	if m.CloseSessionByAdmin(session.ID) {
+		t.Fatal("closed session should no longer be found")
+	}
+}
+
+func TestCloseSessionByAdminDetached(t *testing.T) {
+	m, session := newTestSessionManager("s2")
+	session.detached = true
+
+	if !m.CloseSessionByAdmin(session.ID) {
+		t.Fatal("CloseSessionByAdmin should find the session")
+	}
+	if !session.released || len(m.sessions) != 0 {
+		t.Fatal("detached session should be released immediately")
+	}
+	if got := m.GetStats()["close_reasons"].(map[string]int64)[CloseReasonAdmin]; got != 1 {
+		t.Fatalf("admin close count = %d, want 1", got)
+	}
+}
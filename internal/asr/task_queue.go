@@ -5,14 +5,21 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"airecorder/internal/config"
+	"airecorder/internal/logging"
+	"airecorder/internal/metrics"
+	"airecorder/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -33,6 +40,21 @@ const (
 	TaskStatusFailed
 )
 
+// String 状态名称
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskStatusPending:
+		return "pending"
+	case TaskStatusProcessing:
+		return "processing"
+	case TaskStatusCompleted:
+		return "completed"
+	case TaskStatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
 // ASRTask 语音识别任务
 type ASRTask struct {
 	ID             string
@@ -40,14 +62,22 @@ type ASRTask struct {
 	SampleRate     int
 	DiarizationMgr *DiarizationManager
 	EnableDiar     bool
+	Language       string      // 请求指定的语种，为空时自动检测
+	Model          string      // 请求指定的模型名称，为空时使用默认模型
+	Hotwords       []Hotword   // 热词（已合并命名热词表）
+	Text           TextOptions // 文本后处理流水线及阶段开关
+	MuteAudio      bool        // 结果中保存脱敏内容静音后的音频副本
+	RedactionSpans bool        // 查询结果时返回脱敏位置
+	RequestID      string      // 提交任务的请求 ID，写入任务日志
 	Result         *ASRTaskResult
 	Status         TaskStatus
 	SubmitTime     time.Time
 	StartTime      time.Time
 	CompleteTime   time.Time
 	Error          error
-	TotalChunks    int32 // 总分块数（原子操作）
-	DoneChunks     int32 // 已完成分块数（原子操作）
+	TotalChunks    int32             // 总分块数（原子操作）
+	DoneChunks     int32             // 已完成分块数（原子操作）
+	parent         trace.SpanContext // 提交任务的请求的追踪上下文
 	ctx            context.Context
 	cancel         context.CancelFunc
 	resultChan     chan *ASRTaskResult
@@ -56,26 +86,38 @@ type ASRTask struct {
 
 // ASRTaskResult 任务结果
 type ASRTaskResult struct {
-	Text     string
-	Segments []DiarizationSegment
-	Duration float32
-	Error    error
+	Text         string
+	Segments     []DiarizationSegment
+	Redactions   []Redaction // 脱敏位置（相对于 Text）
+	MutedSamples []float32   // 脱敏内容静音后的音频（仅 MuteAudio 时）
+	Duration     float32
+	Language     LanguageResult
+	Model        string // 实际使用的模型名称
+	Error        error
 }
 
 // Cancel 取消任务
 func (t *ASRTask) Cancel() {
+	t.abort(fmt.Errorf("task cancelled by admin"))
+}
+
+// abort 以指定错误结束尚未完成的任务，处理中的长音频在当前分块结束后停止。
+// 任务已结束时返回 false
+func (t *ASRTask) abort(err error) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.Status == TaskStatusPending || t.Status == TaskStatusProcessing {
-		t.Status = TaskStatusFailed
-		t.Error = fmt.Errorf("task cancelled by admin")
-		t.CompleteTime = time.Now()
-		t.cancel()
-		select {
-		case t.resultChan <- &ASRTaskResult{Error: t.Error}:
-		default:
-		}
+	if t.Status != TaskStatusPending && t.Status != TaskStatusProcessing {
+		return false
+	}
+	t.Status = TaskStatusFailed
+	t.Error = err
+	t.CompleteTime = time.Now()
+	t.cancel()
+	select {
+	case t.resultChan <- &ASRTaskResult{Error: t.Error}:
+	default:
 	}
+	return true
 }
 
 // NewASRTask 创建新任务
@@ -96,12 +138,22 @@ func NewASRTask(samples []float32, sampleRate int, diarizationMgr *DiarizationMa
 	}
 }
 
+// SetParent 关联提交任务的请求的追踪上下文，排队、处理与解码的 span 都挂在该请求下
+func (t *ASRTask) SetParent(ctx context.Context) {
+	t.parent = trace.SpanContextFromContext(ctx)
+}
+
+// traceContext 任务的父追踪上下文
+func (t *ASRTask) traceContext() context.Context {
+	return trace.ContextWithSpanContext(context.Background(), t.parent)
+}
+
 func generateTaskID() string {
 	entropy := make([]byte, taskIDRandomBytes)
 	if _, err := rand.Read(entropy); err != nil {
 		fallbackSeed := fmt.Sprintf("%d_%d", time.Now().UnixNano(), atomic.AddUint64(&taskIDFallbackCounter, 1))
 		digest := sha256.Sum256([]byte(fallbackSeed))
-		log.Printf("[TaskQueue] WARN: crypto/rand unavailable for task ID generation: %v", err)
+		slog.Warn("crypto/rand unavailable for task ID generation", "component", "TaskQueue", "error", err)
 		return taskIDPrefix + hex.EncodeToString(digest[:taskIDRandomBytes])
 	}
 
@@ -132,17 +184,26 @@ func (t *ASRTask) Wait() *ASRTaskResult {
 	case result := <-t.resultChan:
 		return result
 	case <-t.ctx.Done():
+		// 取消任务时结果与 ctx 同时就绪，优先返回结果
+		select {
+		case result := <-t.resultChan:
+			return result
+		default:
+		}
 		return &ASRTaskResult{
 			Error: fmt.Errorf("task timeout"),
 		}
 	}
 }
 
-// Complete 标记任务完成
+// Complete 标记任务完成，已被取消的任务保持取消状态
 func (t *ASRTask) Complete(result *ASRTaskResult) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if t.Status == TaskStatusFailed && t.Error != nil {
+		return
+	}
 	t.Result = result
 	if result.Error != nil {
 		t.Status = TaskStatusFailed
@@ -195,15 +256,19 @@ func (t *ASRTask) SetStatus(status TaskStatus) {
 type TaskQueue struct {
 	config       *config.Config
 	asrManager   *OfflineASRManager
-	queue        chan *ASRTask
-	maxWorkers   int
-	maxQueueSize int
-	workers      []*worker
+	queue        chan *ASRTask // 缓冲区按 queueCapacityLimit 分配，排队上限由 maxQueueSize 控制
+	maxQueueSize atomic.Int64  // 排队上限，可运行中调整（SetCapacity）
+	enqueueMu    sync.Mutex    // 串行化入队，保证排队数不超过上限
+	workers      []*worker     // 由 workersMu 保护
+	nextWorkerID int
+	workersMu    sync.Mutex
 	wg           sync.WaitGroup
 	shutdown     chan struct{}
+	closing      bool // 停止接收新任务（由 mu 保护）
 	stats        taskQueueStats
 	mu           sync.RWMutex
 	taskStore    map[string]*ASRTask // taskId -> task 的内存存储
+	active       map[string]*ASRTask // 已入队且尚未处理结束的任务（含同步请求的任务，由 storeMu 保护）
 	storeMu      sync.RWMutex
 }
 
@@ -220,11 +285,18 @@ type taskQueueStats struct {
 type worker struct {
 	id          int
 	queue       *TaskQueue
+	stop        chan struct{} // 缩减 worker 时关闭，处理完当前任务后退出
 	processing  atomic.Bool
 	currentTask *ASRTask
 	mu          sync.RWMutex
 }
 
+// queueCapacityLimit 运行中可调整到的最大排队数（队列缓冲区大小，配置的 queue_size 更大时以其为准）
+const queueCapacityLimit = 10000
+
+// enqueueTimeout 队列已满时提交等待空位的时间
+const enqueueTimeout = 5 * time.Second
+
 // NewTaskQueue 创建任务队列
 func NewTaskQueue(cfg *config.Config, asrManager *OfflineASRManager) *TaskQueue {
 	maxWorkers := 2 // 默认2个worker，避免资源占用过高
@@ -238,31 +310,24 @@ func NewTaskQueue(cfg *config.Config, asrManager *OfflineASRManager) *TaskQueue
 	}
 
 	tq := &TaskQueue{
-		config:       cfg,
-		asrManager:   asrManager,
-		queue:        make(chan *ASRTask, maxQueueSize),
-		maxWorkers:   maxWorkers,
-		maxQueueSize: maxQueueSize,
-		workers:      make([]*worker, maxWorkers),
-		shutdown:     make(chan struct{}),
-		taskStore:    make(map[string]*ASRTask),
+		config:     cfg,
+		asrManager: asrManager,
+		queue:      make(chan *ASRTask, max(maxQueueSize, queueCapacityLimit)),
+		shutdown:   make(chan struct{}),
+		taskStore:  make(map[string]*ASRTask),
+		active:     make(map[string]*ASRTask),
 	}
+	tq.maxQueueSize.Store(int64(maxQueueSize))
 
 	// 启动worker
-	for i := 0; i < maxWorkers; i++ {
-		w := &worker{
-			id:    i,
-			queue: tq,
-		}
-		tq.workers[i] = w
-		tq.wg.Add(1)
-		go w.run()
-	}
+	tq.workersMu.Lock()
+	tq.startWorkers(maxWorkers)
+	tq.workersMu.Unlock()
 
 	// 启动任务清理协程（1小时后清理已完成/失败任务）
 	go tq.cleanupLoop()
 
-	log.Printf("TaskQueue initialized: max_workers=%d, queue_size=%d", maxWorkers, maxQueueSize)
+	slog.Info("TaskQueue initialized", "max_workers", maxWorkers, "queue_size", maxQueueSize)
 	return tq
 }
 
@@ -305,17 +370,51 @@ func (tq *TaskQueue) cleanupLoop() {
 }
 
 // Submit 提交任务
-func (tq *TaskQueue) Submit(task *ASRTask) error {
+func (tq *TaskQueue) Submit(task *ASRTask) (err error) {
+	_, span := tracing.Start(task.traceContext(), "TaskQueue.Submit",
+		attribute.String("task.id", task.ID), attribute.Int("queue.length", len(tq.queue)))
+	defer func() { tracing.End(span, err) }()
+
+	// 持有读锁直到入队结束，Close 关闭队列前等待正在进行的提交
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	if tq.closing {
+		return ErrShuttingDown
+	}
+
 	atomic.AddInt64(&tq.stats.totalTasks, 1)
 	atomic.AddInt64(&tq.stats.queuedTasks, 1)
+	// 入队前登记，worker 可能在 Submit 返回前就处理完任务
+	tq.storeMu.Lock()
+	tq.active[task.ID] = task
+	tq.storeMu.Unlock()
 
-	select {
-	case tq.queue <- task:
-		log.Printf("[TaskQueue] Task %s submitted, queue_length=%d", task.ID, len(tq.queue))
+	if tq.enqueue(task, enqueueTimeout) {
+		slog.Info("Task submitted", "task_id", task.ID, "request_id", task.RequestID, "queue_length", len(tq.queue))
 		return nil
-	case <-time.After(5 * time.Second):
-		atomic.AddInt64(&tq.stats.queuedTasks, -1)
-		return fmt.Errorf("queue is full, please try again later")
+	}
+	atomic.AddInt64(&tq.stats.queuedTasks, -1)
+	tq.finished(task)
+	return fmt.Errorf("queue is full, please try again later")
+}
+
+// enqueue 排队数低于上限时入队，否则等待空位，超时返回 false
+func (tq *TaskQueue) enqueue(task *ASRTask, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		tq.enqueueMu.Lock()
+		// 只有 Submit 入队，持锁期间排队数只会减少，发送不会阻塞
+		if len(tq.queue) < tq.Capacity() {
+			tq.queue <- task
+			tq.enqueueMu.Unlock()
+			return true
+		}
+		tq.enqueueMu.Unlock()
+
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
 	}
 }
 
@@ -328,8 +427,8 @@ func (tq *TaskQueue) GetStats() map[string]interface{} {
 		"queued_tasks":     atomic.LoadInt64(&tq.stats.queuedTasks),
 		"processing_tasks": atomic.LoadInt64(&tq.stats.processingTasks),
 		"queue_length":     len(tq.queue),
-		"max_workers":      tq.maxWorkers,
-		"max_queue_size":   tq.maxQueueSize,
+		"max_workers":      tq.Workers(),
+		"max_queue_size":   tq.Capacity(),
 		"avg_wait_time_ms": tq.getAvgWaitTime(),
 		"avg_exec_time_ms": tq.getAvgExecTime(),
 	}
@@ -351,13 +450,99 @@ func (tq *TaskQueue) getAvgExecTime() int64 {
 	return atomic.LoadInt64(&tq.stats.totalExecTime) / completed
 }
 
-// Close 关闭队列
+// QueueDepth 排队中的任务数
+func (tq *TaskQueue) QueueDepth() int {
+	return len(tq.queue)
+}
+
+// Capacity 排队上限（concurrency.queue_size，可由 SetCapacity 调整）
+func (tq *TaskQueue) Capacity() int {
+	if n := int(tq.maxQueueSize.Load()); n > 0 {
+		return n
+	}
+	return cap(tq.queue)
+}
+
+// MaxCapacity SetCapacity 可设置的最大排队数
+func (tq *TaskQueue) MaxCapacity() int {
+	return cap(tq.queue)
+}
+
+// SetCapacity 调整排队上限。调小时已排队的任务不受影响，新提交等待排队数降到上限以下
+func (tq *TaskQueue) SetCapacity(n int) error {
+	if n < 1 || n > cap(tq.queue) {
+		return fmt.Errorf("queue size must be between 1 and %d", cap(tq.queue))
+	}
+	previous := tq.maxQueueSize.Swap(int64(n))
+	slog.Info("TaskQueue queue_size changed", "from", previous, "to", n)
+	return nil
+}
+
+// Workers 当前 worker 数
+func (tq *TaskQueue) Workers() int {
+	tq.workersMu.Lock()
+	defer tq.workersMu.Unlock()
+	return len(tq.workers)
+}
+
+// SetWorkers 调整 worker 数。增加时立即启动新 worker；减少时被移除的 worker 处理完当前任务后退出，
+// 排队中的任务由其余 worker 继续处理，不会丢失
+func (tq *TaskQueue) SetWorkers(n int) error {
+	if n < 1 {
+		return fmt.Errorf("worker pool size must be positive")
+	}
+	// 持有读锁，避免与 Close 同时启动 worker
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	if tq.closing {
+		return ErrShuttingDown
+	}
+
+	tq.workersMu.Lock()
+	defer tq.workersMu.Unlock()
+	previous := len(tq.workers)
+	if n > previous {
+		tq.startWorkers(n - previous)
+	} else {
+		for _, w := range tq.workers[n:] {
+			close(w.stop)
+		}
+		tq.workers = tq.workers[:n]
+	}
+	slog.Info("TaskQueue workers changed", "from", previous, "to", n)
+	return nil
+}
+
+// startWorkers 启动 n 个 worker，调用方持有 workersMu
+func (tq *TaskQueue) startWorkers(n int) {
+	for i := 0; i < n; i++ {
+		w := &worker{
+			id:    tq.nextWorkerID,
+			queue: tq,
+			stop:  make(chan struct{}),
+		}
+		tq.nextWorkerID++
+		tq.workers = append(tq.workers, w)
+		tq.wg.Add(1)
+		go w.run()
+	}
+}
+
+// ProcessingTasks 正在处理的任务数
+func (tq *TaskQueue) ProcessingTasks() int64 {
+	return atomic.LoadInt64(&tq.stats.processingTasks)
+}
+
+// Close 关闭队列，正在处理的任务结束后返回
 func (tq *TaskQueue) Close() {
-	log.Println("Closing TaskQueue...")
+	slog.Info("Closing TaskQueue...")
+	tq.mu.Lock()
+	tq.closing = true
+	tq.mu.Unlock()
 	close(tq.shutdown)
 	close(tq.queue)
 	tq.wg.Wait()
-	log.Println("TaskQueue closed")
+	slog.Info("TaskQueue closed")
 }
 
 // ListTasks 返回所有任务的摘要信息
@@ -367,18 +552,6 @@ func (tq *TaskQueue) ListTasks() []map[string]interface{} {
 
 	tasks := make([]map[string]interface{}, 0, len(tq.taskStore))
 	for _, task := range tq.taskStore {
-		statusStr := "unknown"
-		switch task.GetStatus() {
-		case TaskStatusPending:
-			statusStr = "pending"
-		case TaskStatusProcessing:
-			statusStr = "processing"
-		case TaskStatusCompleted:
-			statusStr = "completed"
-		case TaskStatusFailed:
-			statusStr = "failed"
-		}
-
 		var startTimeStr, completeTimeStr string
 		if !task.StartTime.IsZero() {
 			startTimeStr = task.StartTime.Format(time.RFC3339)
@@ -394,13 +567,14 @@ func (tq *TaskQueue) ListTasks() []map[string]interface{} {
 
 		tasks = append(tasks, map[string]interface{}{
 			"id":                 task.ID,
-			"status":             statusStr,
+			"status":             task.GetStatus().String(),
 			"submit_time":        task.SubmitTime.Format(time.RFC3339),
 			"start_time":         startTimeStr,
 			"complete_time":      completeTimeStr,
 			"progress":           task.GetProgress(),
 			"audio_duration_sec": audioSec,
 			"enable_diarization": task.EnableDiar,
+			"model":              task.Model,
 		})
 	}
 	return tasks
@@ -413,14 +587,14 @@ func (tq *TaskQueue) CancelTask(id string) error {
 		return fmt.Errorf("task not found: %s", id)
 	}
 	task.Cancel()
-	log.Printf("[TaskQueue] Task %s cancelled by admin", id)
+	slog.Info("Task cancelled by admin", "task_id", id)
 	return nil
 }
 
 // GetWorkerStatus 返回 worker 状态列表
 func (tq *TaskQueue) GetWorkerStatus() []map[string]interface{} {
-	tq.mu.RLock()
-	defer tq.mu.RUnlock()
+	tq.workersMu.Lock()
+	defer tq.workersMu.Unlock()
 
 	result := make([]map[string]interface{}, 0, len(tq.workers))
 	for _, w := range tq.workers {
@@ -442,16 +616,19 @@ func (tq *TaskQueue) GetWorkerStatus() []map[string]interface{} {
 // worker运行逻辑
 func (w *worker) run() {
 	defer w.queue.wg.Done()
-	log.Printf("[Worker %d] Started", w.id)
+	slog.Debug("Worker started", "worker", w.id)
 
 	for {
 		select {
 		case <-w.queue.shutdown:
-			log.Printf("[Worker %d] Shutting down", w.id)
+			slog.Debug("Worker shutting down", "worker", w.id)
+			return
+		case <-w.stop:
+			slog.Debug("Worker removed", "worker", w.id)
 			return
 		case task, ok := <-w.queue.queue:
 			if !ok {
-				log.Printf("[Worker %d] Queue closed", w.id)
+				slog.Debug("Worker stopped, queue closed", "worker", w.id)
 				return
 			}
 			w.processTask(task)
@@ -472,19 +649,34 @@ func (w *worker) processTask(task *ASRTask) {
 	}()
 
 	atomic.AddInt64(&w.queue.stats.queuedTasks, -1)
+	defer w.queue.finished(task)
+
+	// 排队期间已被取消的任务不再处理
+	if task.GetStatus() != TaskStatusPending {
+		slog.Info("Skipping cancelled task", "worker", w.id, "task_id", task.ID, "request_id", task.RequestID)
+		return
+	}
+
 	atomic.AddInt64(&w.queue.stats.processingTasks, 1)
 	defer atomic.AddInt64(&w.queue.stats.processingTasks, -1)
 
 	// 更新任务状态
 	task.SetStatus(TaskStatusProcessing)
 
+	// 排队时间单独记为一个 span（从提交到开始处理）
+	parent := task.traceContext()
+	_, waitSpan := tracing.StartAt(parent, "TaskQueue.wait", task.SubmitTime, attribute.String("task.id", task.ID))
+	waitSpan.End()
+
 	// 记录等待时间
-	waitTime := time.Since(task.SubmitTime).Milliseconds()
+	wait := time.Since(task.SubmitTime)
+	waitTime := wait.Milliseconds()
 	atomic.AddInt64(&w.queue.stats.totalWaitTime, waitTime)
+	metrics.TaskWaitSeconds.Observe(wait.Seconds())
 
 	audioDuration := float32(len(task.Samples)) / float32(task.SampleRate)
-	log.Printf("[Worker %d] Processing task %s: duration=%.2fs, wait_time=%dms",
-		w.id, task.ID, audioDuration, waitTime)
+	logger := slog.With("worker", w.id, "task_id", task.ID, "request_id", task.RequestID)
+	logger.Info("Processing task", "duration_sec", audioDuration, "wait_time_ms", waitTime, "model", task.Model)
 
 	startTime := time.Now()
 
@@ -492,55 +684,89 @@ func (w *worker) processTask(task *ASRTask) {
 	var result ASRTaskResult
 	result.Duration = audioDuration
 
+	// 任务被取消时中止分块识别（不受任务等待超时影响）
+	runCtx, stop := context.WithCancel(parent)
+	defer stop()
+	context.AfterFunc(task.ctx, func() {
+		if errors.Is(task.ctx.Err(), context.Canceled) {
+			stop()
+		}
+	})
+
+	ctx, span := tracing.Start(runCtx, "ASRTask.process",
+		attribute.String("task.id", task.ID),
+		attribute.String("request.id", task.RequestID),
+		attribute.Int("worker", w.id),
+		attribute.Float64("audio.duration_sec", float64(audioDuration)),
+		attribute.Bool("diarization", task.EnableDiar))
+	defer func() { tracing.End(span, result.Error) }()
+
 	progCb := func(total, completed int) {
 		atomic.StoreInt32(&task.TotalChunks, int32(total))
 		atomic.StoreInt32(&task.DoneChunks, int32(completed))
 	}
 
+	// 之后的识别日志（语种检测、分块、说话者分离片段）带上任务 ID
+	ctx = logging.WithLogger(ctx, logger)
+
+	// 确定语种（请求指定或自动检测），用于选择识别模型；指定模型或热词时语种不影响模型选择，不做检测
+	if task.Model == "" && len(task.Hotwords) == 0 {
+		result.Language = w.queue.asrManager.ResolveLanguageContext(ctx, task.Samples, task.SampleRate, task.Language)
+	}
+	opts := RecognizeOptions{Model: task.Model, Language: result.Language.Language, Hotwords: task.Hotwords, Text: task.Text, Context: ctx}
+	result.Model = w.queue.asrManager.ModelName(opts)
+	span.SetAttributes(attribute.String("asr.model", result.Model), attribute.String("asr.language", result.Language.Language))
+
 	if task.EnableDiar && task.DiarizationMgr != nil {
 		// 带说话者分离
-		segments, err := task.DiarizationMgr.ProcessWithASR(task.Samples, task.SampleRate, w.queue.asrManager, progCb)
+		segments, err := task.DiarizationMgr.ProcessWithASROptions(task.Samples, task.SampleRate, w.queue.asrManager, opts, progCb)
 		if err != nil {
 			result.Error = err
 		} else {
-			fullText := ""
-			for _, seg := range segments {
-				fullText += seg.Text + " "
-			}
-			result.Text = fullText
+			result.Text, result.Redactions = JoinSegments(segments)
 			result.Segments = segments
 		}
 	} else {
 		// 普通识别
 		chunkDurationSec := w.queue.asrManager.GetChunkDurationSec()
-		var text string
+		var transcript Transcript
 		var err error
 
 		if audioDuration > float32(chunkDurationSec) {
-			log.Printf("[Worker %d] Using chunked processing for task %s", w.id, task.ID)
-			text, err = w.queue.asrManager.RecognizeChunked(task.Samples, task.SampleRate, progCb)
+			logger.Info("Using chunked processing")
+			transcript, err = w.queue.asrManager.RecognizeChunkedDetailed(task.Samples, task.SampleRate, opts, progCb)
 		} else {
-			text, err = w.queue.asrManager.Recognize(task.Samples, task.SampleRate)
+			transcript, err = w.queue.asrManager.RecognizeDetailed(task.Samples, task.SampleRate, opts)
 		}
 
-		result.Text = text
+		result.Text = transcript.Text
+		result.Redactions = transcript.Redactions
 		result.Error = err
 	}
 
+	if task.MuteAudio && result.Error == nil {
+		result.MutedSamples = MuteRedactions(task.Samples, task.SampleRate, result.Redactions)
+	}
+
 	// 记录执行时间
-	execTime := time.Since(startTime).Milliseconds()
+	exec := time.Since(startTime)
+	execTime := exec.Milliseconds()
 	atomic.AddInt64(&w.queue.stats.totalExecTime, execTime)
+	status := "completed"
+	if result.Error != nil {
+		status = "failed"
+	}
+	// 音频时长与解码实时率在解码时按模型记录，这里只记录任务整体耗时
+	metrics.TaskExecSeconds.Observe(exec.Seconds(), status)
 
 	// 完成任务
 	task.Complete(&result)
 
 	if result.Error != nil {
 		atomic.AddInt64(&w.queue.stats.failedTasks, 1)
-		log.Printf("[Worker %d] Task %s failed: error=%v, exec_time=%dms",
-			w.id, task.ID, result.Error, execTime)
+		logger.Error("Task failed", "error", result.Error, "exec_time_ms", execTime)
 	} else {
 		atomic.AddInt64(&w.queue.stats.completedTasks, 1)
-		log.Printf("[Worker %d] Task %s completed: result_length=%d chars, exec_time=%dms",
-			w.id, task.ID, len(result.Text), execTime)
+		logger.Info("Task completed", "result_length", len(result.Text), "exec_time_ms", execTime, "model", result.Model)
 	}
 }
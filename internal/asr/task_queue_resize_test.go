@@ -0,0 +1,86 @@
+package asr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetWorkersStartsAndStopsWorkers(t *testing.T) {
+	tq := &TaskQueue{
+		queue:    make(chan *ASRTask, 4),
+		shutdown: make(chan struct{}),
+		active:   make(map[string]*ASRTask),
+	}
+	tq.workersMu.Lock()
+	tq.startWorkers(3)
+	tq.workersMu.Unlock()
+
+	if err := tq.SetWorkers(1); err != nil {
+		t.Fatal(err)
+	}
+	if n := tq.Workers(); n != 1 {
+		t.Fatalf("expected 1 worker, got %d", n)
+	}
+	if err := tq.SetWorkers(5); err != nil {
+		t.Fatal(err)
+	}
+	if n := tq.Workers(); n != 5 {
+		t.Fatalf("expected 5 workers, got %d", n)
+	}
+	if status := tq.GetWorkerStatus(); len(status) != 5 {
+		t.Fatalf("expected status for 5 workers, got %d", len(status))
+	}
+	if err := tq.SetWorkers(0); err == nil {
+		t.Fatal("expected error for zero workers")
+	}
+
+	// 被移除的 2 个 worker 已退出，关闭后其余 worker 也应退出
+	close(tq.shutdown)
+	done := make(chan struct{})
+	go func() {
+		tq.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("workers did not exit")
+	}
+
+	tq.closing = true
+	if err := tq.SetWorkers(2); err != ErrShuttingDown {
+		t.Fatalf("expected ErrShuttingDown, got %v", err)
+	}
+}
+
+func TestSetCapacityKeepsQueuedTasks(t *testing.T) {
+	tq := &TaskQueue{queue: make(chan *ASRTask, 4)}
+	for i := 0; i < 3; i++ {
+		if !tq.enqueue(NewASRTask(nil, 16000, nil, false), 0) {
+			t.Fatalf("enqueue %d failed", i)
+		}
+	}
+
+	if err := tq.SetCapacity(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(tq.queue) != 3 {
+		t.Fatalf("queued tasks should be kept, got %d", len(tq.queue))
+	}
+	if tq.enqueue(NewASRTask(nil, 16000, nil, false), 0) {
+		t.Fatal("enqueue should fail while over capacity")
+	}
+
+	<-tq.queue
+	<-tq.queue
+	if !tq.enqueue(NewASRTask(nil, 16000, nil, false), 0) {
+		t.Fatal("enqueue should succeed below capacity")
+	}
+
+	if err := tq.SetCapacity(tq.MaxCapacity() + 1); err == nil {
+		t.Fatal("expected error above max capacity")
+	}
+	if err := tq.SetCapacity(4); err != nil || tq.Capacity() != 4 {
+		t.Fatalf("expected capacity 4, got %d (%v)", tq.Capacity(), err)
+	}
+}
@@ -0,0 +1,36 @@
+package asr
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTaskSubmitSpanFollowsRequest(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(prev)
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+	task := NewASRTask(nil, 16000, nil, false)
+	task.SetParent(ctx)
+	request.End()
+
+	tq := &TaskQueue{queue: make(chan *ASRTask, 1), active: make(map[string]*ASRTask)}
+	if err := tq.Submit(task); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[1].Name() != "TaskQueue.Submit" {
+		t.Fatalf("expected request and submit spans, got %d", len(spans))
+	}
+	if spans[1].Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Fatal("submit span should be a child of the request span")
+	}
+}
@@ -0,0 +1,249 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"airecorder/internal/config"
+	"airecorder/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultTextPipeline 未配置 text_pipelines 时生成的流水线名称
+const DefaultTextPipeline = "default"
+
+// TextOptions 单次请求的文本后处理参数
+type TextOptions struct {
+	Pipeline string          // 流水线名称，为空时使用默认流水线
+	Stages   map[string]bool // 按阶段类型开关，未列出的阶段使用配置中的默认值
+}
+
+// SetStage 设置阶段开关，override 为 nil 时不改变
+func (o *TextOptions) SetStage(stageType string, override *bool) {
+	if override == nil {
+		return
+	}
+	if o.Stages == nil {
+		o.Stages = make(map[string]bool)
+	}
+	o.Stages[stageType] = *override
+}
+
+type pipelineStage struct {
+	stage   TextStage
+	enabled bool
+}
+
+// TextPipeline 按顺序执行的文本处理阶段
+type TextPipeline struct {
+	name   string
+	stages []pipelineStage
+}
+
+// NewTextPipeline 创建流水线，punct 为 punctuation 阶段使用的标点管理器
+func NewTextPipeline(cfg config.TextPipelineConfig, punct *PunctuationManager) (*TextPipeline, error) {
+	p := &TextPipeline{name: cfg.Name}
+	for i, sc := range cfg.Stages {
+		stage, err := newTextStage(sc, punct)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %q stage %d: %w", cfg.Name, i, err)
+		}
+		p.stages = append(p.stages, pipelineStage{stage: stage, enabled: sc.Enabled == nil || *sc.Enabled})
+	}
+	return p, nil
+}
+
+// Name 流水线名称
+func (p *TextPipeline) Name() string {
+	return p.name
+}
+
+// Stages 按顺序返回阶段类型
+func (p *TextPipeline) Stages() []string {
+	types := make([]string, len(p.stages))
+	for i, s := range p.stages {
+		types[i] = s.stage.Type()
+	}
+	return types
+}
+
+// Process 依次执行阶段，toggles 中的开关优先于配置
+func (p *TextPipeline) Process(text string, toggles map[string]bool) string {
+	return p.process(context.Background(), text, toggles)
+}
+
+// process 依次执行阶段，标点阶段（模型推理）记录 span
+func (p *TextPipeline) process(ctx context.Context, text string, toggles map[string]bool) string {
+	for _, s := range p.stages {
+		if text == "" {
+			return text
+		}
+		enabled := s.enabled
+		if v, ok := toggles[s.stage.Type()]; ok {
+			enabled = v
+		}
+		if !enabled {
+			continue
+		}
+		if s.stage.Type() == StagePunctuation {
+			_, span := tracing.Start(ctx, "text.punctuation", attribute.Int("text.length", len(text)))
+			text = s.stage.Process(text)
+			span.End()
+		} else {
+			text = s.stage.Process(text)
+		}
+	}
+	return text
+}
+
+// redactor 返回本次请求生效的 redact 阶段，未配置或被关闭时返回 nil
+func (p *TextPipeline) redactor(toggles map[string]bool) *redactStage {
+	var active *redactStage
+	for _, s := range p.stages {
+		r, ok := s.stage.(*redactStage)
+		if !ok {
+			continue
+		}
+		enabled := s.enabled
+		if v, ok := toggles[StageRedact]; ok {
+			enabled = v
+		}
+		if enabled {
+			active = r
+		}
+	}
+	return active
+}
+
+// TextPipelines 命名流水线集合
+type TextPipelines struct {
+	pipelines map[string]*TextPipeline
+	names     []string
+	def       string
+}
+
+// NewTextPipelines 按 text_pipelines 配置创建流水线；未配置时按 punctuation 与 post_process 生成 default 流水线
+func NewTextPipelines(cfg *config.Config, punct *PunctuationManager) (*TextPipelines, error) {
+	pipelineConfigs := cfg.TextPipelines.Pipelines
+	if len(pipelineConfigs) == 0 {
+		pipelineConfigs = []config.TextPipelineConfig{legacyTextPipeline(cfg)}
+	}
+
+	ps := &TextPipelines{pipelines: make(map[string]*TextPipeline)}
+	for _, pc := range pipelineConfigs {
+		if pc.Name == "" {
+			return nil, fmt.Errorf("text pipeline name is required")
+		}
+		if _, dup := ps.pipelines[pc.Name]; dup {
+			return nil, fmt.Errorf("duplicate text pipeline %q", pc.Name)
+		}
+		p, err := NewTextPipeline(pc, punct)
+		if err != nil {
+			return nil, err
+		}
+		ps.pipelines[pc.Name] = p
+		ps.names = append(ps.names, pc.Name)
+	}
+
+	ps.def = cfg.TextPipelines.Default
+	if ps.def == "" {
+		ps.def = ps.names[0]
+	}
+	if _, ok := ps.pipelines[ps.def]; !ok {
+		return nil, fmt.Errorf("default text pipeline %q not found", ps.def)
+	}
+
+	for _, name := range ps.names {
+		stages := ps.pipelines[name].Stages()
+		if len(stages) == 0 {
+			stages = []string{"(none)"}
+		}
+		slog.Info("Text pipeline", "pipeline", name, "stages", strings.Join(stages, " -> "))
+	}
+	return ps, nil
+}
+
+// legacyTextPipeline 兼容旧配置：标点 → ITN → 替换词典，ITN 与替换词典按 post_process 决定是否默认启用。
+// 替换词典文件读取失败时仅使用 rules 中的规则
+func legacyTextPipeline(cfg *config.Config) config.TextPipelineConfig {
+	itn := cfg.PostProcess.ITN.Enabled
+	replacements := cfg.PostProcess.Replacements.Enabled
+
+	var rules []config.ReplacementRule
+	if file := cfg.PostProcess.Replacements.File; file != "" {
+		fileRules, err := loadReplacementFile(file)
+		if err != nil {
+			slog.Warn("Failed to load replacement dictionary", "file", file, "error", err)
+		}
+		rules = append(rules, fileRules...)
+	}
+	rules = append(rules, cfg.PostProcess.Replacements.Rules...)
+
+	return config.TextPipelineConfig{
+		Name: DefaultTextPipeline,
+		Stages: []config.TextStageConfig{
+			{Type: StagePunctuation},
+			{Type: StageITN, Enabled: &itn},
+			{Type: StageReplacements, Enabled: &replacements, Rules: rules},
+		},
+	}
+}
+
+// Get 按名称获取流水线，名称为空时返回默认流水线
+func (ps *TextPipelines) Get(name string) (*TextPipeline, error) {
+	if name == "" {
+		name = ps.def
+	}
+	p, ok := ps.pipelines[name]
+	if !ok {
+		return nil, fmt.Errorf("text pipeline %q not found", name)
+	}
+	return p, nil
+}
+
+// Names 按配置顺序返回流水线名称
+func (ps *TextPipelines) Names() []string {
+	return append([]string(nil), ps.names...)
+}
+
+// Default 默认流水线名称
+func (ps *TextPipelines) Default() string {
+	return ps.def
+}
+
+// Process 使用 opts 指定的流水线处理文本，流水线不存在时使用默认流水线
+func (ps *TextPipelines) Process(text string, opts TextOptions) string {
+	return ps.ProcessContext(context.Background(), text, opts)
+}
+
+// ProcessContext 同 Process，阶段的 span 挂在 ctx 下
+func (ps *TextPipelines) ProcessContext(ctx context.Context, text string, opts TextOptions) string {
+	if ps == nil || text == "" {
+		return text
+	}
+	p, err := ps.Get(opts.Pipeline)
+	if err != nil {
+		p = ps.pipelines[ps.def]
+	}
+	return p.process(ctx, text, opts.Stages)
+}
+
+// Redactions 在处理后的文本中定位 redact 阶段插入的标签，未执行 redact 阶段时返回 nil。
+// 返回的位置不含时间信息，由调用方按音频估计
+func (ps *TextPipelines) Redactions(text string, opts TextOptions) []Redaction {
+	if ps == nil || text == "" {
+		return nil
+	}
+	p, err := ps.Get(opts.Pipeline)
+	if err != nil {
+		p = ps.pipelines[ps.def]
+	}
+	r := p.redactor(opts.Stages)
+	if r == nil {
+		return nil
+	}
+	return r.find(text)
+}
@@ -0,0 +1,134 @@
+package asr
+
+import (
+	"testing"
+
+	"airecorder/internal/config"
+)
+
+func TestLegacyPipelineStages(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PostProcess.ITN.Enabled = true
+	cfg.PostProcess.Replacements.Enabled = true
+	cfg.PostProcess.Replacements.Rules = []config.ReplacementRule{
+		{From: "阿里巴巴", To: "Alibaba"},
+		{From: "嗯，", To: ""},
+	}
+	ps, err := NewTextPipelines(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.Default() != DefaultTextPipeline {
+		t.Fatalf("unexpected default pipeline %q", ps.Default())
+	}
+
+	in := "嗯，阿里巴巴股价上涨百分之五十"
+	if got := ps.Process(in, TextOptions{}); got != "Alibaba股价上涨50%" {
+		t.Fatalf("unexpected result with all stages: %q", got)
+	}
+
+	off := TextOptions{}
+	off.SetStage(StageITN, new(bool))
+	if got := ps.Process(in, off); got != "Alibaba股价上涨百分之五十" {
+		t.Fatalf("ITN should be skipped per request: %q", got)
+	}
+	if got := ps.Process(in, TextOptions{Stages: map[string]bool{StageReplacements: false}}); got != "嗯，阿里巴巴股价上涨50%" {
+		t.Fatalf("replacements should be skipped per request: %q", got)
+	}
+
+	// 配置关闭时请求可以单独打开
+	disabled, err := NewTextPipelines(&config.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := disabled.Process("百分之五", TextOptions{}); got != "百分之五" {
+		t.Fatalf("disabled pipeline changed text: %q", got)
+	}
+	if got := disabled.Process("百分之五", TextOptions{Stages: map[string]bool{StageITN: true}}); got != "5%" {
+		t.Fatalf("ITN should be enabled per request: %q", got)
+	}
+}
+
+func TestNamedPipelines(t *testing.T) {
+	off := false
+	cfg := &config.Config{}
+	cfg.TextPipelines = config.TextPipelinesConfig{
+		Default: "meeting",
+		Pipelines: []config.TextPipelineConfig{
+			{Name: "raw"},
+			{Name: "meeting", Stages: []config.TextStageConfig{
+				{Type: StageFiller},
+				{Type: StageITN},
+				{Type: StageProfanity, Words: []string{"damn"}, Enabled: &off},
+				{Type: StageCasing, Mode: "sentence"},
+			}},
+		},
+	}
+	ps, err := NewTextPipelines(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := "uh damn we have twenty five items"
+	if got := ps.Process(in, TextOptions{}); got != "Damn we have 25 items" {
+		t.Fatalf("default pipeline: %q", got)
+	}
+	if got := ps.Process(in, TextOptions{Stages: map[string]bool{StageProfanity: true}}); got != "**** We have 25 items" {
+		t.Fatalf("profanity enabled per request: %q", got)
+	}
+	if got := ps.Process(in, TextOptions{Pipeline: "raw"}); got != in {
+		t.Fatalf("raw pipeline changed text: %q", got)
+	}
+	if _, err := ps.Get("missing"); err == nil {
+		t.Fatal("expected error for unknown pipeline")
+	}
+}
+
+func TestTextPipelinesConfigErrors(t *testing.T) {
+	cases := map[string]config.TextPipelinesConfig{
+		"unknown stage": {Pipelines: []config.TextPipelineConfig{{Name: "a", Stages: []config.TextStageConfig{{Type: "nope"}}}}},
+		"duplicate":     {Pipelines: []config.TextPipelineConfig{{Name: "a"}, {Name: "a"}}},
+		"no name":       {Pipelines: []config.TextPipelineConfig{{}}},
+		"bad default":   {Default: "b", Pipelines: []config.TextPipelineConfig{{Name: "a"}}},
+	}
+	for name, tp := range cases {
+		cfg := &config.Config{TextPipelines: tp}
+		if _, err := NewTextPipelines(cfg, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPipelineRedactions(t *testing.T) {
+	off := false
+	cfg := &config.Config{}
+	cfg.TextPipelines.Pipelines = []config.TextPipelineConfig{{
+		Name: "calls",
+		Stages: []config.TextStageConfig{
+			{Type: StageITN},
+			{Type: StageRedact, Enabled: &off},
+		},
+	}}
+	ps, err := NewTextPipelines(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := "我的电话是一三八零零一三八零零零"
+	if got := ps.Process(in, TextOptions{}); got != "我的电话是13800138000" {
+		t.Fatalf("redact should be off by default: %q", got)
+	}
+	if spans := ps.Redactions("我的电话是13800138000", TextOptions{}); spans != nil {
+		t.Fatalf("no spans expected when redact is off: %+v", spans)
+	}
+
+	opts := TextOptions{Stages: map[string]bool{StageRedact: true}}
+	got := ps.Process(in, opts)
+	if got != "我的电话是[PHONE]" {
+		t.Fatalf("unexpected redacted text %q", got)
+	}
+	spans := ps.Redactions(got, opts)
+	if len(spans) != 1 || spans[0].Start != 5 || spans[0].End != 12 {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
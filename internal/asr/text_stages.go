@@ -0,0 +1,356 @@
+package asr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"airecorder/internal/config"
+)
+
+// 文本处理阶段类型（text_pipelines.pipelines[].stages[].type）
+const (
+	StagePunctuation  = "punctuation"
+	StageITN          = "itn"
+	StageCasing       = "casing"
+	StageProfanity    = "profanity"
+	StageRegex        = "regex"
+	StageFiller       = "filler"
+	StageReplacements = "replacements"
+	StageRedact       = "redact"
+)
+
+// TextStage 文本处理阶段，Process 必须可并发调用
+type TextStage interface {
+	// Type 阶段类型，请求按类型开关阶段
+	Type() string
+	Process(text string) string
+}
+
+// newTextStage 按配置创建阶段，punctuation 阶段使用传入的标点管理器
+func newTextStage(sc config.TextStageConfig, punct *PunctuationManager) (TextStage, error) {
+	switch sc.Type {
+	case StagePunctuation:
+		return &punctuationStage{punct: punct}, nil
+	case StageITN:
+		return itnStage{}, nil
+	case StageCasing:
+		return newCasingStage(sc.Mode)
+	case StageProfanity:
+		words := sc.Words
+		if sc.File != "" {
+			fileWords, err := loadWordFile(sc.File)
+			if err != nil {
+				return nil, fmt.Errorf("profanity word file: %w", err)
+			}
+			words = append(words, fileWords...)
+		}
+		return newProfanityStage(words, sc.Mask)
+	case StageRegex:
+		return newRegexStage(sc.Patterns)
+	case StageFiller:
+		return newFillerStage(sc.Words)
+	case StageReplacements:
+		var rules []config.ReplacementRule
+		if sc.File != "" {
+			fileRules, err := loadReplacementFile(sc.File)
+			if err != nil {
+				return nil, fmt.Errorf("replacement dictionary: %w", err)
+			}
+			rules = append(rules, fileRules...)
+		}
+		rules = append(rules, sc.Rules...)
+		return newReplacementStage(rules), nil
+	case StageRedact:
+		return newRedactStage(sc.Entities, sc.Tags)
+	}
+	return nil, fmt.Errorf("unknown stage type %q", sc.Type)
+}
+
+// ---------------- punctuation ----------------
+
+type punctuationStage struct {
+	punct *PunctuationManager
+}
+
+func (s *punctuationStage) Type() string { return StagePunctuation }
+
+func (s *punctuationStage) Process(text string) string {
+	if s.punct == nil {
+		return text
+	}
+	return s.punct.AddPunctuation(text)
+}
+
+// ---------------- itn ----------------
+
+type itnStage struct{}
+
+func (itnStage) Type() string               { return StageITN }
+func (itnStage) Process(text string) string { return ApplyITN(text) }
+
+// ---------------- casing ----------------
+
+// casingStage 调整英文大小写。sentence 模式先转小写，再将句首字母与单独的 i 大写
+type casingStage struct {
+	mode string
+}
+
+func newCasingStage(mode string) (*casingStage, error) {
+	switch mode {
+	case "lower", "upper", "sentence":
+		return &casingStage{mode: mode}, nil
+	case "":
+		return &casingStage{mode: "sentence"}, nil
+	}
+	return nil, fmt.Errorf("unknown casing mode %q, expected lower, upper or sentence", mode)
+}
+
+func (s *casingStage) Type() string { return StageCasing }
+
+func (s *casingStage) Process(text string) string {
+	switch s.mode {
+	case "lower":
+		return strings.ToLower(text)
+	case "upper":
+		return strings.ToUpper(text)
+	}
+	return sentenceCase(text)
+}
+
+func sentenceCase(text string) string {
+	runes := []rune(strings.ToLower(text))
+	capNext := true
+	for i, r := range runes {
+		switch {
+		case strings.ContainsRune(".!?。！？", r):
+			capNext = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if capNext {
+				runes[i] = unicode.ToUpper(r)
+				capNext = false
+			}
+			// 单独的 i 作为代词大写
+			if r == 'i' && (i == 0 || !unicode.IsLetter(runes[i-1])) && (i+1 == len(runes) || !unicode.IsLetter(runes[i+1])) {
+				runes[i] = 'I'
+			}
+		}
+	}
+	return string(runes)
+}
+
+// ---------------- profanity ----------------
+
+// profanityStage 将敏感词替换为等长掩码。英文词按整词、忽略大小写匹配，中文按子串匹配
+type profanityStage struct {
+	pattern *regexp.Regexp
+	mask    string
+}
+
+func newProfanityStage(words []string, mask string) (*profanityStage, error) {
+	if mask == "" {
+		mask = "*"
+	}
+	pattern, err := wordsPattern(words, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &profanityStage{pattern: pattern, mask: mask}, nil
+}
+
+func (s *profanityStage) Type() string { return StageProfanity }
+
+func (s *profanityStage) Process(text string) string {
+	if s.pattern == nil {
+		return text
+	}
+	return s.pattern.ReplaceAllStringFunc(text, func(m string) string {
+		return strings.Repeat(s.mask, utf8.RuneCountInString(m))
+	})
+}
+
+// ---------------- regex ----------------
+
+type regexRule struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// regexStage 按顺序执行正则替换
+type regexStage struct {
+	rules []regexRule
+}
+
+func newRegexStage(patterns []config.RegexRule) (*regexStage, error) {
+	s := &regexStage{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", p.Pattern, err)
+		}
+		s.rules = append(s.rules, regexRule{pattern: re, replace: p.Replace})
+	}
+	return s, nil
+}
+
+func (s *regexStage) Type() string { return StageRegex }
+
+func (s *regexStage) Process(text string) string {
+	for _, r := range s.rules {
+		text = r.pattern.ReplaceAllString(text, r.replace)
+	}
+	return text
+}
+
+// ---------------- filler ----------------
+
+// defaultFillerWords 未配置 words 时移除的语气词
+var defaultFillerWords = []string{"嗯", "呃", "额", "uh", "um", "umm", "er", "erm", "hmm"}
+
+// fillerStage 移除语气词及其后紧跟的逗号和空白
+type fillerStage struct {
+	pattern *regexp.Regexp
+	spaces  *regexp.Regexp
+}
+
+func newFillerStage(words []string) (*fillerStage, error) {
+	if len(words) == 0 {
+		words = defaultFillerWords
+	}
+	pattern, err := wordsPattern(words, "", `[，,、]?\s*`)
+	if err != nil {
+		return nil, err
+	}
+	return &fillerStage{pattern: pattern, spaces: regexp.MustCompile(`[ \t]{2,}`)}, nil
+}
+
+func (s *fillerStage) Type() string { return StageFiller }
+
+func (s *fillerStage) Process(text string) string {
+	if s.pattern == nil {
+		return text
+	}
+	text = s.pattern.ReplaceAllString(text, "")
+	return strings.TrimSpace(s.spaces.ReplaceAllString(text, " "))
+}
+
+// ---------------- replacements ----------------
+
+// replacementStage 固定词典替换，同一位置先匹配先出现的规则
+type replacementStage struct {
+	replacer *strings.Replacer
+}
+
+func newReplacementStage(rules []config.ReplacementRule) *replacementStage {
+	pairs := make([]string, 0, len(rules)*2)
+	for _, rule := range rules {
+		if rule.From == "" {
+			continue
+		}
+		pairs = append(pairs, rule.From, rule.To)
+	}
+	if len(pairs) == 0 {
+		return &replacementStage{}
+	}
+	return &replacementStage{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (s *replacementStage) Type() string { return StageReplacements }
+
+func (s *replacementStage) Process(text string) string {
+	if s.replacer == nil {
+		return text
+	}
+	return s.replacer.Replace(text)
+}
+
+// ---------------- helpers ----------------
+
+// wordsPattern 将词表编译为一个正则：ASCII 词按整词、忽略大小写匹配，其他按子串匹配；
+// 长词优先，避免短词截断长词。prefix/suffix 附加在每个词的前后
+func wordsPattern(words []string, prefix, suffix string) (*regexp.Regexp, error) {
+	cleaned := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			cleaned = append(cleaned, w)
+		}
+	}
+	if len(cleaned) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(cleaned, func(i, j int) bool { return len(cleaned[i]) > len(cleaned[j]) })
+
+	alts := make([]string, len(cleaned))
+	for i, w := range cleaned {
+		quoted := regexp.QuoteMeta(w)
+		if isASCIIWord(w) {
+			alts[i] = `\b(?i:` + quoted + `)\b`
+		} else {
+			alts[i] = quoted
+		}
+	}
+	return regexp.Compile(prefix + "(?:" + strings.Join(alts, "|") + ")" + suffix)
+}
+
+func isASCIIWord(w string) bool {
+	for _, r := range w {
+		if r >= unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// loadWordFile 读取每行一个词的词表文件，忽略空行与 # 注释
+func loadWordFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// loadReplacementFile 读取替换词典文件
+func loadReplacementFile(path string) ([]config.ReplacementRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseReplacementRules(f)
+}
+
+// parseReplacementRules 解析「原文<Tab>替换」格式，忽略空行、# 注释与没有 Tab 的行；替换为空表示删除原文
+func parseReplacementRules(r io.Reader) ([]config.ReplacementRule, error) {
+	var rules []config.ReplacementRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		from, to, found := strings.Cut(line, "\t")
+		if !found || from == "" {
+			continue
+		}
+		rules = append(rules, config.ReplacementRule{From: from, To: to})
+	}
+	return rules, scanner.Err()
+}
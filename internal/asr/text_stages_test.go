@@ -0,0 +1,92 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+
+	"airecorder/internal/config"
+)
+
+func TestCasingStage(t *testing.T) {
+	cases := []struct {
+		mode, in, want string
+	}{
+		{"sentence", "hello world. i think i am here! ok", "Hello world. I think I am here! Ok"},
+		{"sentence", "你好。this is it", "你好。This is it"},
+		{"lower", "Hello World", "hello world"},
+		{"upper", "Hello World", "HELLO WORLD"},
+	}
+	for _, tc := range cases {
+		stage, err := newCasingStage(tc.mode)
+		if err != nil {
+			t.Fatalf("mode %s: %v", tc.mode, err)
+		}
+		if got := stage.Process(tc.in); got != tc.want {
+			t.Errorf("%s(%q) = %q, want %q", tc.mode, tc.in, got, tc.want)
+		}
+	}
+	if _, err := newCasingStage("title"); err == nil {
+		t.Fatal("expected error for unknown casing mode")
+	}
+}
+
+func TestProfanityStage(t *testing.T) {
+	stage, err := newProfanityStage([]string{"damn", "混蛋"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := "Damn it, 你这个混蛋, damnation"
+	want := "**** it, 你这个**, damnation"
+	if got := stage.Process(in); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegexStage(t *testing.T) {
+	stage, err := newRegexStage([]config.RegexRule{
+		{Pattern: `(\d{3})(\d{4})(\d{4})`, Replace: "$1-$2-$3"},
+		{Pattern: `\s+`, Replace: " "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stage.Process("call  13800138000 now"); got != "call 138-0013-8000 now" {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if _, err := newRegexStage([]config.RegexRule{{Pattern: "("}}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
+
+func TestFillerStage(t *testing.T) {
+	stage, err := newFillerStage(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"嗯，我觉得呃这个可以":             "我觉得这个可以",
+		"uh I think um it works": "I think it works",
+		"the umbrella is here":   "the umbrella is here",
+	}
+	for in, want := range cases {
+		if got := stage.Process(in); got != want {
+			t.Errorf("Process(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseReplacementRules(t *testing.T) {
+	rules, err := parseReplacementRules(strings.NewReader("# 注释\n\n喜马拉雅\tHimalaya\r\n呃\t\n无效行\n"))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	want := []config.ReplacementRule{{From: "喜马拉雅", To: "Himalaya"}, {From: "呃", To: ""}}
+	if len(rules) != len(want) {
+		t.Fatalf("expected %v, got %v", want, rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Fatalf("rule %d: expected %v, got %v", i, want[i], rules[i])
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package asr
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"airecorder/internal/recording"
+)
+
+// watcherBuffer 每个旁观者缓存的事件数，旁观者处理不过来时丢弃新事件
+const watcherBuffer = 64
+
+// 旁观事件类型
+const (
+	WatchEventPartial = "partial" // 中间结果
+	WatchEventResult  = "result"  // 整句结果
+	WatchEventLevel   = "level"   // 音频电平
+	WatchEventClosed  = "closed"  // 会话已关闭
+)
+
+// SessionEvent 推送给旁观者的会话事件
+type SessionEvent struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text,omitempty"`
+	Segment int       `json:"segment,omitempty"`
+	Speaker *int      `json:"speaker,omitempty"`
+	Level   float32   `json:"level,omitempty"` // 音频电平（dBFS，-100 ~ 0）
+	Time    time.Time `json:"time"`
+}
+
+// Watcher 一个会话旁观者
+type Watcher struct {
+	SessionID string
+	events    chan SessionEvent
+	level     bool
+	dropped   int64
+}
+
+// Events 事件通道，会话关闭或取消旁观后关闭
+func (w *Watcher) Events() <-chan SessionEvent {
+	return w.events
+}
+
+// Dropped 因旁观者处理过慢而丢弃的事件数
+func (w *Watcher) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// watchHub 会话事件的发布/订阅。发布不阻塞：旁观者缓存已满时丢弃事件，不拖慢识别会话
+type watchHub struct {
+	subs map[string]map[*Watcher]struct{}
+	mu   sync.RWMutex
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{subs: make(map[string]map[*Watcher]struct{})}
+}
+
+// subscribe 订阅会话事件，level 为 true 时同时接收音频电平
+func (h *watchHub) subscribe(sessionID string, level bool) *Watcher {
+	w := &Watcher{SessionID: sessionID, events: make(chan SessionEvent, watcherBuffer), level: level}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[sessionID] == nil {
+		h.subs[sessionID] = make(map[*Watcher]struct{})
+	}
+	h.subs[sessionID][w] = struct{}{}
+	return w
+}
+
+// unsubscribe 取消订阅并关闭事件通道，可重复调用
+func (h *watchHub) unsubscribe(w *Watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := h.subs[w.SessionID]
+	if _, ok := subs[w]; !ok {
+		return
+	}
+	delete(subs, w)
+	if len(subs) == 0 {
+		delete(h.subs, w.SessionID)
+	}
+	close(w.events)
+}
+
+// publish 向会话的旁观者推送事件
+func (h *watchHub) publish(sessionID string, ev SessionEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for w := range h.subs[sessionID] {
+		if ev.Type == WatchEventLevel && !w.level {
+			continue
+		}
+		select {
+		case w.events <- ev:
+		default:
+			atomic.AddInt64(&w.dropped, 1)
+		}
+	}
+}
+
+// wantsLevel 会话是否有旁观者需要音频电平
+func (h *watchHub) wantsLevel(sessionID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for w := range h.subs[sessionID] {
+		if w.level {
+			return true
+		}
+	}
+	return false
+}
+
+// closeSession 会话关闭：通知并移除全部旁观者
+func (h *watchHub) closeSession(sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for w := range h.subs[sessionID] {
+		select {
+		case w.events <- SessionEvent{Type: WatchEventClosed, Time: time.Now()}:
+		default:
+			atomic.AddInt64(&w.dropped, 1)
+		}
+		close(w.events)
+	}
+	delete(h.subs, sessionID)
+}
+
+// audioLevel 音频的 RMS 电平（dBFS），静音时为 -100
+func audioLevel(samples []float32) float32 {
+	if len(samples) == 0 {
+		return -100
+	}
+	var sum float64
+	for _, x := range samples {
+		sum += float64(x) * float64(x)
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+	if rms <= 1e-5 {
+		return -100
+	}
+	return float32(20 * math.Log10(rms))
+}
+
+// Publish 向旁观者推送会话事件，会话在录音时同时写入识别结果日志
+func (s *StreamingASRSession) Publish(ev SessionEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	s.mu.Lock()
+	rec := s.recording
+	s.mu.Unlock()
+	if rec != nil && (ev.Type == WatchEventPartial || ev.Type == WatchEventResult) {
+		rec.WriteEntry(recording.Entry{Type: ev.Type, Text: ev.Text, Segment: ev.Segment, Speaker: ev.Speaker})
+	}
+	if s.hub != nil {
+		s.hub.publish(s.ID, ev)
+	}
+}
+
+// PublishLevel 有旁观者需要时推送本段音频的电平
+func (s *StreamingASRSession) PublishLevel(samples []float32) {
+	if s.hub == nil || !s.hub.wantsLevel(s.ID) {
+		return
+	}
+	s.hub.publish(s.ID, SessionEvent{Type: WatchEventLevel, Level: audioLevel(samples), Time: time.Now()})
+}
+
+// WatchSession 旁观指定会话的识别结果，level 为 true 时同时接收音频电平。
+// 使用完毕后需调用 Unwatch
+func (m *StreamingASRManager) WatchSession(sessionID string, level bool) (*Watcher, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, exists := m.sessions[sessionID]; !exists {
+		return nil, fmt.Errorf("session not found")
+	}
+	return m.hub.subscribe(sessionID, level), nil
+}
+
+// Unwatch 取消旁观
+func (m *StreamingASRManager) Unwatch(w *Watcher) {
+	m.hub.unsubscribe(w)
+}
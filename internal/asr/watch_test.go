@@ -0,0 +1,102 @@
+package asr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchHubSlowWatcherDoesNotBlock(t *testing.T) {
+	h := newWatchHub()
+	slow := h.subscribe("s1", false)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < watcherBuffer+10; i++ {
+			h.publish("s1", SessionEvent{Type: WatchEventPartial, Segment: i})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a slow watcher")
+	}
+	if slow.Dropped() != 10 {
+		t.Fatalf("dropped = %d, want 10", slow.Dropped())
+	}
+	if ev := <-slow.Events(); ev.Segment != 0 {
+		t.Fatalf("oldest buffered event should be kept, got segment %d", ev.Segment)
+	}
+}
+
+func TestWatchHubLevelAndClose(t *testing.T) {
+	h := newWatchHub()
+	text := h.subscribe("s1", false)
+	level := h.subscribe("s1", true)
+	other := h.subscribe("s2", true)
+
+	if !h.wantsLevel("s1") || h.wantsLevel("s3") {
+		t.Fatal("wantsLevel should reflect level watchers")
+	}
+	h.publish("s1", SessionEvent{Type: WatchEventLevel, Level: -20})
+	h.publish("s1", SessionEvent{Type: WatchEventResult, Text: "你好"})
+	h.closeSession("s1")
+
+	var got []string
+	for ev := range level.Events() {
+		got = append(got, ev.Type)
+	}
+	if len(got) != 3 || got[0] != WatchEventLevel || got[2] != WatchEventClosed {
+		t.Fatalf("level watcher events = %v", got)
+	}
+	got = nil
+	for ev := range text.Events() {
+		got = append(got, ev.Type)
+	}
+	if len(got) != 2 || got[0] != WatchEventResult {
+		t.Fatalf("text watcher should not receive levels, got %v", got)
+	}
+
+	// 已随会话关闭的旁观者可以再次取消订阅
+	h.unsubscribe(text)
+	h.unsubscribe(other)
+	h.unsubscribe(other)
+	if _, ok := <-other.Events(); ok {
+		t.Fatal("unsubscribed watcher channel should be closed")
+	}
+}
+
+func TestWatchSessionClosedWithSession(t *testing.T) {
+	m, session := newTestSessionManager("s1")
+	if _, err := m.WatchSession("missing", false); err == nil {
+		t.Fatal("watching a missing session should fail")
+	}
+	w, err := m.WatchSession("s1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Publish(SessionEvent{Type: WatchEventPartial, Text: "今天"})
+	m.CloseSession("s1")
+
+	ev := <-w.Events()
+	if ev.Text != "今天" || ev.Time.IsZero() {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev := <-w.Events(); ev.Type != WatchEventClosed {
+		t.Fatalf("expected closed event, got %+v", ev)
+	}
+	m.Unwatch(w)
+}
+
+func TestAudioLevel(t *testing.T) {
+	if got := audioLevel(make([]float32, 160)); got != -100 {
+		t.Fatalf("silence level = %v, want -100", got)
+	}
+	full := make([]float32, 160)
+	for i := range full {
+		full[i] = 1
+	}
+	if got := audioLevel(full); got != 0 {
+		t.Fatalf("full scale level = %v, want 0", got)
+	}
+}
@@ -2,12 +2,18 @@ package audio
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os/exec"
 	"strings"
+
+	"airecorder/internal/metrics"
+	"airecorder/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // AudioFormat 音频格式类型
@@ -41,13 +47,22 @@ func NewAudioConverter() *AudioConverter {
 
 // ConvertToSamples 将各种音频格式转换为 float32 样本
 func (c *AudioConverter) ConvertToSamples(audioData []byte) ([]float32, int, error) {
+	return c.ConvertToSamplesContext(context.Background(), audioData)
+}
+
+// ConvertToSamplesContext 同 ConvertToSamples，转换过程记录为 ctx 下的 span
+func (c *AudioConverter) ConvertToSamplesContext(ctx context.Context, audioData []byte) (samples []float32, sampleRate int, err error) {
+	_, span := tracing.Start(ctx, "audio.convert", attribute.Int("audio.bytes", len(audioData)))
+	defer func() { tracing.End(span, err) }()
+
 	// 检测音频格式
 	format, err := c.detectFormat(audioData)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to detect audio format: %w", err)
 	}
 
-	log.Printf("Detected audio format: %s", format)
+	slog.Debug("Detected audio format", "format", format)
+	span.SetAttributes(attribute.String("audio.format", string(format)))
 
 	// 根据格式进行转换
 	switch format {
@@ -179,8 +194,8 @@ func (c *AudioConverter) convertWAV(data []byte) ([]float32, int, error) {
 		}
 	}
 
-	log.Printf("WAV info: format=%d, channels=%d, sampleRate=%d, bitsPerSample=%d, dataSize=%d",
-		audioFormat, numChannels, sampleRate, bitsPerSample, len(audioData))
+	slog.Debug("WAV info", "format", audioFormat, "channels", numChannels, "sample_rate", sampleRate,
+		"bits_per_sample", bitsPerSample, "data_size", len(audioData))
 
 	// 转换为 float32 样本
 	samples, err := c.decodePCM(audioData, bitsPerSample, numChannels)
@@ -254,10 +269,11 @@ func (c *AudioConverter) decodePCM(data []byte, bitsPerSample uint16, numChannel
 func (c *AudioConverter) convertWithFFmpeg(audioData []byte, format AudioFormat) ([]float32, int, error) {
 	// 检查 FFmpeg 是否可用
 	if !c.isFFmpegAvailable() {
+		metrics.FFmpegFailures.Inc("unavailable")
 		return nil, 0, fmt.Errorf("FFmpeg is not available. Please install FFmpeg to support %s format", format)
 	}
 
-	log.Printf("Converting %s format using FFmpeg...", format)
+	slog.Debug("Converting audio using FFmpeg", "format", format)
 
 	// 使用 FFmpeg 将音频转换为 16kHz, 16bit, mono PCM
 	cmd := exec.Command("ffmpeg",
@@ -275,12 +291,13 @@ func (c *AudioConverter) convertWithFFmpeg(audioData []byte, format AudioFormat)
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		log.Printf("FFmpeg error: %s", stderr.String())
+		slog.Error("FFmpeg error", "stderr", stderr.String())
+		metrics.FFmpegFailures.Inc("failed")
 		return nil, 0, fmt.Errorf("FFmpeg conversion failed: %w", err)
 	}
 
 	pcmData := stdout.Bytes()
-	log.Printf("Converted to PCM: %d bytes", len(pcmData))
+	slog.Debug("Converted to PCM", "bytes", len(pcmData))
 
 	// 转换 PCM 为 float32
 	samples, err := c.decodePCM(pcmData, 16, 1)
@@ -300,13 +317,19 @@ func (c *AudioConverter) isFFmpegAvailable() bool {
 	return true
 }
 
+// FFmpegAvailable 是否能找到 ffmpeg 可执行文件（只查找 PATH，不启动进程，可用于频繁调用的健康检查）
+func FFmpegAvailable() bool {
+	_, err := exec.LookPath("ffmpeg")
+	return err == nil
+}
+
 // resample 简单的线性重采样（生产环境建议使用更高质量的重采样算法）
 func (c *AudioConverter) resample(samples []float32, fromRate, toRate int) []float32 {
 	if fromRate == toRate {
 		return samples
 	}
 
-	log.Printf("Resampling from %d Hz to %d Hz", fromRate, toRate)
+	slog.Debug("Resampling", "from_hz", fromRate, "to_hz", toRate)
 
 	ratio := float64(fromRate) / float64(toRate)
 	newLength := int(float64(len(samples)) / ratio)
@@ -356,3 +379,43 @@ func FormatDescription() string {
 
 	return desc.String()
 }
+
+// EncodeWAV 将 float32 样本编码为 16-bit 单声道 PCM WAV
+func EncodeWAV(samples []float32, sampleRate int) []byte {
+	pcm := EncodePCM16(samples)
+	return append(WAVHeader(len(pcm), sampleRate), pcm...)
+}
+
+// WAVHeader 16-bit 单声道 PCM WAV 文件头，dataSize 为 PCM 数据字节数
+func WAVHeader(dataSize int, sampleRate int) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, 44))
+
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(buf, binary.LittleEndian, uint32(16))           // fmt chunk size
+	binary.Write(buf, binary.LittleEndian, uint16(1))            // PCM
+	binary.Write(buf, binary.LittleEndian, uint16(1))            // 单声道
+	binary.Write(buf, binary.LittleEndian, uint32(sampleRate))   // 采样率
+	binary.Write(buf, binary.LittleEndian, uint32(sampleRate*2)) // 字节率
+	binary.Write(buf, binary.LittleEndian, uint16(2))            // block align
+	binary.Write(buf, binary.LittleEndian, uint16(16))           // 位深
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, uint32(dataSize))
+	return buf.Bytes()
+}
+
+// EncodePCM16 将 float32 样本编码为 16-bit 小端 PCM
+func EncodePCM16(samples []float32) []byte {
+	pcm := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		if s > 1 {
+			s = 1
+		} else if s < -1 {
+			s = -1
+		}
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(s*32767)))
+	}
+	return pcm
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -15,8 +16,25 @@ type Config struct {
 	SpeakerDiarization SpeakerDiarizationConfig `yaml:"speaker_diarization"`
 	VAD                VADConfig                `yaml:"vad"`
 	Punctuation        PunctuationConfig        `yaml:"punctuation"`
+	PostProcess        PostProcessConfig        `yaml:"post_process"`
+	TextPipelines      TextPipelinesConfig      `yaml:"text_pipelines"`
+	LanguageID         LanguageIDConfig         `yaml:"language_id"`
+	Models             []ModelEntryConfig       `yaml:"models"`
+	Hotwords           HotwordsConfig           `yaml:"hotwords"`
 	Concurrency        ConcurrencyConfig        `yaml:"concurrency"`
+	Recording          RecordingConfig          `yaml:"recording"`
+	Metrics            MetricsConfig            `yaml:"metrics"`
+	Tracing            TracingConfig            `yaml:"tracing"`
+	Health             HealthConfig             `yaml:"health"`
 	Logging            LoggingConfig            `yaml:"logging"`
+
+	unknownEnv []string // 未对应任何配置字段的 AIRECORDER_ 环境变量
+}
+
+// UnknownEnv 未对应任何配置字段的 AIRECORDER_ 环境变量名称。
+// 加载配置时只记录警告，避免多余或改名的环境变量导致服务无法启动；--check-config 时视为错误
+func (c *Config) UnknownEnv() []string {
+	return c.unknownEnv
 }
 
 type AdminConfig struct {
@@ -35,49 +53,101 @@ type ServerConfig struct {
 	MaxConnections int    `yaml:"max_connections"`
 	ReadTimeout    int    `yaml:"read_timeout"`
 	WriteTimeout   int    `yaml:"write_timeout"`
+	// 收到 SIGTERM/SIGINT 后等待实时连接与离线任务结束的时间（秒），默认 30
+	ShutdownTimeout int `yaml:"shutdown_timeout"`
+	// 超时仍未完成的离线任务保存目录（音频与参数），为空时只记录日志
+	UnfinishedTasksDir string `yaml:"unfinished_tasks_dir"`
 }
 
 type StreamingASRConfig struct {
+	Enabled                 bool                      `yaml:"enabled"`
+	ModelType               string                    `yaml:"model_type"`
+	ModelsDir               string                    `yaml:"models_dir"`
+	Encoder                 string                    `yaml:"encoder"`
+	Decoder                 string                    `yaml:"decoder"`
+	Joiner                  string                    `yaml:"joiner"`
+	Tokens                  string                    `yaml:"tokens"`
+	Paraformer              StreamingParaformerConfig `yaml:"paraformer"`     // model_type: paraformer
+	Zipformer2CTC           StreamingCTCConfig        `yaml:"zipformer2_ctc"` // model_type: zipformer2_ctc
+	NemoCTC                 StreamingCTCConfig        `yaml:"nemo_ctc"`       // model_type: nemo_ctc
+	ModelingUnit            string                    `yaml:"modeling_unit"`  // 热词编码所需：cjkchar、bpe 或 cjkchar+bpe
+	BpeVocab                string                    `yaml:"bpe_vocab"`      // 热词编码所需的 bpe 词表（modeling_unit 含 bpe 时）
+	HotwordsFile            string                    `yaml:"hotwords_file"`  // 固定热词文件（仅 transducer，需 modified_beam_search）
+	HotwordsScore           float32                   `yaml:"hotwords_score"` // 热词默认加分
+	DecodingMethod          string                    `yaml:"decoding_method"`
+	MaxActivePaths          int                       `yaml:"max_active_paths"`
+	NumThreads              int                       `yaml:"num_threads"`
+	SampleRate              int                       `yaml:"sample_rate"`
+	FeatureDim              int                       `yaml:"feature_dim"`
+	EnableEndpoint          bool                      `yaml:"enable_endpoint"`
+	Rule1MinTrailingSilence float32                   `yaml:"rule1_min_trailing_silence"`
+	Rule2MinTrailingSilence float32                   `yaml:"rule2_min_trailing_silence"`
+	Rule3MinUtteranceLength float32                   `yaml:"rule3_min_utterance_length"`
+	StablePrefixPartials    int                       `yaml:"stable_prefix_partials"` // 前缀在连续多少个中间结果中不变即视为稳定，默认 3
+	ResumeGraceSec          int                       `yaml:"resume_grace_sec"`       // 连接意外断开后保留会话等待重连的秒数，0 表示不保留
+	ResumeBuffer            int                       `yaml:"resume_buffer"`          // 每个会话保留的未确认消息条数，默认 256
+	PingIntervalSec         int                       `yaml:"ping_interval_sec"`      // 服务端发送 ping 的间隔秒数，0 表示不发送
+	PongTimeoutSec          int                       `yaml:"pong_timeout_sec"`       // 超过该秒数未收到任何消息或 pong 即视为连接失效，0 表示不检查
+	IdleTimeoutSec          int                       `yaml:"idle_timeout_sec"`       // 超过该秒数未收到音频即关闭会话，0 表示不限制
+	MaxSessionSec           int                       `yaml:"max_session_sec"`        // 单个连接的最长时长秒数，0 表示不限制
+}
+
+// StreamingParaformerConfig 流式 Paraformer 模型文件（相对 models_dir）
+type StreamingParaformerConfig struct {
+	Encoder string `yaml:"encoder"`
+	Decoder string `yaml:"decoder"`
+}
+
+// StreamingCTCConfig 流式 CTC 模型文件（相对 models_dir）
+type StreamingCTCConfig struct {
+	Model string `yaml:"model"`
+}
+
+type OfflineASRConfig struct {
 	Enabled                 bool    `yaml:"enabled"`
 	ModelType               string  `yaml:"model_type"`
 	ModelsDir               string  `yaml:"models_dir"`
+	Model                   string  `yaml:"model"` // 单文件模型：sense_voice、nemo_ctc、zipformer_ctc、tdnn、paraformer
 	Encoder                 string  `yaml:"encoder"`
 	Decoder                 string  `yaml:"decoder"`
-	Joiner                  string  `yaml:"joiner"`
+	Joiner                  string  `yaml:"joiner"`           // transducer
+	Preprocessor            string  `yaml:"preprocessor"`     // moonshine
+	UncachedDecoder         string  `yaml:"uncached_decoder"` // moonshine
+	CachedDecoder           string  `yaml:"cached_decoder"`   // moonshine
+	Language                string  `yaml:"language"`         // sense_voice / whisper 语种，为空时自动检测
+	UseITN                  bool    `yaml:"use_itn"`          // sense_voice 逆文本正则化（数字、日期等转写为阿拉伯数字）
+	Task                    string  `yaml:"task"`             // whisper 任务：transcribe 或 translate
+	ModelingUnit            string  `yaml:"modeling_unit"`    // 热词编码所需：cjkchar、bpe 或 cjkchar+bpe
+	BpeVocab                string  `yaml:"bpe_vocab"`        // 热词编码所需的 bpe 词表（modeling_unit 含 bpe 时）
+	HotwordsFile            string  `yaml:"hotwords_file"`    // 固定热词文件（仅 transducer，需 modified_beam_search）
+	HotwordsScore           float32 `yaml:"hotwords_score"`   // 热词默认加分
 	Tokens                  string  `yaml:"tokens"`
 	NumThreads              int     `yaml:"num_threads"`
 	SampleRate              int     `yaml:"sample_rate"`
-	FeatureDim              int     `yaml:"feature_dim"`
-	EnableEndpoint          bool    `yaml:"enable_endpoint"`
-	Rule1MinTrailingSilence float32 `yaml:"rule1_min_trailing_silence"`
-	Rule2MinTrailingSilence float32 `yaml:"rule2_min_trailing_silence"`
-	Rule3MinUtteranceLength float32 `yaml:"rule3_min_utterance_length"`
+	DecodingMethod          string  `yaml:"decoding_method"`
+	MaxActivePaths          int     `yaml:"max_active_paths"`
+	MaxFileSizeMB           int     `yaml:"max_file_size_mb"`           // 最大文件大小（MB）
+	ChunkDurationSec        int     `yaml:"chunk_duration_sec"`         // 分块处理时长（秒）
+	MaxConcurrency          int     `yaml:"max_concurrency"`            // 最大并发处理数
+	MaxProcessingTimeoutMin int     `yaml:"max_processing_timeout_min"` // 最大处理超时时间（分钟）
 }
 
-type OfflineASRConfig struct {
-	Enabled                 bool   `yaml:"enabled"`
-	ModelType               string `yaml:"model_type"`
-	ModelsDir               string `yaml:"models_dir"`
-	Encoder                 string `yaml:"encoder"`
-	Decoder                 string `yaml:"decoder"`
-	Tokens                  string `yaml:"tokens"`
-	NumThreads              int    `yaml:"num_threads"`
-	SampleRate              int    `yaml:"sample_rate"`
-	DecodingMethod          string `yaml:"decoding_method"`
-	MaxActivePaths          int    `yaml:"max_active_paths"`
-	MaxFileSizeMB           int    `yaml:"max_file_size_mb"`           // 最大文件大小（MB）
-	ChunkDurationSec        int    `yaml:"chunk_duration_sec"`         // 分块处理时长（秒）
-	MaxConcurrency          int    `yaml:"max_concurrency"`            // 最大并发处理数
-	MaxProcessingTimeoutMin int    `yaml:"max_processing_timeout_min"` // 最大处理超时时间（分钟）
+type SpeakerDiarizationConfig struct {
+	Enabled           bool                       `yaml:"enabled"`
+	ModelsDir         string                     `yaml:"models_dir"`
+	SegmentationModel string                     `yaml:"segmentation_model"`
+	EmbeddingModel    string                     `yaml:"embedding_model"`
+	Clustering        ClusteringConfig           `yaml:"clustering"`
+	NumThreads        int                        `yaml:"num_threads"`
+	Streaming         StreamingDiarizationConfig `yaml:"streaming"` // 实时识别的说话人标注
 }
 
-type SpeakerDiarizationConfig struct {
-	Enabled           bool             `yaml:"enabled"`
-	ModelsDir         string           `yaml:"models_dir"`
-	SegmentationModel string           `yaml:"segmentation_model"`
-	EmbeddingModel    string           `yaml:"embedding_model"`
-	Clustering        ClusteringConfig `yaml:"clustering"`
-	NumThreads        int              `yaml:"num_threads"`
+// StreamingDiarizationConfig 实时说话人分离：在每句结束时提取说话人特征并在线聚类（使用 embedding_model）
+type StreamingDiarizationConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Threshold   float32 `yaml:"threshold"`    // 归入已有说话人的余弦相似度阈值，默认 0.5
+	MaxSpeakers int     `yaml:"max_speakers"` // 每个会话的最大说话人数，0 表示不限
+	Extractors  int     `yaml:"extractors"`   // 各会话共用的特征提取器数量（可同时计算的句子数），默认 2
 }
 
 type ClusteringConfig struct {
@@ -104,6 +174,204 @@ type PunctuationConfig struct {
 	NumThreads int    `yaml:"num_threads"`
 }
 
+// PostProcessConfig 识别结果后处理配置（在标点之后依次执行 ITN 与替换词典）
+type PostProcessConfig struct {
+	ITN          ITNConfig          `yaml:"itn"`
+	Replacements ReplacementsConfig `yaml:"replacements"`
+}
+
+// ITNConfig 逆文本正则化配置（中英文数字、日期、金额、百分比转写）
+type ITNConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// ReplacementsConfig 自定义替换词典，rules 在 file 之后追加
+type ReplacementsConfig struct {
+	Enabled bool              `yaml:"enabled"`
+	File    string            `yaml:"file"` // 每行「原文<Tab>替换」，# 开头为注释
+	Rules   []ReplacementRule `yaml:"rules"`
+}
+
+// ReplacementRule 一条替换规则
+type ReplacementRule struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// TextPipelinesConfig 文本后处理流水线配置。未配置任何流水线时，
+// 按 punctuation 与 post_process 生成名为 default 的流水线（标点 → ITN → 替换词典）
+type TextPipelinesConfig struct {
+	Default   string               `yaml:"default"` // 默认流水线名称，为空时使用第一条
+	Pipelines []TextPipelineConfig `yaml:"pipelines"`
+}
+
+// TextPipelineConfig 一条命名流水线，stages 按顺序执行
+type TextPipelineConfig struct {
+	Name   string            `yaml:"name"`
+	Stages []TextStageConfig `yaml:"stages"`
+}
+
+// TextStageConfig 流水线中的一个阶段
+type TextStageConfig struct {
+	Type     string            `yaml:"type"`     // punctuation、itn、casing、profanity、regex、filler、replacements、redact
+	Enabled  *bool             `yaml:"enabled"`  // 设为 false 时默认跳过，请求可单独打开
+	Mode     string            `yaml:"mode"`     // casing：lower、upper、sentence
+	Words    []string          `yaml:"words"`    // profanity 敏感词 / filler 语气词
+	Mask     string            `yaml:"mask"`     // profanity 掩码字符，默认 *
+	File     string            `yaml:"file"`     // replacements 词典文件 / profanity 词表文件（每行一个词）
+	Rules    []ReplacementRule `yaml:"rules"`    // replacements 规则
+	Patterns []RegexRule       `yaml:"patterns"` // regex 规则
+	Entities []string          `yaml:"entities"` // redact 识别的信息类型：phone、id_card、bank_card、email，为空时全部识别
+	Tags     map[string]string `yaml:"tags"`     // redact 各类型的替换标签，默认 [PHONE]、[ID_CARD]、[BANK_CARD]、[EMAIL]
+}
+
+// RegexRule 正则替换规则，replace 中可使用 $1 引用分组
+type RegexRule struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// LanguageIDConfig 语种识别配置（基于 Whisper 的 SpokenLanguageIdentification）
+type LanguageIDConfig struct {
+	Enabled         bool                        `yaml:"enabled"`
+	DefaultLanguage string                      `yaml:"default_language"` // offline_asr 默认模型识别的语种，为空表示未知（请求指定任意语种都回退到默认模型）
+	ModelsDir       string                      `yaml:"models_dir"`
+	Encoder         string                      `yaml:"encoder"`
+	Decoder         string                      `yaml:"decoder"`
+	NumThreads      int                         `yaml:"num_threads"`
+	TailPaddings    int                         `yaml:"tail_paddings"`
+	ProbeWindowSec  int                         `yaml:"probe_window_sec"` // 单个探测窗口时长（秒）
+	ProbeWindows    int                         `yaml:"probe_windows"`    // 探测窗口数量，多窗口投票得到置信度
+	Recognizers     map[string]OfflineASRConfig `yaml:"recognizers"`      // 语种 -> 离线识别模型，未命中时使用 offline_asr
+}
+
+const (
+	// ModelTypeOffline 离线（非流式）模型
+	ModelTypeOffline = "offline"
+	// ModelTypeStreaming 实时（流式）模型
+	ModelTypeStreaming = "streaming"
+)
+
+// ModelEntryConfig models 列表中的一项命名模型。
+// 除 name/type/default 外的字段与 offline_asr 或 streaming_asr 相同，未填写的字段继承对应的主配置
+type ModelEntryConfig struct {
+	Name      string             `yaml:"name"`
+	Type      string             `yaml:"type"`    // offline 或 streaming
+	Default   bool               `yaml:"default"` // 是否作为该类型的默认模型
+	Offline   OfflineASRConfig   `yaml:"-"`
+	Streaming StreamingASRConfig `yaml:"-"`
+	raw       *yaml.Node
+}
+
+// UnmarshalYAML 保留原始节点，待主配置解析完成后再叠加到对应的主配置上
+func (m *ModelEntryConfig) UnmarshalYAML(node *yaml.Node) error {
+	var head modelEntryHead
+	if err := node.Decode(&head); err != nil {
+		return err
+	}
+	m.Name = head.Name
+	m.Type = head.Type
+	m.Default = head.Default
+	m.raw = node
+	return nil
+}
+
+// MarshalYAML 输出解析后的完整配置（含继承自主配置的字段）
+func (m ModelEntryConfig) MarshalYAML() (interface{}, error) {
+	head := modelEntryHead{Name: m.Name, Type: m.Type, Default: m.Default}
+	switch m.Type {
+	case ModelTypeOffline:
+		return struct {
+			modelEntryHead   `yaml:",inline"`
+			OfflineASRConfig `yaml:",inline"`
+		}{head, m.Offline}, nil
+	case ModelTypeStreaming:
+		return struct {
+			modelEntryHead     `yaml:",inline"`
+			StreamingASRConfig `yaml:",inline"`
+		}{head, m.Streaming}, nil
+	}
+	return head, nil
+}
+
+type modelEntryHead struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"`
+	Default bool   `yaml:"default"`
+}
+
+// resolveModelEntries 以主配置为基础解析每个命名模型的完整配置
+func (c *Config) resolveModelEntries() error {
+	for i := range c.Models {
+		entry := &c.Models[i]
+		if entry.raw == nil {
+			continue
+		}
+		switch entry.Type {
+		case ModelTypeOffline:
+			entry.Offline = c.OfflineASR
+			entry.Offline.Enabled = true
+			if err := entry.raw.Decode(&entry.Offline); err != nil {
+				return fmt.Errorf("models[%d] (%s): %w", i, entry.Name, err)
+			}
+		case ModelTypeStreaming:
+			entry.Streaming = c.StreamingASR
+			entry.Streaming.Enabled = true
+			if err := entry.raw.Decode(&entry.Streaming); err != nil {
+				return fmt.Errorf("models[%d] (%s): %w", i, entry.Name, err)
+			}
+		default:
+			return fmt.Errorf("models[%d] (%s): unknown type %q, expected offline or streaming", i, entry.Name, entry.Type)
+		}
+		entry.raw = nil
+	}
+	return nil
+}
+
+// HotwordsConfig 热词（上下文偏置）配置，仅对 transducer 模型生效
+type HotwordsConfig struct {
+	Enabled              bool    `yaml:"enabled"`
+	StoreFile            string  `yaml:"store_file"`             // 命名热词表持久化文件
+	DefaultBoost         float32 `yaml:"default_boost"`          // 未指定 boost 时的加分
+	MaxPhrases           int     `yaml:"max_phrases"`            // 单次请求最多热词数
+	MaxCachedRecognizers int     `yaml:"max_cached_recognizers"` // 带热词的识别器缓存数量
+}
+
+// RecordingConfig 实时会话录音存档（音频 WAV + 识别结果 JSONL）
+type RecordingConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	Dir             string   `yaml:"dir"`              // 存档目录
+	APIKeys         []string `yaml:"api_keys"`         // 这些 API Key 的会话总是录音，其余会话需在 start 命令中开启
+	MaxAgeDays      int      `yaml:"max_age_days"`     // 超过天数的录音自动删除，0 表示不限
+	MaxTotalMB      int      `yaml:"max_total_mb"`     // 总大小上限，超出时从最早的录音开始删除，0 表示不限
+	CleanupInterval int      `yaml:"cleanup_interval"` // 保留策略检查间隔（分钟），默认 60
+}
+
+// MetricsConfig Prometheus 指标接口，需配置 token 或独立监听地址之一
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Token   string `yaml:"token"`  // 抓取时需携带 Authorization: Bearer <token>，为空时读取环境变量 METRICS_TOKEN
+	Listen  string `yaml:"listen"` // 独立监听地址（如 127.0.0.1:9100），为空时挂载在主服务的 /metrics
+}
+
+// TracingConfig OpenTelemetry 链路追踪
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Exporter    string  `yaml:"exporter"`     // otlp（默认，OTLP/HTTP）或 stdout（本地调试）
+	Endpoint    string  `yaml:"endpoint"`     // OTLP 地址（host:port），为空时读取 OTEL_EXPORTER_OTLP_ENDPOINT，默认 localhost:4318
+	Insecure    bool    `yaml:"insecure"`     // OTLP 使用 HTTP 而非 HTTPS
+	SampleRatio float64 `yaml:"sample_ratio"` // 采样比例（0~1），默认 1；上游已决定采样时沿用上游
+	ServiceName string  `yaml:"service_name"` // 默认 airecorder
+}
+
+// HealthConfig 就绪探针（/readyz）
+type HealthConfig struct {
+	QueueThreshold  float64 `yaml:"queue_threshold"`   // 排队任务数达到队列容量的该比例时未就绪，默认 0.9
+	WAVOnly         bool    `yaml:"wav_only"`          // 上传音频只用 WAV 时设为 true，不要求安装 FFmpeg
+	StartupSelfTest bool    `yaml:"startup_self_test"` // 启动时用各类型的默认模型解码一小段静音，失败时未就绪；能力恢复或模型热加载后重新自检
+	LoadRetrySec    int     `yaml:"load_retry_sec"`    // 识别能力加载失败后的重试间隔（秒），默认 60，负数关闭自动重试
+}
+
 type ConcurrencyConfig struct {
 	MaxStreamingSessions int `yaml:"max_streaming_sessions"`
 	MaxOfflineJobs       int `yaml:"max_offline_jobs"`
@@ -119,13 +387,18 @@ type LoggingConfig struct {
 	MaxAge     int    `yaml:"max_age"`
 }
 
-// LoadConfig 从文件加载配置
+// LoadConfig 从环境变量 CONFIG_PATH 指定的文件（默认 config.yaml）加载配置
 func LoadConfig() (*Config, error) {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
 		configPath = "config.yaml"
 	}
+	return LoadConfigFile(configPath)
+}
 
+// LoadConfigFile 从文件加载配置，再用 AIRECORDER_ 开头的环境变量覆盖（见 EnvPrefix）。
+// 只检查格式，取值校验见 Validate
+func LoadConfigFile(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, err
@@ -135,14 +408,31 @@ func LoadConfig() (*Config, error) {
 	// 默认启用签名校验，并允许 5 分钟时间偏差。
 	config.Signature.Enabled = true
 	config.Signature.MaxSkewSeconds = 300
+	// 默认开启实时连接保活，及时释放半开连接占用的会话。
+	// 空闲超时默认关闭：保持连接但暂停发送音频（静音、等待）的客户端不应被断开
+	config.StreamingASR.PingIntervalSec = 20
+	config.StreamingASR.PongTimeoutSec = 60
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
 
+	// 命名模型继承主配置，需在解析 models 之前覆盖
+	if err := config.applyEnvOverrides(os.Environ()); err != nil {
+		return nil, err
+	}
+
+	if err := config.resolveModelEntries(); err != nil {
+		return nil, err
+	}
+
 	if config.Signature.Secret == "" {
 		config.Signature.Secret = os.Getenv("API_SIGNATURE_SECRET")
 	}
 
+	if config.Metrics.Token == "" {
+		config.Metrics.Token = os.Getenv("METRICS_TOKEN")
+	}
+
 	if config.Signature.MaxSkewSeconds <= 0 {
 		config.Signature.MaxSkewSeconds = 300
 	}
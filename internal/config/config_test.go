@@ -0,0 +1,374 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigModelEntriesInheritMainConfig(t *testing.T) {
+	path := writeTestConfig(t, `
+offline_asr:
+  enabled: true
+  model_type: "paraformer"
+  models_dir: "/models/offline/base"
+  tokens: "tokens.txt"
+  num_threads: 4
+  sample_rate: 16000
+  chunk_duration_sec: 30
+streaming_asr:
+  enabled: false
+  feature_dim: 80
+  enable_endpoint: true
+  rule1_min_trailing_silence: 2.4
+models:
+  - name: "whisper-en"
+    type: "offline"
+    default: true
+    model_type: "whisper"
+    models_dir: "/models/offline/whisper"
+    encoder: "encoder.onnx"
+    decoder: "decoder.onnx"
+    num_threads: 2
+  - name: "zipformer-fast"
+    type: "streaming"
+    models_dir: "/models/streaming/fast"
+`)
+	t.Setenv("CONFIG_PATH", path)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if len(cfg.Models) != 2 {
+		t.Fatalf("expected 2 model entries, got %d", len(cfg.Models))
+	}
+
+	offline := cfg.Models[0]
+	if offline.Name != "whisper-en" || offline.Type != ModelTypeOffline || !offline.Default {
+		t.Fatalf("unexpected offline entry header: %+v", offline)
+	}
+	if offline.Offline.ModelType != "whisper" || offline.Offline.NumThreads != 2 {
+		t.Fatalf("entry fields not applied: %+v", offline.Offline)
+	}
+	// 未填写的字段继承 offline_asr
+	if offline.Offline.Tokens != "tokens.txt" || offline.Offline.ChunkDurationSec != 30 {
+		t.Fatalf("entry did not inherit main config: %+v", offline.Offline)
+	}
+	// 主配置不应被命名模型修改
+	if cfg.OfflineASR.ModelType != "paraformer" || cfg.OfflineASR.NumThreads != 4 {
+		t.Fatalf("main offline config was modified: %+v", cfg.OfflineASR)
+	}
+
+	streaming := cfg.Models[1]
+	if !streaming.Streaming.Enabled || streaming.Streaming.FeatureDim != 80 || !streaming.Streaming.EnableEndpoint {
+		t.Fatalf("streaming entry did not inherit main config: %+v", streaming.Streaming)
+	}
+	if streaming.Streaming.ModelsDir != "/models/streaming/fast" {
+		t.Fatalf("streaming entry models_dir not applied: %+v", streaming.Streaming)
+	}
+}
+
+func TestLoadConfigRejectsUnknownModelType(t *testing.T) {
+	path := writeTestConfig(t, `
+models:
+  - name: "tts"
+    type: "speech-synthesis"
+`)
+	t.Setenv("CONFIG_PATH", path)
+
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected error for unknown model type")
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	path := writeTestConfig(t, `
+offline_asr:
+  enabled: true
+  num_threads: 4
+models:
+  - name: "whisper-en"
+    type: "offline"
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("AIRECORDER_OFFLINE_ASR__NUM_THREADS", "2")
+	t.Setenv("AIRECORDER_SPEAKER_DIARIZATION__STREAMING__THRESHOLD", "0.7")
+	t.Setenv("AIRECORDER_RECORDING__API_KEYS", "key-a, key-b")
+	t.Setenv("AIRECORDER_ADMIN__PASSWORD", "from-env")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.OfflineASR.NumThreads != 2 || cfg.SpeakerDiarization.Streaming.Threshold != 0.7 || cfg.Admin.Password != "from-env" {
+		t.Fatalf("overrides not applied: %+v", cfg)
+	}
+	if len(cfg.Recording.APIKeys) != 2 || cfg.Recording.APIKeys[1] != "key-b" {
+		t.Fatalf("unexpected api_keys %v", cfg.Recording.APIKeys)
+	}
+	// 命名模型继承覆盖后的主配置
+	if cfg.Models[0].Offline.NumThreads != 2 {
+		t.Fatalf("model entry did not inherit override: %+v", cfg.Models[0].Offline)
+	}
+
+	t.Setenv("AIRECORDER_OFFLINE_ASR__NUM_THREADS", "four")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "invalid integer") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+
+	// 未知字段只记录警告，不影响启动与热加载
+	t.Setenv("AIRECORDER_OFFLINE_ASR__NUM_THREADS", "2")
+	t.Setenv("AIRECORDER_OFFLINE_ASR__NUM_THREAD", "2")
+	t.Setenv("AIRECORDER_OFFLINE_ASR__NUM_THREADS__X", "2")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("unknown fields should not fail loading: %v", err)
+	}
+	if unknown := cfg.UnknownEnv(); len(unknown) != 2 || unknown[0] != "AIRECORDER_OFFLINE_ASR__NUM_THREAD" {
+		t.Fatalf("unexpected unknown env %v", unknown)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := &Config{
+		Server:       ServerConfig{Port: 8080},
+		OfflineASR:   OfflineASRConfig{Enabled: true, ModelType: OfflineModelSenseVoice, Model: "model.onnx", Tokens: "tokens.txt", NumThreads: 0, SampleRate: 44100},
+		StreamingASR: StreamingASRConfig{Enabled: true, Encoder: "e", Decoder: "d", Joiner: "j", Tokens: "t", NumThreads: 2, SampleRate: 16000, FeatureDim: 80},
+		Concurrency:  ConcurrencyConfig{MaxStreamingSessions: 10, WorkerPoolSize: -1},
+		Models: []ModelEntryConfig{
+			{Name: "a", Type: ModelTypeStreaming, Default: true, Streaming: StreamingASRConfig{Encoder: "e", Decoder: "d", Joiner: "j", Tokens: "t", NumThreads: 1, SampleRate: 16000, FeatureDim: 80}},
+			{Name: "a", Type: ModelTypeStreaming, Default: true, Streaming: StreamingASRConfig{Encoder: "e", Decoder: "d", Joiner: "j", Tokens: "t", NumThreads: 1, SampleRate: 16000, FeatureDim: 80}},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"offline_asr.num_threads: must be positive",
+		"offline_asr.sample_rate: unsupported sample rate 44100",
+		"concurrency.worker_pool_size",
+		"models[1] (a): duplicate streaming model name",
+		"2 streaming models marked as default",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "streaming_asr") {
+		t.Errorf("valid streaming_asr reported:\n%v", err)
+	}
+}
+
+func TestMarshalRedactedHidesSecrets(t *testing.T) {
+	cfg := &Config{
+		Admin:     AdminConfig{Password: "admin-pass"},
+		Signature: SignatureConfig{Secret: "sign-secret"},
+		Recording: RecordingConfig{APIKeys: []string{"key-a"}},
+		Models: []ModelEntryConfig{
+			{Name: "whisper-en", Type: ModelTypeOffline, Offline: OfflineASRConfig{ModelType: OfflineModelWhisper, NumThreads: 2}},
+		},
+	}
+
+	out, err := cfg.MarshalRedacted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"admin-pass", "sign-secret", "key-a"} {
+		if strings.Contains(string(out), secret) {
+			t.Fatalf("secret %q not redacted:\n%s", secret, out)
+		}
+	}
+	// 命名模型输出解析后的完整配置
+	if !strings.Contains(string(out), "model_type: whisper") {
+		t.Fatalf("model entry fields missing:\n%s", out)
+	}
+	if cfg.Admin.Password != "admin-pass" || cfg.Recording.APIKeys[0] != "key-a" {
+		t.Fatal("redaction modified the config")
+	}
+}
+
+func TestOfflineModelFilesPerType(t *testing.T) {
+	cases := []struct {
+		cfg    OfflineASRConfig
+		fields []string
+	}{
+		{OfflineASRConfig{ModelType: OfflineModelSenseVoice, Model: "model.int8.onnx", Tokens: "tokens.txt"}, []string{"model", "tokens"}},
+		{OfflineASRConfig{ModelType: OfflineModelTransducer, Encoder: "e.onnx", Decoder: "d.onnx", Joiner: "j.onnx", Tokens: "tokens.txt"}, []string{"encoder", "decoder", "joiner", "tokens"}},
+		{OfflineASRConfig{ModelType: OfflineModelMoonshine, Preprocessor: "p.onnx", Encoder: "e.onnx", UncachedDecoder: "u.onnx", CachedDecoder: "c.onnx", Tokens: "tokens.txt"}, []string{"preprocessor", "encoder", "uncached_decoder", "cached_decoder", "tokens"}},
+		{OfflineASRConfig{ModelType: OfflineModelFireRedASR, Encoder: "e.onnx", Decoder: "d.onnx", Tokens: "tokens.txt"}, []string{"encoder", "decoder", "tokens"}},
+	}
+	for _, tc := range cases {
+		files, err := tc.cfg.ModelFiles()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.cfg.ModelType, err)
+		}
+		if len(files) != len(tc.fields) {
+			t.Fatalf("%s: expected %d files, got %+v", tc.cfg.ModelType, len(tc.fields), files)
+		}
+		for i, f := range files {
+			if f.Field != tc.fields[i] {
+				t.Fatalf("%s: file %d expected %s, got %s", tc.cfg.ModelType, i, tc.fields[i], f.Field)
+			}
+		}
+	}
+
+	// 旧配置：paraformer 使用 encoder 字段，encoder.onnx 视为未指定
+	files, err := OfflineASRConfig{ModelType: OfflineModelParaformer, ModelsDir: "/m", Encoder: "encoder.onnx", Tokens: "tokens.txt"}.ModelFiles()
+	if err != nil || files[0].Path != filepath.Join("/m", "model.int8.onnx") {
+		t.Fatalf("unexpected paraformer fallback: %+v, %v", files, err)
+	}
+
+	if _, err := (OfflineASRConfig{ModelType: OfflineModelTransducer, Encoder: "e.onnx", Tokens: "tokens.txt"}).ModelFiles(); err == nil || !strings.Contains(err.Error(), "decoder, joiner") {
+		t.Fatalf("expected error naming empty fields, got %v", err)
+	}
+	if _, err := (OfflineASRConfig{ModelType: "kaldi"}).ModelFiles(); err == nil {
+		t.Fatal("expected error for unsupported model_type")
+	}
+}
+
+func TestOfflineValidateReportsMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tokens.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := OfflineASRConfig{ModelType: OfflineModelNemoCTC, ModelsDir: dir, Model: "model.onnx", Tokens: "tokens.txt"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "model ("+filepath.Join(dir, "model.onnx")+")") {
+		t.Fatalf("expected missing model file in error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "tokens") {
+		t.Fatalf("existing tokens file reported as missing: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "model.onnx"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+}
+
+func TestStreamingModelFilesPerType(t *testing.T) {
+	base := StreamingASRConfig{
+		ModelsDir:     "/m",
+		Encoder:       "encoder.onnx",
+		Decoder:       "decoder.onnx",
+		Joiner:        "joiner.onnx",
+		Tokens:        "tokens.txt",
+		Paraformer:    StreamingParaformerConfig{Encoder: "pf-encoder.onnx", Decoder: "pf-decoder.onnx"},
+		Zipformer2CTC: StreamingCTCConfig{Model: "ctc.onnx"},
+	}
+
+	cases := map[string][]string{
+		StreamingModelZipformer:     {"/m/encoder.onnx", "/m/decoder.onnx", "/m/joiner.onnx", "/m/tokens.txt"},
+		StreamingModelParaformer:    {"/m/pf-encoder.onnx", "/m/pf-decoder.onnx", "/m/tokens.txt"},
+		StreamingModelZipformer2CTC: {"/m/ctc.onnx", "/m/tokens.txt"},
+	}
+	for modelType, want := range cases {
+		cfg := base
+		cfg.ModelType = modelType
+		files, err := cfg.ModelFiles()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", modelType, err)
+		}
+		if len(files) != len(want) {
+			t.Fatalf("%s: expected %v, got %+v", modelType, want, files)
+		}
+		for i, f := range files {
+			if f.Path != filepath.FromSlash(want[i]) {
+				t.Fatalf("%s: file %d expected %s, got %s", modelType, i, want[i], f.Path)
+			}
+		}
+	}
+
+	cfg := base
+	cfg.ModelType = StreamingModelNemoCTC
+	if _, err := cfg.ModelFiles(); err == nil || !strings.Contains(err.Error(), "nemo_ctc.model") {
+		t.Fatalf("expected error naming nemo_ctc.model, got %v", err)
+	}
+}
+
+func TestRuntimeSettingsMergeDiffApply(t *testing.T) {
+	cfg := &Config{
+		Concurrency:  ConcurrencyConfig{MaxStreamingSessions: 10, WorkerPoolSize: 4},
+		StreamingASR: StreamingASRConfig{EnableEndpoint: true, Rule2MinTrailingSilence: 1.2},
+		Signature:    SignatureConfig{MaxSkewSeconds: 300},
+		Models: []ModelEntryConfig{
+			{Name: "zh", Type: ModelTypeStreaming},
+			{Name: "whisper", Type: ModelTypeOffline},
+		},
+	}
+	current := cfg.RuntimeSettings()
+	patch := RuntimeSettings{WorkerPoolSize: ptr(8), Rule2MinTrailingSilence: ptr(float32(0.8)), MaxSkewSeconds: ptr(int64(300))}
+	if err := patch.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !patch.EndpointChanged() {
+		t.Fatal("expected endpoint change")
+	}
+
+	next := current.Merge(patch)
+	if *next.MaxStreamingSessions != 10 || *next.WorkerPoolSize != 8 {
+		t.Fatalf("unexpected merge result: %+v", next)
+	}
+	changes := current.Diff(next)
+	if len(changes) != 2 || changes[0].Field != "worker_pool_size" || changes[0].From != 4 || changes[0].To != 8 ||
+		changes[1].Field != "rule2_min_trailing_silence" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	cfg.ApplyRuntime(next)
+	if cfg.Concurrency.WorkerPoolSize != 8 || cfg.StreamingASR.Rule2MinTrailingSilence != 0.8 ||
+		cfg.Models[0].Streaming.Rule2MinTrailingSilence != 0.8 || !cfg.Models[0].Streaming.EnableEndpoint {
+		t.Fatalf("runtime settings not applied: %+v", cfg)
+	}
+}
+
+func TestRuntimeSettingsValidate(t *testing.T) {
+	err := RuntimeSettings{QueueSize: ptr(0), Rule1MinTrailingSilence: ptr(float32(-1))}.Validate()
+	if !errors.Is(err, ErrInvalidRuntimeSetting) {
+		t.Fatalf("expected ErrInvalidRuntimeSetting, got %v", err)
+	}
+	for _, field := range []string{"queue_size", "rule1_min_trailing_silence"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("error should mention %s: %v", field, err)
+		}
+	}
+}
+
+func TestStreamingKeepaliveDefaultsAndValidation(t *testing.T) {
+	cfg, err := LoadConfigFile(writeTestConfig(t, "server:\n  port: 8080\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc := cfg.StreamingASR; sc.PingIntervalSec != 20 || sc.PongTimeoutSec != 60 || sc.IdleTimeoutSec != 0 {
+		t.Fatalf("unexpected keepalive defaults: ping=%d pong=%d idle=%d", sc.PingIntervalSec, sc.PongTimeoutSec, sc.IdleTimeoutSec)
+	}
+
+	cfg.StreamingASR = StreamingASRConfig{Enabled: true, Encoder: "e", Decoder: "d", Joiner: "j", Tokens: "t", NumThreads: 1, SampleRate: 16000, FeatureDim: 80,
+		PingIntervalSec: 30, PongTimeoutSec: 30}
+	cfg.Concurrency.MaxStreamingSessions = 1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "streaming_asr.pong_timeout_sec: must be greater than ping_interval_sec") {
+		t.Fatalf("expected pong_timeout_sec error, got %v", err)
+	}
+	cfg.StreamingASR.PongTimeoutSec = 0 // 不检查 pong 超时
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
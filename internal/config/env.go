@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量覆盖配置的前缀。变量名为前缀加上以 __ 分隔的 yaml 字段路径（大写），
+// 例如 AIRECORDER_OFFLINE_ASR__NUM_THREADS 覆盖 offline_asr.num_threads
+const EnvPrefix = "AIRECORDER_"
+
+// errUnknownField 环境变量路径不对应任何配置字段
+var errUnknownField = errors.New("unknown config field")
+
+// applyEnvOverrides 用 environ 中以 EnvPrefix 开头的变量覆盖配置，字符串列表以逗号分隔。
+// 取值无法解析时返回错误；字段不存在时只记录警告（见 UnknownEnv）
+func (c *Config) applyEnvOverrides(environ []string) error {
+	sort.Strings(environ)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		path := strings.Split(strings.TrimPrefix(name, EnvPrefix), "__")
+		field := strings.ToLower(strings.Join(path, "."))
+		err := setField(reflect.ValueOf(c).Elem(), path, value)
+		if errors.Is(err, errUnknownField) {
+			slog.Warn("Ignoring environment override without a matching config field", "env", name, "field", field)
+			c.unknownEnv = append(c.unknownEnv, name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("environment %s (%s): %w", name, field, err)
+		}
+	}
+	return nil
+}
+
+// setField 按 yaml 字段路径（不区分大小写）找到字段并赋值
+func setField(v reflect.Value, path []string, value string) error {
+	if len(path) == 0 {
+		return setValue(v, value)
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		// 路径超出了叶子字段
+		return errUnknownField
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" || !strings.EqualFold(tag, path[0]) {
+			continue
+		}
+		return setField(v.Field(i), path[1:], value)
+	}
+	return errUnknownField
+}
+
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("lists of %s cannot be set from the environment", v.Type().Elem())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s cannot be set from the environment", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 离线模型类型（offline_asr.model_type）
+const (
+	OfflineModelParaformer   = "paraformer"
+	OfflineModelWhisper      = "whisper"
+	OfflineModelTransducer   = "transducer"
+	OfflineModelSenseVoice   = "sense_voice"
+	OfflineModelNemoCTC      = "nemo_ctc"
+	OfflineModelZipformerCTC = "zipformer_ctc"
+	OfflineModelTDNN         = "tdnn"
+	OfflineModelFireRedASR   = "fire_red_asr"
+	OfflineModelMoonshine    = "moonshine"
+)
+
+// OfflineModelTypes 支持的离线模型类型
+var OfflineModelTypes = []string{
+	OfflineModelParaformer,
+	OfflineModelWhisper,
+	OfflineModelTransducer,
+	OfflineModelSenseVoice,
+	OfflineModelNemoCTC,
+	OfflineModelZipformerCTC,
+	OfflineModelTDNN,
+	OfflineModelFireRedASR,
+	OfflineModelMoonshine,
+}
+
+// 实时模型类型（streaming_asr.model_type）。
+// zipformer、zipformer2、conformer、lstm 与 transducer 同属 transducer 结构，使用 encoder/decoder/joiner
+const (
+	StreamingModelTransducer    = "transducer"
+	StreamingModelZipformer     = "zipformer"
+	StreamingModelZipformer2    = "zipformer2"
+	StreamingModelConformer     = "conformer"
+	StreamingModelLSTM          = "lstm"
+	StreamingModelParaformer    = "paraformer"
+	StreamingModelZipformer2CTC = "zipformer2_ctc"
+	StreamingModelNemoCTC       = "nemo_ctc"
+)
+
+// StreamingModelTypes 支持的实时模型类型
+var StreamingModelTypes = []string{
+	StreamingModelTransducer,
+	StreamingModelZipformer,
+	StreamingModelZipformer2,
+	StreamingModelConformer,
+	StreamingModelLSTM,
+	StreamingModelParaformer,
+	StreamingModelZipformer2CTC,
+	StreamingModelNemoCTC,
+}
+
+// ModelFile 模型所需的一个文件
+type ModelFile struct {
+	Field string // 对应的配置字段，例如 encoder、tokens
+	Path  string // models_dir 拼接后的完整路径
+}
+
+// ModelFiles 按 model_type 返回所需的模型文件（含 tokens），字段未填写或类型不支持时返回错误
+func (c OfflineASRConfig) ModelFiles() ([]ModelFile, error) {
+	var fields []string
+	values := map[string]string{
+		"model":            c.Model,
+		"encoder":          c.Encoder,
+		"decoder":          c.Decoder,
+		"joiner":           c.Joiner,
+		"preprocessor":     c.Preprocessor,
+		"uncached_decoder": c.UncachedDecoder,
+		"cached_decoder":   c.CachedDecoder,
+		"tokens":           c.Tokens,
+	}
+
+	switch c.ModelType {
+	case OfflineModelParaformer:
+		// 兼容旧配置：paraformer 曾使用 encoder 字段指定模型文件，encoder.onnx 视为未指定
+		if c.Model == "" {
+			values["model"] = c.Encoder
+			if c.Encoder == "" || c.Encoder == "encoder.onnx" {
+				values["model"] = "model.int8.onnx"
+			}
+		}
+		fields = []string{"model"}
+	case OfflineModelSenseVoice, OfflineModelNemoCTC, OfflineModelZipformerCTC, OfflineModelTDNN:
+		fields = []string{"model"}
+	case OfflineModelWhisper, OfflineModelFireRedASR:
+		fields = []string{"encoder", "decoder"}
+	case OfflineModelTransducer:
+		fields = []string{"encoder", "decoder", "joiner"}
+	case OfflineModelMoonshine:
+		fields = []string{"preprocessor", "encoder", "uncached_decoder", "cached_decoder"}
+	default:
+		return nil, fmt.Errorf("unsupported model_type %q, expected one of: %s", c.ModelType, strings.Join(OfflineModelTypes, ", "))
+	}
+	return collectModelFiles(c.ModelType, c.ModelsDir, fields, values)
+}
+
+// ModelFiles 按 model_type 返回所需的模型文件（含 tokens），字段未填写或类型不支持时返回错误。
+// 空的 model_type 按 transducer 处理
+func (c StreamingASRConfig) ModelFiles() ([]ModelFile, error) {
+	var fields []string
+	values := map[string]string{
+		"encoder":              c.Encoder,
+		"decoder":              c.Decoder,
+		"joiner":               c.Joiner,
+		"paraformer.encoder":   c.Paraformer.Encoder,
+		"paraformer.decoder":   c.Paraformer.Decoder,
+		"zipformer2_ctc.model": c.Zipformer2CTC.Model,
+		"nemo_ctc.model":       c.NemoCTC.Model,
+		"tokens":               c.Tokens,
+	}
+
+	switch c.ModelType {
+	case "", StreamingModelTransducer, StreamingModelZipformer, StreamingModelZipformer2, StreamingModelConformer, StreamingModelLSTM:
+		fields = []string{"encoder", "decoder", "joiner"}
+	case StreamingModelParaformer:
+		fields = []string{"paraformer.encoder", "paraformer.decoder"}
+	case StreamingModelZipformer2CTC:
+		fields = []string{"zipformer2_ctc.model"}
+	case StreamingModelNemoCTC:
+		fields = []string{"nemo_ctc.model"}
+	default:
+		return nil, fmt.Errorf("unsupported model_type %q, expected one of: %s", c.ModelType, strings.Join(StreamingModelTypes, ", "))
+	}
+	return collectModelFiles(c.ModelType, c.ModelsDir, fields, values)
+}
+
+// Validate 检查所选模型类型需要的文件是否齐全，缺失时在错误中列出全部缺失文件
+func (c StreamingASRConfig) Validate() error {
+	files, err := c.ModelFiles()
+	if err != nil {
+		return err
+	}
+	return CheckModelFiles(c.ModelType, files)
+}
+
+// collectModelFiles 按字段顺序拼接模型文件路径（末尾追加 tokens），字段未填写时返回错误
+func collectModelFiles(modelType, modelsDir string, fields []string, values map[string]string) ([]ModelFile, error) {
+	fields = append(fields, "tokens")
+
+	files := make([]ModelFile, 0, len(fields))
+	var empty []string
+	for _, field := range fields {
+		if values[field] == "" {
+			empty = append(empty, field)
+			continue
+		}
+		files = append(files, ModelFile{Field: field, Path: filepath.Join(modelsDir, values[field])})
+	}
+	if len(empty) > 0 {
+		return nil, fmt.Errorf("model_type %s requires %s", modelType, strings.Join(empty, ", "))
+	}
+	return files, nil
+}
+
+// Validate 检查所选模型类型需要的文件是否齐全，缺失时在错误中列出全部缺失文件
+func (c OfflineASRConfig) Validate() error {
+	files, err := c.ModelFiles()
+	if err != nil {
+		return err
+	}
+	return CheckModelFiles(c.ModelType, files)
+}
+
+// CheckModelFiles 检查模型文件是否存在
+func CheckModelFiles(modelType string, files []ModelFile) error {
+	var missing []string
+	for _, f := range files {
+		if _, err := os.Stat(f.Path); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%s)", f.Field, f.Path))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("model_type %s: missing files: %s", modelType, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// SupportsHotwords 是否支持热词（sherpa-onnx 仅 transducer 模型支持上下文偏置）
+func (c OfflineASRConfig) SupportsHotwords() bool {
+	return c.ModelType == OfflineModelTransducer
+}
+
+// SupportsHotwords 是否支持热词（sherpa-onnx 仅 transducer 模型支持上下文偏置）
+func (c StreamingASRConfig) SupportsHotwords() bool {
+	switch c.ModelType {
+	case "", StreamingModelTransducer, StreamingModelZipformer, StreamingModelZipformer2, StreamingModelConformer, StreamingModelLSTM:
+		return true
+	}
+	return false
+}
+
+// ResolveModelPath 拼接 models_dir 下的可选文件路径，绝对路径与空值原样返回
+func ResolveModelPath(modelsDir, file string) string {
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(modelsDir, file)
+}
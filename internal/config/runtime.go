@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidRuntimeSetting 运行时配置取值非法
+var ErrInvalidRuntimeSetting = errors.New("invalid runtime setting")
+
+// RuntimeSettings 运行中可通过管理接口（GET/PATCH /admin/api/config）修改的配置子集。
+// 修改立即生效但不写回配置文件，重启后恢复；PATCH 时为 nil 的字段保持不变
+type RuntimeSettings struct {
+	MaxStreamingSessions    *int     `json:"max_streaming_sessions,omitempty"`     // concurrency.max_streaming_sessions
+	QueueSize               *int     `json:"queue_size,omitempty"`                 // concurrency.queue_size
+	WorkerPoolSize          *int     `json:"worker_pool_size,omitempty"`           // concurrency.worker_pool_size
+	ChunkDurationSec        *int     `json:"chunk_duration_sec,omitempty"`         // offline_asr.chunk_duration_sec
+	MaxConcurrency          *int     `json:"max_concurrency,omitempty"`            // offline_asr.max_concurrency
+	EnableEndpoint          *bool    `json:"enable_endpoint,omitempty"`            // streaming_asr.enable_endpoint
+	Rule1MinTrailingSilence *float32 `json:"rule1_min_trailing_silence,omitempty"` // streaming_asr.rule1_min_trailing_silence
+	Rule2MinTrailingSilence *float32 `json:"rule2_min_trailing_silence,omitempty"` // streaming_asr.rule2_min_trailing_silence
+	Rule3MinUtteranceLength *float32 `json:"rule3_min_utterance_length,omitempty"` // streaming_asr.rule3_min_utterance_length
+	MaxSkewSeconds          *int64   `json:"max_skew_seconds,omitempty"`           // signature.max_skew_seconds
+}
+
+// RuntimeChange 一个运行时配置字段的变更
+type RuntimeChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RuntimeSettings 配置中可运行时修改的字段的当前取值
+func (c *Config) RuntimeSettings() RuntimeSettings {
+	sc := c.StreamingASR
+	return RuntimeSettings{
+		MaxStreamingSessions:    ptr(c.Concurrency.MaxStreamingSessions),
+		QueueSize:               ptr(c.Concurrency.QueueSize),
+		WorkerPoolSize:          ptr(c.Concurrency.WorkerPoolSize),
+		ChunkDurationSec:        ptr(c.OfflineASR.ChunkDurationSec),
+		MaxConcurrency:          ptr(c.OfflineASR.MaxConcurrency),
+		EnableEndpoint:          ptr(sc.EnableEndpoint),
+		Rule1MinTrailingSilence: ptr(sc.Rule1MinTrailingSilence),
+		Rule2MinTrailingSilence: ptr(sc.Rule2MinTrailingSilence),
+		Rule3MinUtteranceLength: ptr(sc.Rule3MinUtteranceLength),
+		MaxSkewSeconds:          ptr(c.Signature.MaxSkewSeconds),
+	}
+}
+
+// ApplyRuntime 将运行时修改写入配置（用于热加载时重新读取的配置），端点规则同时应用到 models 中的实时模型
+func (c *Config) ApplyRuntime(r RuntimeSettings) {
+	set(&c.Concurrency.MaxStreamingSessions, r.MaxStreamingSessions)
+	set(&c.Concurrency.QueueSize, r.QueueSize)
+	set(&c.Concurrency.WorkerPoolSize, r.WorkerPoolSize)
+	set(&c.OfflineASR.ChunkDurationSec, r.ChunkDurationSec)
+	set(&c.OfflineASR.MaxConcurrency, r.MaxConcurrency)
+	set(&c.Signature.MaxSkewSeconds, r.MaxSkewSeconds)
+	r.applyEndpoint(&c.StreamingASR)
+	for i := range c.Models {
+		if c.Models[i].Type == ModelTypeStreaming {
+			r.applyEndpoint(&c.Models[i].Streaming)
+		}
+	}
+}
+
+func (r RuntimeSettings) applyEndpoint(sc *StreamingASRConfig) {
+	set(&sc.EnableEndpoint, r.EnableEndpoint)
+	set(&sc.Rule1MinTrailingSilence, r.Rule1MinTrailingSilence)
+	set(&sc.Rule2MinTrailingSilence, r.Rule2MinTrailingSilence)
+	set(&sc.Rule3MinUtteranceLength, r.Rule3MinUtteranceLength)
+}
+
+// EndpointChanged 是否修改了端点检测规则（需重新加载实时模型）
+func (r RuntimeSettings) EndpointChanged() bool {
+	return r.EnableEndpoint != nil || r.Rule1MinTrailingSilence != nil ||
+		r.Rule2MinTrailingSilence != nil || r.Rule3MinUtteranceLength != nil
+}
+
+// Validate 检查 PATCH 中给出的字段，错误包装 ErrInvalidRuntimeSetting
+func (r RuntimeSettings) Validate() error {
+	var p problems
+	positiveIf := func(field string, v *int) {
+		if v != nil && *v <= 0 {
+			p.add(field, "must be positive, got %d", *v)
+		}
+	}
+	positiveIf("max_streaming_sessions", r.MaxStreamingSessions)
+	positiveIf("queue_size", r.QueueSize)
+	positiveIf("worker_pool_size", r.WorkerPoolSize)
+	positiveIf("chunk_duration_sec", r.ChunkDurationSec)
+	positiveIf("max_concurrency", r.MaxConcurrency)
+	positiveSec := func(field string, v *float32) {
+		if v != nil && *v <= 0 {
+			p.add(field, "must be positive, got %g", *v)
+		}
+	}
+	positiveSec("rule1_min_trailing_silence", r.Rule1MinTrailingSilence)
+	positiveSec("rule2_min_trailing_silence", r.Rule2MinTrailingSilence)
+	positiveSec("rule3_min_utterance_length", r.Rule3MinUtteranceLength)
+	if r.MaxSkewSeconds != nil && *r.MaxSkewSeconds <= 0 {
+		p.add("max_skew_seconds", "must be positive, got %d", *r.MaxSkewSeconds)
+	}
+	if err := p.err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRuntimeSetting, err)
+	}
+	return nil
+}
+
+// Merge 返回以 patch 中非 nil 字段覆盖后的设置
+func (r RuntimeSettings) Merge(patch RuntimeSettings) RuntimeSettings {
+	merged := r
+	mv, pv := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(patch)
+	for i := 0; i < pv.NumField(); i++ {
+		if !pv.Field(i).IsNil() {
+			mv.Field(i).Set(pv.Field(i))
+		}
+	}
+	return merged
+}
+
+// Diff 返回 next 中与 r 取值不同的字段，字段名为 json 名称
+func (r RuntimeSettings) Diff(next RuntimeSettings) []RuntimeChange {
+	var changes []RuntimeChange
+	rv, nv := reflect.ValueOf(r), reflect.ValueOf(next)
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		from, to := deref(rv.Field(i)), deref(nv.Field(i))
+		if to == nil || reflect.DeepEqual(from, to) {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		changes = append(changes, RuntimeChange{Field: name, From: from, To: to})
+	}
+	return changes
+}
+
+func deref(v reflect.Value) interface{} {
+	if v.IsNil() {
+		return nil
+	}
+	return v.Elem().Interface()
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func set[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
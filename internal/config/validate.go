@@ -0,0 +1,286 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SupportedSampleRates 识别与 VAD 模型支持的采样率
+var SupportedSampleRates = []int{8000, 16000}
+
+// secretFields 输出配置时需要隐藏的字段（yaml 路径）
+var secretFields = [][]string{
+	{"admin", "password"},
+	{"signature", "secret"},
+	{"metrics", "token"},
+	{"recording", "api_keys"},
+}
+
+// redactedValue 隐藏后的取值
+const redactedValue = "******"
+
+// problems 收集全部校验问题，一次性返回
+type problems []error
+
+func (p *problems) add(field, format string, args ...interface{}) {
+	*p = append(*p, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (p *problems) check(field string, err error) {
+	if err != nil {
+		*p = append(*p, fmt.Errorf("%s: %w", field, err))
+	}
+}
+
+func (p problems) err() error {
+	return errors.Join(p...)
+}
+
+// Validate 检查配置取值（线程数、采样率、并发数、模型类型等），返回全部问题，每行一个。
+// 不检查模型文件是否存在，见 CheckFiles
+func (c *Config) Validate() error {
+	var p problems
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		p.add("server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.ShutdownTimeout < 0 {
+		p.add("server", "timeouts must not be negative")
+	}
+
+	if c.StreamingASR.Enabled {
+		validateStreaming(&p, "streaming_asr", c.StreamingASR)
+	}
+	if c.OfflineASR.Enabled {
+		validateOffline(&p, "offline_asr", c.OfflineASR)
+	}
+	validateModelEntries(&p, c.Models)
+
+	if sd := c.SpeakerDiarization; sd.Enabled {
+		positive(&p, "speaker_diarization.num_threads", sd.NumThreads)
+		if sd.SegmentationModel == "" || sd.EmbeddingModel == "" {
+			p.add("speaker_diarization", "segmentation_model and embedding_model are required")
+		}
+		if sd.Streaming.Enabled && (sd.Streaming.Threshold < 0 || sd.Streaming.Threshold > 1) {
+			p.add("speaker_diarization.streaming.threshold", "must be between 0 and 1, got %g", sd.Streaming.Threshold)
+		}
+	}
+	if c.VAD.Enabled {
+		positive(&p, "vad.num_threads", c.VAD.NumThreads)
+		sampleRate(&p, "vad.sample_rate", c.VAD.SampleRate)
+	}
+	if c.Punctuation.Enabled && c.Punctuation.Model == "" {
+		p.add("punctuation.model", "is required when punctuation is enabled")
+	}
+	if c.LanguageID.Enabled && (c.LanguageID.Encoder == "" || c.LanguageID.Decoder == "") {
+		p.add("language_id", "encoder and decoder are required")
+	}
+
+	positive(&p, "concurrency.max_streaming_sessions", c.Concurrency.MaxStreamingSessions)
+	// 0 表示使用默认值
+	if c.Concurrency.WorkerPoolSize < 0 {
+		p.add("concurrency.worker_pool_size", "must not be negative, got %d", c.Concurrency.WorkerPoolSize)
+	}
+	if c.Concurrency.QueueSize < 0 {
+		p.add("concurrency.queue_size", "must not be negative, got %d", c.Concurrency.QueueSize)
+	}
+	if c.Concurrency.MaxOfflineJobs < 0 {
+		p.add("concurrency.max_offline_jobs", "must not be negative, got %d", c.Concurrency.MaxOfflineJobs)
+	}
+
+	switch c.Logging.Level {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		p.add("logging.level", "unknown level %q, expected debug, info, warn or error", c.Logging.Level)
+	}
+	if c.Health.QueueThreshold < 0 || c.Health.QueueThreshold > 1 {
+		p.add("health.queue_threshold", "must be between 0 and 1, got %g", c.Health.QueueThreshold)
+	}
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case "", "otlp", "stdout":
+		default:
+			p.add("tracing.exporter", "unknown exporter %q, expected otlp or stdout", c.Tracing.Exporter)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			p.add("tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+		}
+	}
+
+	return p.err()
+}
+
+func validateStreaming(p *problems, field string, sc StreamingASRConfig) {
+	_, err := sc.ModelFiles()
+	p.check(field, err)
+	positive(p, field+".num_threads", sc.NumThreads)
+	sampleRate(p, field+".sample_rate", sc.SampleRate)
+	positive(p, field+".feature_dim", sc.FeatureDim)
+	// pong 超时不大于 ping 间隔时，正常的客户端在下一次 ping 之前就会被断开
+	if sc.PingIntervalSec > 0 && sc.PongTimeoutSec > 0 && sc.PongTimeoutSec <= sc.PingIntervalSec {
+		p.add(field+".pong_timeout_sec", "must be greater than ping_interval_sec (%d), got %d", sc.PingIntervalSec, sc.PongTimeoutSec)
+	}
+}
+
+func validateOffline(p *problems, field string, oc OfflineASRConfig) {
+	_, err := oc.ModelFiles()
+	p.check(field, err)
+	positive(p, field+".num_threads", oc.NumThreads)
+	sampleRate(p, field+".sample_rate", oc.SampleRate)
+	if oc.MaxFileSizeMB < 0 || oc.ChunkDurationSec < 0 || oc.MaxConcurrency < 0 || oc.MaxProcessingTimeoutMin < 0 {
+		p.add(field, "max_file_size_mb, chunk_duration_sec, max_concurrency and max_processing_timeout_min must not be negative")
+	}
+}
+
+// validateModelEntries 检查 models 列表：名称必填且同类型内唯一，每种类型最多一个默认模型
+func validateModelEntries(p *problems, entries []ModelEntryConfig) {
+	names := map[string]bool{}
+	defaults := map[string]int{}
+	for i, entry := range entries {
+		field := fmt.Sprintf("models[%d]", i)
+		if entry.Name == "" {
+			p.add(field+".name", "is required")
+		} else {
+			field = fmt.Sprintf("models[%d] (%s)", i, entry.Name)
+			if names[entry.Type+"/"+entry.Name] {
+				p.add(field, "duplicate %s model name", entry.Type)
+			}
+			names[entry.Type+"/"+entry.Name] = true
+		}
+		if entry.Default {
+			defaults[entry.Type]++
+		}
+		switch entry.Type {
+		case ModelTypeOffline:
+			validateOffline(p, field, entry.Offline)
+		case ModelTypeStreaming:
+			validateStreaming(p, field, entry.Streaming)
+		}
+	}
+	for modelType, n := range defaults {
+		if n > 1 {
+			p.add("models", "%d %s models marked as default, expected at most one", n, modelType)
+		}
+	}
+}
+
+func positive(p *problems, field string, v int) {
+	if v <= 0 {
+		p.add(field, "must be positive, got %d", v)
+	}
+}
+
+func sampleRate(p *problems, field string, rate int) {
+	for _, r := range SupportedSampleRates {
+		if rate == r {
+			return
+		}
+	}
+	p.add(field, "unsupported sample rate %d, expected one of %v", rate, SupportedSampleRates)
+}
+
+// CheckFiles 检查已启用功能所需的模型文件是否存在，返回全部缺失的文件。
+// 模型文件缺失时服务仍可启动，对应能力以降级模式运行
+func (c *Config) CheckFiles() error {
+	var p problems
+
+	if c.StreamingASR.Enabled {
+		p.check("streaming_asr", c.StreamingASR.Validate())
+	}
+	if c.OfflineASR.Enabled {
+		p.check("offline_asr", c.OfflineASR.Validate())
+	}
+	for i, entry := range c.Models {
+		field := fmt.Sprintf("models[%d] (%s)", i, entry.Name)
+		switch entry.Type {
+		case ModelTypeOffline:
+			p.check(field, entry.Offline.Validate())
+		case ModelTypeStreaming:
+			p.check(field, entry.Streaming.Validate())
+		}
+	}
+	if sd := c.SpeakerDiarization; sd.Enabled {
+		p.check("speaker_diarization", CheckModelFiles("pyannote", []ModelFile{
+			{Field: "segmentation_model", Path: filepath.Join(sd.ModelsDir, sd.SegmentationModel)},
+			{Field: "embedding_model", Path: filepath.Join(sd.ModelsDir, sd.EmbeddingModel)},
+		}))
+	}
+	if c.Punctuation.Enabled {
+		p.check("punctuation", CheckModelFiles("ct_transformer", []ModelFile{
+			{Field: "model", Path: filepath.Join(c.Punctuation.ModelDir, c.Punctuation.Model)},
+		}))
+	}
+	if lid := c.LanguageID; lid.Enabled {
+		p.check("language_id", CheckModelFiles("whisper", []ModelFile{
+			{Field: "encoder", Path: filepath.Join(lid.ModelsDir, lid.Encoder)},
+			{Field: "decoder", Path: filepath.Join(lid.ModelsDir, lid.Decoder)},
+		}))
+	}
+
+	return p.err()
+}
+
+// MarshalRedacted 输出生效的配置（YAML），密码、密钥、token 等字段被隐藏
+func (c *Config) MarshalRedacted() ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return nil, err
+	}
+	root := &doc
+	if root.Kind == yaml.DocumentNode {
+		root = root.Content[0]
+	}
+	for _, path := range secretFields {
+		if v := lookupNode(root, path); v != nil {
+			redactNode(v)
+		}
+	}
+	return yaml.Marshal(&doc)
+}
+
+// lookupNode 按键路径查找映射节点中的值
+func lookupNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// redactNode 隐藏非空的标量与列表元素，空值保持为空以便看出未配置
+func redactNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != "" {
+			node.Value, node.Tag, node.Style = redactedValue, "!!str", 0
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			redactNode(item)
+		}
+	}
+}
+
+// FormatProblems 将 Validate/CheckFiles 返回的错误格式化为每行一个问题
+func FormatProblems(err error) string {
+	if err == nil {
+		return ""
+	}
+	return "  - " + strings.ReplaceAll(err.Error(), "\n", "\n  - ")
+}
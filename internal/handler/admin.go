@@ -4,13 +4,17 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"airecorder/internal/asr"
 	"airecorder/internal/config"
+	"airecorder/internal/recording"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 const adminTokenMessage = "airecorder-admin-token"
@@ -130,6 +134,80 @@ func HandleAdminListSessions(streamingASR *asr.StreamingASRManager) gin.HandlerF
 	}
 }
 
+// HandleAdminGetSession 返回指定流式 ASR 会话的详细信息
+func HandleAdminGetSession(streamingASR *asr.StreamingASRManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if streamingASR == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "streaming ASR not available"})
+			return
+		}
+		session, ok := streamingASR.GetSession(c.Param("sessionId"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// watchMessage 推送给管理员的旁观事件，dropped 为累计丢弃的事件数
+type watchMessage struct {
+	asr.SessionEvent
+	Dropped int64 `json:"dropped,omitempty"`
+}
+
+// HandleAdminWatchSession 通过 WebSocket 旁观指定会话的中间结果与整句结果（?level=true 时附带音频电平）。
+// 管理员连接处理过慢时丢弃事件，不影响会话本身
+func HandleAdminWatchSession(streamingASR *asr.StreamingASRManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if streamingASR == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "streaming ASR not available"})
+			return
+		}
+		watcher, err := streamingASR.WatchSession(c.Param("sessionId"), c.Query("level") == "true")
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		defer streamingASR.Unwatch(watcher)
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			requestLogger(c).Warn("WebSocket upgrade error", "error", err)
+			return
+		}
+		defer conn.Close()
+
+		// 读取管理员连接以处理关闭帧，连接断开后结束旁观
+		gone := make(chan struct{})
+		go func() {
+			defer close(gone)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-gone:
+				return
+			case ev, ok := <-watcher.Events():
+				if !ok {
+					msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session closed")
+					conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+				if err := conn.WriteJSON(watchMessage{SessionEvent: ev, Dropped: watcher.Dropped()}); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
 // HandleAdminCloseSession 强制关闭指定流式 ASR 会话
 func HandleAdminCloseSession(streamingASR *asr.StreamingASRManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -150,6 +228,64 @@ func HandleAdminCloseSession(streamingASR *asr.StreamingASRManager) gin.HandlerF
 	}
 }
 
+// HandleAdminListRecordings 列出会话录音存档
+func HandleAdminListRecordings(store *recording.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusOK, gin.H{"recordings": []interface{}{}, "enabled": false})
+			return
+		}
+		recordings, err := store.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recordings": recordings, "enabled": true})
+	}
+}
+
+// HandleAdminDownloadRecording 下载录音音频（WAV）或识别结果日志（?file=transcript，JSONL）
+func HandleAdminDownloadRecording(store *recording.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "recording not enabled"})
+			return
+		}
+		id := c.Param("id")
+		path, err := store.AudioPath(id)
+		name := id + ".wav"
+		if c.Query("file") == "transcript" {
+			path, err = store.TranscriptPath(id)
+			name = id + ".jsonl"
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.FileAttachment(path, name)
+	}
+}
+
+// HandleAdminDeleteRecording 删除录音存档，正在录制的会话返回 409
+func HandleAdminDeleteRecording(store *recording.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "recording not enabled"})
+			return
+		}
+		id := c.Param("id")
+		if err := store.Delete(id); err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, recording.ErrActive) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "recording deleted", "id": id})
+	}
+}
+
 // HandleAdminWorkers 返回 worker 状态
 func HandleAdminWorkers(taskQueue *asr.TaskQueue) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -161,3 +297,118 @@ func HandleAdminWorkers(taskQueue *asr.TaskQueue) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"workers": workers})
 	}
 }
+
+// HandleAdminListModels 返回已登记的模型及加载状态
+func HandleAdminListModels(models *asr.ModelRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if models == nil {
+			c.JSON(http.StatusOK, gin.H{"models": []interface{}{}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"models": models.List()})
+	}
+}
+
+// ReloadModelsRequest 模型热加载请求，type/name 为空时重新加载全部模型
+type ReloadModelsRequest struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// HandleAdminReloadModels 重新读取配置文件并热加载模型。
+// 在途请求和会话继续使用旧版本，旧版本在引用全部释放后销毁
+func HandleAdminReloadModels(models *asr.ModelRegistry, reload func(modelType, name string) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ReloadModelsRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+				return
+			}
+		}
+		if req.Type != "" && req.Type != config.ModelTypeOffline && req.Type != config.ModelTypeStreaming {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid model type: " + req.Type})
+			return
+		}
+
+		if err := reload(req.Type, req.Name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "models": models.List()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "models reloaded", "models": models.List()})
+	}
+}
+
+// HotwordListRequest 创建或替换命名热词表的请求
+type HotwordListRequest struct {
+	Hotwords []asr.Hotword `json:"hotwords"`
+}
+
+// HandleAdminListHotwords 返回全部命名热词表
+func HandleAdminListHotwords(store *asr.HotwordStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusOK, gin.H{"enabled": false, "lists": []interface{}{}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"enabled": true, "lists": store.List()})
+	}
+}
+
+// HandleAdminGetHotwords 返回指定命名热词表
+func HandleAdminGetHotwords(store *asr.HotwordStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": asr.ErrHotwordsDisabled.Error()})
+			return
+		}
+		list, ok := store.Get(c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "hotword list not found"})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// HandleAdminPutHotwords 创建或整体替换命名热词表，下一次使用该表的请求即生效
+func HandleAdminPutHotwords(store *asr.HotwordStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": asr.ErrHotwordsDisabled.Error()})
+			return
+		}
+		var req HotwordListRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+		list, err := store.Put(c.Param("name"), req.Hotwords)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+// HandleAdminDeleteHotwords 删除命名热词表
+func HandleAdminDeleteHotwords(store *asr.HotwordStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": asr.ErrHotwordsDisabled.Error()})
+			return
+		}
+		name := c.Param("name")
+		deleted, err := store.Delete(name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "hotword list not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "hotword list deleted", "name": name})
+	}
+}
@@ -2,13 +2,16 @@ package handler
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"airecorder/internal/asr"
 	"airecorder/internal/audio"
+	"airecorder/internal/logging"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -24,51 +27,110 @@ var upgrader = websocket.Upgrader{
 
 // StreamingASRRequest WebSocket 消息格式
 type StreamingASRMessage struct {
-	Type       string `json:"type"`  // "audio" 或 "control"
+	Type       string `json:"type"`  // "audio"、"control" 或 "ack"
 	Audio      string `json:"audio"` // Base64 编码的音频数据
 	SampleRate int    `json:"sample_rate,omitempty"`
-	Command    string `json:"command,omitempty"` // "start", "stop", "reset"
+	Command    string `json:"command,omitempty"` // "start", "stop", "reset", "summary"
+	Seq        int64  `json:"seq,omitempty"`     // ack 消息确认已收到的最大序号
+
+	// start 命令可携带热词（需 transducer 模型），应在发送音频前发送
+	Hotwords    []asr.Hotword `json:"hotwords,omitempty"`
+	HotwordList string        `json:"hotword_list,omitempty"`
+	// start 命令开启录音存档（需 recording.enabled），从此刻起记录音频与识别结果
+	Record bool `json:"record,omitempty"`
 }
 
 // StreamingASRResponse WebSocket 响应格式
 type StreamingASRResponse struct {
-	Type       string `json:"type"` // "result", "partial", "error"
-	Text       string `json:"text"`
-	IsEndpoint bool   `json:"is_endpoint,omitempty"`
-	Segment    int    `json:"segment,omitempty"`
-	Error      string `json:"error,omitempty"`
+	Type         string               `json:"type"` // "result", "partial", "summary", "error"
+	Text         string               `json:"text"`
+	Seq          int64                `json:"seq,omitempty"` // 消息序号（整句结果、部分结果与 summary），客户端据此确认与去重
+	IsEndpoint   bool                 `json:"is_endpoint,omitempty"`
+	Segment      int                  `json:"segment,omitempty"`
+	Model        string               `json:"model,omitempty"`         // 会话使用的模型（仅欢迎消息）
+	SessionID    string               `json:"session_id,omitempty"`    // 会话 ID（仅欢迎消息）
+	ResumeToken  string               `json:"resume_token,omitempty"`  // 重连令牌，断线后以 ?resume= 重连（仅欢迎消息，开启重连时）
+	Resumed      bool                 `json:"resumed,omitempty"`       // 本连接接管了已断开的会话（仅欢迎消息）
+	StablePrefix string               `json:"stable_prefix,omitempty"` // 中间结果中已稳定的前缀（?stable_prefix=true，仅 partial）
+	Redactions   []asr.Redaction      `json:"redactions,omitempty"`    // 本句脱敏位置（?redaction_spans=true，仅 result）
+	Speaker      *int                 `json:"speaker,omitempty"`       // 本句说话人（?diarization=true，仅 result）
+	Speakers     []asr.SegmentSpeaker `json:"speakers,omitempty"`      // 重新聚类后修正的各句说话人（仅 summary）
+	Error        string               `json:"error,omitempty"`
 }
 
 // HandleStreamingASR 处理实时语音识别 WebSocket 连接
 func HandleStreamingASR(c *gin.Context, manager *asr.StreamingASRManager) {
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		requestLogger(c).Warn("WebSocket upgrade error", "error", err)
 		return
 	}
 	defer conn.Close()
 
-	// 创建会话
-	session, err := manager.CreateSession()
-	if err != nil {
-		log.Printf("Failed to create session: %v", err)
-		conn.WriteJSON(StreamingASRResponse{
-			Type:  "error",
-			Error: "Failed to create session: " + err.Error(),
-		})
-		return
+	// ?resume= 重新接管断线前的会话，否则创建新会话
+	var session *asr.StreamingASRSession
+	resumed := c.Query("resume") != ""
+	if resumed {
+		session, err = manager.ResumeSession(c.Query("resume"))
+		if err != nil {
+			conn.WriteJSON(StreamingASRResponse{
+				Type:  "error",
+				Error: "Failed to resume session: " + err.Error(),
+			})
+			return
+		}
+		// ?last_seq= 为客户端已收到的最大序号，之前的消息不再重发
+		if lastSeq, err := strconv.ParseInt(c.Query("last_seq"), 10, 64); err == nil {
+			session.Ack(lastSeq)
+		}
+	} else {
+		session, err = createStreamingSession(c, conn, manager)
+		if err != nil {
+			return
+		}
 	}
-	defer manager.CloseSession(session.ID)
 
-	log.Printf("Streaming ASR session %s started", session.ID)
+	// 客户端发送 stop 时关闭会话，连接意外断开时保留会话等待重连。
+	// 识别流只在此处（本连接不再使用会话后）释放
+	stopped := false
+	defer func() {
+		if stopped || !manager.DetachSession(session.ID) {
+			manager.CloseSession(session.ID)
+		}
+	}()
+
+	logger := requestLogger(c).With("session_id", session.ID)
+	if resumed {
+		logger.Info("Streaming ASR session reattached")
+	} else {
+		logger.Info("Streaming ASR session started")
+	}
 
 	// 发送欢迎消息
-	conn.WriteJSON(StreamingASRResponse{
-		Type: "result",
-		Text: "Connected. Ready to receive audio.",
-	})
+	welcome := StreamingASRResponse{
+		Type:      "result",
+		Text:      "Connected. Ready to receive audio.",
+		Model:     session.Model,
+		SessionID: session.ID,
+		Resumed:   resumed,
+		Seq:       session.LastSeq(),
+	}
+	if manager.ResumeEnabled() {
+		welcome.ResumeToken = manager.ResumeToken(session)
+	}
+	conn.WriteJSON(welcome)
+
+	// 重发客户端尚未确认的消息
+	for _, m := range session.Unacked() {
+		if err := conn.WriteMessage(websocket.TextMessage, m.Payload); err != nil {
+			return
+		}
+	}
+
+	// 保活与空闲、时长限制
+	watchdog := newStreamWatchdog(conn, manager.SessionLimits(), session)
+	defer watchdog.stop()
 
-	segmentIdx := 0
 	lastText := ""
 
 	// 读取消息循环
@@ -76,14 +138,22 @@ func HandleStreamingASR(c *gin.Context, manager *asr.StreamingASRManager) {
 		var msg StreamingASRMessage
 		err := conn.ReadJSON(&msg)
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+			if reason := watchdog.closeReason(err); reason != "" {
+				manager.RecordClose(reason)
+				logger.Info("Streaming ASR session closed by server", "reason", reason)
+				// 保活失败可能只是网络中断，仍保留会话等待重连
+				stopped = reason != asr.CloseReasonKeepalive
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				logger.Warn("WebSocket error", "error", err)
 			}
 			break
 		}
+		watchdog.received()
+		session.Touch()
 
 		switch msg.Type {
 		case "audio":
+			watchdog.audio()
 			// 解码音频数据
 			audioData, err := base64.StdEncoding.DecodeString(msg.Audio)
 			if err != nil {
@@ -96,9 +166,10 @@ func HandleStreamingASR(c *gin.Context, manager *asr.StreamingASRManager) {
 
 			// 将字节数据转换为 float32 样本
 			samples := bytesToFloat32(audioData)
+			session.PublishLevel(samples)
 
 			// 处理音频
-			result, isEndpoint, err := session.ProcessAudio(samples)
+			result, err := session.ProcessAudio(samples)
 			if err != nil {
 				conn.WriteJSON(StreamingASRResponse{
 					Type:  "error",
@@ -107,34 +178,87 @@ func HandleStreamingASR(c *gin.Context, manager *asr.StreamingASRManager) {
 				continue
 			}
 
-			// 如果有新的识别结果，发送回客户端
-			if result != "" && result != lastText {
-				lastText = result
-				conn.WriteJSON(StreamingASRResponse{
-					Type:       "partial",
-					Text:       result,
-					IsEndpoint: isEndpoint,
-					Segment:    segmentIdx,
-				})
-			}
-
-			// 如果检测到端点，重置
-			if isEndpoint {
-				if result != "" {
-					segmentIdx++
-					conn.WriteJSON(StreamingASRResponse{
+			// 检测到端点时发送整句结果（含标点）并重置
+			if result.IsEndpoint {
+				if result.Text != "" {
+					resp := StreamingASRResponse{
 						Type:       "result",
-						Text:       result,
+						Text:       result.Text,
 						IsEndpoint: true,
-						Segment:    segmentIdx,
+						Segment:    session.NextSegment(),
+						Redactions: session.Redactions(result.Text),
+					}
+					if speaker, ok := session.AssignSpeaker(); ok && speaker >= 0 {
+						resp.Speaker = &speaker
+					}
+					sendSequenced(conn, session, resp)
+					session.Publish(asr.SessionEvent{
+						Type:    asr.WatchEventResult,
+						Text:    resp.Text,
+						Segment: resp.Segment,
+						Speaker: resp.Speaker,
 					})
 				}
 				session.Reset()
 				lastText = ""
+				continue
 			}
 
+			// 如果有新的中间结果，发送回客户端
+			if result.Text != "" && result.Text != lastText {
+				lastText = result.Text
+				segment := session.Segment()
+				sendSequenced(conn, session, StreamingASRResponse{
+					Type:         "partial",
+					Text:         result.Text,
+					StablePrefix: result.StablePrefix,
+					Segment:      segment,
+				})
+				session.Publish(asr.SessionEvent{
+					Type:    asr.WatchEventPartial,
+					Text:    result.Text,
+					Segment: segment,
+				})
+			}
+
+		case "ack":
+			session.Ack(msg.Seq)
+
 		case "control":
 			switch msg.Command {
+			case "start":
+				if msg.Record {
+					if err := manager.StartRecording(session.ID); err != nil {
+						conn.WriteJSON(StreamingASRResponse{
+							Type:  "error",
+							Error: "Failed to start recording: " + err.Error(),
+						})
+					} else {
+						conn.WriteJSON(StreamingASRResponse{
+							Type: "result",
+							Text: "Recording started",
+						})
+					}
+				}
+				if msg.HotwordList == "" && len(msg.Hotwords) == 0 {
+					continue
+				}
+				hotwords, err := manager.ResolveHotwords(msg.HotwordList, msg.Hotwords)
+				if err == nil {
+					err = manager.SetSessionHotwords(session.ID, hotwords)
+				}
+				if err != nil {
+					conn.WriteJSON(StreamingASRResponse{
+						Type:  "error",
+						Error: "Invalid hotwords: " + err.Error(),
+					})
+					continue
+				}
+				lastText = ""
+				conn.WriteJSON(StreamingASRResponse{
+					Type: "result",
+					Text: fmt.Sprintf("Hotwords applied (%d)", len(hotwords)),
+				})
 			case "reset":
 				session.Reset()
 				lastText = ""
@@ -142,32 +266,185 @@ func HandleStreamingASR(c *gin.Context, manager *asr.StreamingASRManager) {
 					Type: "result",
 					Text: "Session reset",
 				})
+			case "summary":
+				sendSpeakerSummary(conn, session)
 			case "stop":
+				sendSpeakerSummary(conn, session)
 				conn.WriteJSON(StreamingASRResponse{
 					Type: "result",
 					Text: "Session stopped",
 				})
+				stopped = true
 				return
 			}
 		}
 	}
 
-	log.Printf("Streaming ASR session %s ended", session.ID)
+	logger.Info("Streaming ASR session ended")
+}
+
+// sendSpeakerSummary 发送按整个会话重新聚类后的各句说话人，未开启说话人标注时不发送
+func sendSpeakerSummary(conn *websocket.Conn, session *asr.StreamingASRSession) {
+	speakers, ok := session.SpeakerSummary()
+	if !ok {
+		return
+	}
+	sendSequenced(conn, session, StreamingASRResponse{
+		Type:     "summary",
+		Speakers: speakers,
+	})
+}
+
+// sendSequenced 为消息分配序号后发送。整句结果与 summary 在客户端确认前保留，
+// 断线重连后重发；部分结果会被后续结果取代，不保留
+func sendSequenced(conn *websocket.Conn, session *asr.StreamingASRSession, resp StreamingASRResponse) error {
+	resp.Seq = session.NextSeq()
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if resp.Type != "partial" {
+		session.Keep(resp.Seq, data)
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// createStreamingSession 按查询参数创建新会话，失败时向客户端发送错误
+func createStreamingSession(c *gin.Context, conn *websocket.Conn, manager *asr.StreamingASRManager) (*asr.StreamingASRSession, error) {
+	// 可通过 ?model= 指定模型，?hotword_list= 指定命名热词表
+	hotwords, err := manager.ResolveHotwords(c.Query("hotword_list"), nil)
+	if err != nil {
+		conn.WriteJSON(StreamingASRResponse{
+			Type:  "error",
+			Error: "Invalid hotwords: " + err.Error(),
+		})
+		return nil, err
+	}
+	textOpts := streamingTextOptions(c)
+	if err := manager.ResolvePipeline(textOpts.Pipeline); err != nil {
+		conn.WriteJSON(StreamingASRResponse{
+			Type:  "error",
+			Error: "Invalid pipeline: " + err.Error(),
+		})
+		return nil, err
+	}
+	session, err := manager.CreateSessionWithOptions(asr.SessionOptions{
+		Model:          c.Query("model"),
+		Hotwords:       hotwords,
+		Text:           textOpts,
+		StablePrefix:   c.Query("stable_prefix") == "true",
+		Diarization:    c.Query("diarization") == "true",
+		RedactionSpans: c.Query("redaction_spans") == "true",
+		ClientIP:       c.ClientIP(),
+		APIKey:         clientAPIKey(c),
+	})
+	if err != nil {
+		requestLogger(c).Error("Failed to create streaming session", "error", err)
+		conn.WriteJSON(StreamingASRResponse{
+			Type:  "error",
+			Error: "Failed to create session: " + err.Error(),
+		})
+		return nil, err
+	}
+	return session, nil
+}
+
+// clientAPIKey 客户端标识用的 API Key（X-API-Key 请求头或 ?api_key=），仅用于会话展示
+func clientAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	return c.Query("api_key")
 }
 
 // OfflineASRRequest 离线识别请求格式
 type OfflineASRRequest struct {
-	Audio             string `json:"audio" form:"audio"`                           // Base64 编码的音频数据
-	SampleRate        int    `json:"sample_rate" form:"sample_rate"`               // 采样率，默认 16000
-	EnableDiarization bool   `json:"enable_diarization" form:"enable_diarization"` // 是否启用说话者分离
+	Audio             string          `json:"audio" form:"audio"`                           // Base64 编码的音频数据
+	SampleRate        int             `json:"sample_rate" form:"sample_rate"`               // 采样率，默认 16000
+	EnableDiarization bool            `json:"enable_diarization" form:"enable_diarization"` // 是否启用说话者分离
+	Language          string          `json:"language" form:"language"`                     // 指定语种（如 zh、en、yue），为空时自动检测
+	Model             string          `json:"model" form:"model"`                           // 指定模型名称，为空时使用默认模型
+	Hotwords          []asr.Hotword   `json:"hotwords" form:"-"`                            // 热词列表（表单上传时为 JSON 数组字符串）
+	HotwordList       string          `json:"hotword_list" form:"hotword_list"`             // 服务端命名热词表
+	Pipeline          string          `json:"pipeline" form:"pipeline"`                     // 文本后处理流水线名称，为空时使用默认流水线
+	Stages            map[string]bool `json:"stages" form:"-"`                              // 按阶段类型开关（表单上传时为 JSON 对象字符串）
+	ITN               *bool           `json:"itn" form:"itn"`                               // 是否执行逆文本正则化，等同 stages.itn
+	Replacements      *bool           `json:"replacements" form:"replacements"`             // 是否执行替换词典，等同 stages.replacements
+	RedactionSpans    bool            `json:"redaction_spans" form:"redaction_spans"`       // 返回脱敏内容的位置与估计时间（需 redact 阶段）
+	MuteAudio         bool            `json:"mute_audio" form:"mute_audio"`                 // 返回脱敏内容静音后的音频副本（base64 WAV）
+}
+
+// textOptions 请求中的文本后处理参数，itn/replacements 优先于 stages 中的同名开关
+func (r *OfflineASRRequest) textOptions() asr.TextOptions {
+	opts := asr.TextOptions{Pipeline: r.Pipeline}
+	for stage, on := range r.Stages {
+		on := on
+		opts.SetStage(stage, &on)
+	}
+	opts.SetStage(asr.StageITN, r.ITN)
+	opts.SetStage(asr.StageReplacements, r.Replacements)
+	return opts
+}
+
+// redactionOutput 按请求开关返回脱敏位置与静音后的音频（base64 WAV）
+func (r *OfflineASRRequest) redactionOutput(spans []asr.Redaction, muted []float32) ([]asr.Redaction, string) {
+	var mutedAudio string
+	if r.MuteAudio && muted != nil {
+		mutedAudio = base64.StdEncoding.EncodeToString(audio.EncodeWAV(muted, r.SampleRate))
+	}
+	if !r.RedactionSpans {
+		spans = nil
+	}
+	return spans, mutedAudio
+}
+
+// streamingTextOptions 从查询参数读取文本后处理参数（?pipeline=、?itn=、?replacements= 等阶段开关）
+func streamingTextOptions(c *gin.Context) asr.TextOptions {
+	opts := asr.TextOptions{Pipeline: c.Query("pipeline")}
+	for _, stage := range []string{asr.StagePunctuation, asr.StageITN, asr.StageCasing, asr.StageProfanity, asr.StageRegex, asr.StageFiller, asr.StageReplacements} {
+		opts.SetStage(stage, queryBool(c, stage))
+	}
+	return opts
+}
+
+// queryBool 读取布尔查询参数，未传或无法解析时返回 nil
+func queryBool(c *gin.Context, key string) *bool {
+	v, err := strconv.ParseBool(c.Query(key))
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// bindUploadFields 文件上传时读取其余表单字段，hotwords 字段为 JSON 数组字符串，stages 字段为 JSON 对象字符串
+func bindUploadFields(c *gin.Context, req *OfflineASRRequest) error {
+	if err := c.ShouldBind(req); err != nil {
+		return err
+	}
+	if raw := c.PostForm("hotwords"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Hotwords); err != nil {
+			return fmt.Errorf("invalid hotwords: %w", err)
+		}
+	}
+	if raw := c.PostForm("stages"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Stages); err != nil {
+			return fmt.Errorf("invalid stages: %w", err)
+		}
+	}
+	return nil
 }
 
 // OfflineASRResponse 离线识别响应格式
 type OfflineASRResponse struct {
-	Text     string               `json:"text"`
-	Segments []DiarizationSegment `json:"segments,omitempty"`
-	Duration float32              `json:"duration,omitempty"`
-	Error    string               `json:"error,omitempty"`
+	Text               string               `json:"text"`
+	Segments           []DiarizationSegment `json:"segments,omitempty"`
+	Duration           float32              `json:"duration,omitempty"`
+	Language           string               `json:"language,omitempty"`            // 识别所用语种
+	LanguageConfidence float32              `json:"language_confidence,omitempty"` // 语种置信度 0-1
+	Model              string               `json:"model,omitempty"`               // 识别所用模型
+	Redactions         []asr.Redaction      `json:"redactions,omitempty"`          // 脱敏位置（redaction_spans=true 时）
+	MutedAudio         string               `json:"muted_audio,omitempty"`         // 脱敏内容静音后的音频，base64 WAV（mute_audio=true 时）
+	Error              string               `json:"error,omitempty"`
 }
 
 // DiarizationSegment 说话者分离片段
@@ -214,6 +491,13 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		if err == nil {
 			fileSize = file.Size
 
+			if err := bindUploadFields(c, &req); err != nil {
+				c.JSON(http.StatusBadRequest, OfflineASRResponse{
+					Error: "Invalid request: " + err.Error(),
+				})
+				return
+			}
+
 			// 检查文件大小（从配置获取，默认50MB）
 			maxFileSizeMB := asrManager.GetMaxFileSizeMB()
 			maxFileSize := int64(maxFileSizeMB) << 20
@@ -242,7 +526,7 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 				return
 			}
 		} else {
-			if err := c.ShouldBind(&req); err != nil {
+			if err := bindUploadFields(c, &req); err != nil {
 				c.JSON(http.StatusBadRequest, OfflineASRResponse{
 					Error: "Invalid request: " + err.Error(),
 				})
@@ -261,11 +545,11 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		}
 	}
 
-	log.Printf("Processing audio file: size=%d bytes (%.2f MB)", fileSize, float64(fileSize)/(1024*1024))
+	requestLogger(c).Info("Processing audio file", "bytes", fileSize)
 
 	// 使用音频转换器自动检测和转换格式
 	converter := audio.NewAudioConverter()
-	samples, sampleRate, convertErr := converter.ConvertToSamples(audioData)
+	samples, sampleRate, convertErr := converter.ConvertToSamplesContext(c.Request.Context(), audioData)
 	if convertErr != nil {
 		c.JSON(http.StatusBadRequest, OfflineASRResponse{
 			Error: "Audio format conversion failed: " + convertErr.Error(),
@@ -273,13 +557,44 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		return
 	}
 
-	log.Printf("Audio converted successfully: %d samples at %d Hz", len(samples), sampleRate)
+	requestLogger(c).Debug("Audio converted", "samples", len(samples), "sample_rate", sampleRate)
 
 	// 如果请求中指定了采样率，使用转换后的实际采样率
 	if req.SampleRate == 0 {
 		req.SampleRate = sampleRate
 	}
 
+	// 校验模型名称
+	if _, err := asrManager.ResolveModel(req.Model); err != nil {
+		c.JSON(http.StatusBadRequest, OfflineASRResponse{
+			Error: "Invalid model: " + err.Error(),
+		})
+		return
+	}
+
+	// 合并命名热词表与请求热词
+	hotwords, err := asrManager.ResolveHotwords(req.Model, req.HotwordList, req.Hotwords)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, OfflineASRResponse{
+			Error: "Invalid hotwords: " + err.Error(),
+		})
+		return
+	}
+
+	if err := asrManager.ResolvePipeline(req.Pipeline); err != nil {
+		c.JSON(http.StatusBadRequest, OfflineASRResponse{
+			Error: "Invalid pipeline: " + err.Error(),
+		})
+		return
+	}
+
+	if err := asrManager.CheckLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, OfflineASRResponse{
+			Error: "Invalid language: " + err.Error(),
+		})
+		return
+	}
+
 	// 计算音频时长
 	audioDuration := float32(len(samples)) / float32(req.SampleRate)
 
@@ -287,16 +602,23 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 	useQueue := taskQueue != nil && audioDuration > 120.0 // 2分钟
 
 	if useQueue {
-		log.Printf("Audio duration (%.2fs) > 120s, using task queue", audioDuration)
+		requestLogger(c).Info("Audio longer than 120s, using task queue", "duration_sec", audioDuration)
 
 		// 创建任务
 		enableDiar := diarizationMgr != nil
 		task := asr.NewASRTask(samples, req.SampleRate, diarizationMgr, enableDiar)
+		task.Language = req.Language
+		task.Model = req.Model
+		task.Hotwords = hotwords
+		task.Text = req.textOptions()
+		task.MuteAudio = req.MuteAudio
+		task.RequestID = RequestID(c)
+		task.SetParent(c.Request.Context())
 
 		// 提交任务
 		if err := taskQueue.Submit(task); err != nil {
 			c.JSON(http.StatusServiceUnavailable, OfflineASRResponse{
-				Error: "Task queue full: " + err.Error(),
+				Error: submitError(err),
 			})
 			return
 		}
@@ -312,6 +634,7 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		}
 
 		// 返回结果
+		redactions, mutedAudio := req.redactionOutput(result.Redactions, result.MutedSamples)
 		if enableDiar {
 			diarSegments := make([]DiarizationSegment, len(result.Segments))
 			for i, seg := range result.Segments {
@@ -324,22 +647,40 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 			}
 
 			c.JSON(http.StatusOK, OfflineASRResponse{
-				Text:     result.Text,
-				Segments: diarSegments,
-				Duration: result.Duration,
+				Text:               result.Text,
+				Segments:           diarSegments,
+				Duration:           result.Duration,
+				Language:           result.Language.Language,
+				LanguageConfidence: result.Language.Confidence,
+				Model:              result.Model,
+				Redactions:         redactions,
+				MutedAudio:         mutedAudio,
 			})
 		} else {
 			c.JSON(http.StatusOK, OfflineASRResponse{
-				Text:     result.Text,
-				Duration: result.Duration,
+				Text:               result.Text,
+				Duration:           result.Duration,
+				Language:           result.Language.Language,
+				LanguageConfidence: result.Language.Confidence,
+				Model:              result.Model,
+				Redactions:         redactions,
+				MutedAudio:         mutedAudio,
 			})
 		}
 		return
 	}
 
+	// 确定语种（请求指定或自动检测），用于选择识别模型；指定模型或热词时语种不影响模型选择，不做检测
+	var lang asr.LanguageResult
+	if req.Model == "" && len(hotwords) == 0 {
+		lang = asrManager.ResolveLanguageContext(logging.WithLogger(c.Request.Context(), requestLogger(c)), samples, req.SampleRate, req.Language)
+	}
+	opts := asr.RecognizeOptions{Model: req.Model, Language: lang.Language, Hotwords: hotwords, Text: req.textOptions(), Context: c.Request.Context()}
+	modelName := asrManager.ModelName(opts)
+
 	// 直接处理（不使用队列）
 	if diarizationMgr != nil {
-		segments, err := diarizationMgr.ProcessWithASR(samples, req.SampleRate, asrManager)
+		segments, err := diarizationMgr.ProcessWithASROptions(samples, req.SampleRate, asrManager, opts)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, OfflineASRResponse{
 				Error: "Diarization error: " + err.Error(),
@@ -348,10 +689,14 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		}
 
 		// 组合所有文本
-		fullText := ""
+		fullText, spans := asr.JoinSegments(segments)
+		var muted []float32
+		if req.MuteAudio {
+			muted = asr.MuteRedactions(samples, req.SampleRate, spans)
+		}
+		redactions, mutedAudio := req.redactionOutput(spans, muted)
 		diarSegments := make([]DiarizationSegment, len(segments))
 		for i, seg := range segments {
-			fullText += seg.Text + " "
 			diarSegments[i] = DiarizationSegment{
 				Start:   seg.Start,
 				End:     seg.End,
@@ -361,9 +706,14 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		}
 
 		c.JSON(http.StatusOK, OfflineASRResponse{
-			Text:     fullText,
-			Segments: diarSegments,
-			Duration: audioDuration,
+			Text:               fullText,
+			Segments:           diarSegments,
+			Duration:           audioDuration,
+			Language:           lang.Language,
+			LanguageConfidence: lang.Confidence,
+			Model:              modelName,
+			Redactions:         redactions,
+			MutedAudio:         mutedAudio,
 		})
 		return
 	}
@@ -371,14 +721,13 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 	// 普通识别（不带说话者分离）
 	chunkDurationSec := asrManager.GetChunkDurationSec()
 
-	var text string
-	var err error
+	var transcript asr.Transcript
 
 	if audioDuration > float32(chunkDurationSec) {
-		log.Printf("Audio duration (%.2fs) exceeds chunk duration (%ds), using chunked processing", audioDuration, chunkDurationSec)
-		text, err = asrManager.RecognizeChunked(samples, req.SampleRate)
+		requestLogger(c).Info("Audio exceeds chunk duration, using chunked processing", "duration_sec", audioDuration, "chunk_duration_sec", chunkDurationSec)
+		transcript, err = asrManager.RecognizeChunkedDetailed(samples, req.SampleRate, opts)
 	} else {
-		text, err = asrManager.Recognize(samples, req.SampleRate)
+		transcript, err = asrManager.RecognizeDetailed(samples, req.SampleRate, opts)
 	}
 
 	if err != nil {
@@ -388,9 +737,20 @@ func HandleOfflineASRWithQueue(c *gin.Context, asrManager *asr.OfflineASRManager
 		return
 	}
 
+	var muted []float32
+	if req.MuteAudio {
+		muted = asr.MuteRedactions(samples, req.SampleRate, transcript.Redactions)
+	}
+	redactions, mutedAudio := req.redactionOutput(transcript.Redactions, muted)
+
 	c.JSON(http.StatusOK, OfflineASRResponse{
-		Text:     text,
-		Duration: audioDuration,
+		Text:               transcript.Text,
+		Duration:           audioDuration,
+		Language:           lang.Language,
+		LanguageConfidence: lang.Confidence,
+		Model:              modelName,
+		Redactions:         redactions,
+		MutedAudio:         mutedAudio,
 	})
 }
 
@@ -456,7 +816,7 @@ func HandleDiarization(c *gin.Context, manager *asr.DiarizationManager) {
 
 	// 使用音频转换器转换格式
 	converter := audio.NewAudioConverter()
-	samples, sampleRate, err := converter.ConvertToSamples(audioData)
+	samples, sampleRate, err := converter.ConvertToSamplesContext(c.Request.Context(), audioData)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Audio format conversion failed: " + err.Error(),
@@ -514,13 +874,18 @@ type OfflineASRAsyncResponse struct {
 
 // OfflineASRTaskResponse 任务查询响应
 type OfflineASRTaskResponse struct {
-	TaskID   string               `json:"task_id"`
-	Status   string               `json:"status"`
-	Progress float32              `json:"progress"` // 处理进度百分比 0-100
-	Text     string               `json:"text,omitempty"`
-	Segments []DiarizationSegment `json:"segments,omitempty"`
-	Duration float32              `json:"duration,omitempty"`
-	Error    string               `json:"error,omitempty"`
+	TaskID             string               `json:"task_id"`
+	Status             string               `json:"status"`
+	Progress           float32              `json:"progress"` // 处理进度百分比 0-100
+	Text               string               `json:"text,omitempty"`
+	Segments           []DiarizationSegment `json:"segments,omitempty"`
+	Duration           float32              `json:"duration,omitempty"`
+	Language           string               `json:"language,omitempty"`            // 识别所用语种
+	LanguageConfidence float32              `json:"language_confidence,omitempty"` // 语种置信度 0-1
+	Model              string               `json:"model,omitempty"`               // 识别所用模型
+	Redactions         []asr.Redaction      `json:"redactions,omitempty"`          // 脱敏位置（redaction_spans=true 时）
+	MutedAudio         string               `json:"muted_audio,omitempty"`         // 脱敏内容静音后的音频，base64 WAV（mute_audio=true 时）
+	Error              string               `json:"error,omitempty"`
 }
 
 // taskStatusString 将内部状态枚举转成字符串
@@ -568,6 +933,10 @@ func HandleOfflineASRAsync(c *gin.Context, asrManager *asr.OfflineASRManager, di
 		file, err := c.FormFile("audio_file")
 		if err == nil {
 			fileSize = file.Size
+			if err := bindUploadFields(c, &req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+				return
+			}
 			maxFileSizeMB := asrManager.GetMaxFileSizeMB()
 			if fileSize > int64(maxFileSizeMB)<<20 {
 				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
@@ -587,7 +956,7 @@ func HandleOfflineASRAsync(c *gin.Context, asrManager *asr.OfflineASRManager, di
 				return
 			}
 		} else {
-			if err := c.ShouldBind(&req); err != nil {
+			if err := bindUploadFields(c, &req); err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
 				return
 			}
@@ -601,10 +970,10 @@ func HandleOfflineASRAsync(c *gin.Context, asrManager *asr.OfflineASRManager, di
 		}
 	}
 
-	log.Printf("[Async] Processing audio file: size=%d bytes (%.2f MB)", fileSize, float64(fileSize)/(1024*1024))
+	requestLogger(c).Info("Processing audio file", "bytes", fileSize, "async", true)
 
 	converter := audio.NewAudioConverter()
-	samples, sampleRate, convertErr := converter.ConvertToSamples(audioData)
+	samples, sampleRate, convertErr := converter.ConvertToSamplesContext(c.Request.Context(), audioData)
 	if convertErr != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio format conversion failed: " + convertErr.Error()})
 		return
@@ -614,16 +983,46 @@ func HandleOfflineASRAsync(c *gin.Context, asrManager *asr.OfflineASRManager, di
 		req.SampleRate = sampleRate
 	}
 
+	if _, err := asrManager.ResolveModel(req.Model); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid model: " + err.Error()})
+		return
+	}
+
+	hotwords, err := asrManager.ResolveHotwords(req.Model, req.HotwordList, req.Hotwords)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hotwords: " + err.Error()})
+		return
+	}
+
+	if err := asrManager.ResolvePipeline(req.Pipeline); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pipeline: " + err.Error()})
+		return
+	}
+
+	if err := asrManager.CheckLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language: " + err.Error()})
+		return
+	}
+
 	enableDiar := diarizationMgr != nil && req.EnableDiarization
 	task := asr.NewASRTask(samples, req.SampleRate, diarizationMgr, enableDiar)
+	task.Language = req.Language
+	task.Model = req.Model
+	task.Hotwords = hotwords
+	task.Text = req.textOptions()
+	task.MuteAudio = req.MuteAudio
+	task.RedactionSpans = req.RedactionSpans
+	task.RequestID = RequestID(c)
+	task.SetParent(c.Request.Context())
 
 	// 先存入任务存储，再提交到队列
 	taskQueue.StoreTask(task)
 
 	if err := taskQueue.Submit(task); err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Task queue full: " + err.Error()})
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": submitError(err)})
 		return
 	}
+	requestLogger(c).Info("Task created", "task_id", task.ID)
 
 	c.JSON(http.StatusAccepted, OfflineASRAsyncResponse{
 		TaskID: task.ID,
@@ -658,6 +1057,15 @@ func HandleASRTaskQuery(c *gin.Context, taskQueue *asr.TaskQueue) {
 	if task.GetStatus() == asr.TaskStatusCompleted && task.Result != nil {
 		resp.Text = task.Result.Text
 		resp.Duration = task.Result.Duration
+		resp.Language = task.Result.Language.Language
+		resp.LanguageConfidence = task.Result.Language.Confidence
+		resp.Model = task.Result.Model
+		if task.RedactionSpans {
+			resp.Redactions = task.Result.Redactions
+		}
+		if task.Result.MutedSamples != nil {
+			resp.MutedAudio = base64.StdEncoding.EncodeToString(audio.EncodeWAV(task.Result.MutedSamples, task.SampleRate))
+		}
 		if len(task.Result.Segments) > 0 {
 			segs := make([]DiarizationSegment, len(task.Result.Segments))
 			for i, seg := range task.Result.Segments {
@@ -682,7 +1090,7 @@ func HandleASRTaskQuery(c *gin.Context, taskQueue *asr.TaskQueue) {
 // bytesToFloat32 将字节数组转换为 float32 样本数组
 func bytesToFloat32(data []byte) []float32 {
 	if len(data)%2 != 0 {
-		log.Printf("Warning: audio data length is not even, truncating last byte")
+		slog.Warn("Audio data length is not even, truncating last byte")
 		data = data[:len(data)-1]
 	}
 
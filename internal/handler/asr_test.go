@@ -67,15 +67,15 @@ func TestOfflineASRAPI(t *testing.T) {
 	}
 
 	// 创建管理器
-	asrMgr := asr.NewOfflineASRManager(cfg)
-	if asrMgr == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	asrMgr, err := asr.NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer asrMgr.Close()
 
-	diarizationMgr := asr.NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		t.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := asr.NewDiarizationManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
@@ -188,15 +188,15 @@ func TestOfflineASRAPIWithFile(t *testing.T) {
 	}
 
 	// 创建管理器
-	asrMgr := asr.NewOfflineASRManager(cfg)
-	if asrMgr == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	asrMgr, err := asr.NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer asrMgr.Close()
 
-	diarizationMgr := asr.NewDiarizationManager(cfg)
-	if diarizationMgr == nil {
-		t.Fatal("Failed to create diarization manager")
+	diarizationMgr, err := asr.NewDiarizationManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create diarization manager: %v", err)
 	}
 	defer diarizationMgr.Close()
 
@@ -248,9 +248,9 @@ func TestOfflineASRAPIErrors(t *testing.T) {
 	}
 
 	// 创建管理器
-	asrMgr := asr.NewOfflineASRManager(cfg)
-	if asrMgr == nil {
-		t.Fatal("Failed to create offline ASR manager")
+	asrMgr, err := asr.NewOfflineASRManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer asrMgr.Close()
 
@@ -321,9 +321,9 @@ func BenchmarkOfflineASRAPI(b *testing.B) {
 	}
 
 	// 创建管理器
-	asrMgr := asr.NewOfflineASRManager(cfg)
-	if asrMgr == nil {
-		b.Fatal("Failed to create offline ASR manager")
+	asrMgr, err := asr.NewOfflineASRManager(cfg)
+	if err != nil {
+		b.Fatalf("Failed to create offline ASR manager: %v", err)
 	}
 	defer asrMgr.Close()
 
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 识别能力状态
+const (
+	CapabilityReady       = "ready"
+	CapabilityUnavailable = "unavailable"
+)
+
+// CapabilityStatus 一项识别能力（实时识别、离线识别、说话者分离）的加载状态。
+// 加载失败时服务以降级模式运行，只停用该能力，并定期重试加载
+type CapabilityStatus struct {
+	Status      string     `json:"status"` // ready 或 unavailable
+	Error       string     `json:"error,omitempty"`
+	Attempts    int        `json:"attempts"`               // 加载次数（含启动时）
+	LastAttempt *time.Time `json:"last_attempt,omitempty"` // 最近一次加载时间
+	NextRetry   *time.Time `json:"next_retry,omitempty"`   // 下次自动重试时间，未开启自动重试时为空
+}
+
+// RequireAvailable 能力不可用时返回 503，available 返回该能力的加载错误
+func RequireAvailable(name string, available func() error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := available(); err != nil {
+			c.Header("Retry-After", "60")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":  name + " is unavailable",
+				"reason": err.Error(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HandleAdminListCapabilities 返回各识别能力的加载状态
+func HandleAdminListCapabilities(capabilities func() map[string]CapabilityStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"capabilities": capabilities()})
+	}
+}
+
+// HandleAdminRetryCapability 立即重试加载不可用的识别能力，已就绪时直接返回当前状态。
+// retry 的第二个返回值为 false 表示该能力不存在或未启用
+func HandleAdminRetryCapability(retry func(name string) (CapabilityStatus, bool)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		status, found := retry(name)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Capability not found or not enabled: " + name})
+			return
+		}
+		if status.Status != CapabilityReady {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "capability still unavailable", "name": name, "capability": status})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "capability ready", "name": name, "capability": status})
+	}
+}
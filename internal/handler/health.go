@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"airecorder/internal/asr"
 	"airecorder/internal/audio"
 	"airecorder/internal/version"
 
@@ -18,10 +19,83 @@ func HealthCheck(c *gin.Context) {
 	})
 }
 
-// Index 首页
-func Index(c *gin.Context) {
+// 组件状态
+const (
+	ComponentOK       = "ok"
+	ComponentDegraded = "degraded" // 组件不可用，但不影响其他能力，服务仍然就绪
+	ComponentFail     = "fail"
+)
+
+// ComponentStatus 就绪检查中单个组件的状态
+type ComponentStatus struct {
+	Status  string                 `json:"status"` // ok、degraded 或 fail
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// ReadinessCheck 一项组件检查
+type ReadinessCheck struct {
+	Name  string
+	Check func() ComponentStatus
+}
+
+// HandleLivez 存活探针：进程能处理请求即返回 200，不检查模型等依赖，避免依赖故障导致反复重启
+func HandleLivez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// HandleReadyz 就绪探针：执行各组件检查，全部正常时返回 200（ready），只有降级组件时返回 200（degraded），
+// 存在失败组件时返回 503，响应中列出各组件状态
+func HandleReadyz(checks []ReadinessCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ready, degraded := true, false
+		components := make(map[string]ComponentStatus, len(checks))
+		for _, check := range checks {
+			status := check.Check()
+			switch status.Status {
+			case ComponentOK:
+			case ComponentDegraded:
+				degraded = true
+			default:
+				ready = false
+			}
+			components[check.Name] = status
+		}
+
+		code, status := http.StatusOK, "ready"
+		if !ready {
+			code, status = http.StatusServiceUnavailable, "not_ready"
+		} else if degraded {
+			status = "degraded"
+		}
+		c.JSON(code, gin.H{
+			"status":     status,
+			"version":    version.Short(),
+			"components": components,
+		})
+	}
+}
+
+// Index 首页（包含可用模型列表）
+func Index(models *asr.ModelRegistry) gin.HandlerFunc {
+	return IndexWithCapabilities(models, nil)
+}
+
+// IndexWithCapabilities 首页，额外列出各识别能力的加载状态（capabilities 为 nil 时不输出）
+func IndexWithCapabilities(models *asr.ModelRegistry, capabilities func() map[string]CapabilityStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		index(c, models, capabilities)
+	}
+}
+
+func index(c *gin.Context, models *asr.ModelRegistry, capabilities func() map[string]CapabilityStatus) {
+	modelList := []asr.ModelInfo{}
+	if models != nil {
+		modelList = models.List()
+	}
+
 	versionInfo := version.Get()
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"service": "AI Recorder - Speech Recognition Service",
 		"version": versionInfo.Version,
 		"build_info": gin.H{
@@ -38,5 +112,10 @@ func Index(c *gin.Context) {
 			"stats":                    "/api/v1/stats (GET)",
 		},
 		"supported_audio_formats": audio.GetSupportedFormats(),
-	})
+		"models":                  modelList,
+	}
+	if capabilities != nil {
+		resp["capabilities"] = capabilities()
+	}
+	c.JSON(http.StatusOK, resp)
 }
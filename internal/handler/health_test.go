@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyzReportsComponents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	queueFull := false
+	checks := []ReadinessCheck{
+		{Name: "offline_asr", Check: func() ComponentStatus { return ComponentStatus{Status: ComponentOK} }},
+		{Name: "task_queue", Check: func() ComponentStatus {
+			if queueFull {
+				return ComponentStatus{Status: ComponentFail, Message: "task queue saturated"}
+			}
+			return ComponentStatus{Status: ComponentOK}
+		}},
+	}
+	r := gin.New()
+	r.GET("/livez", HandleLivez)
+	r.GET("/readyz", HandleReadyz(checks))
+
+	readyz := func() (int, map[string]ComponentStatus, string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body struct {
+			Status     string                     `json:"status"`
+			Components map[string]ComponentStatus `json:"components"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, body.Components, body.Status
+	}
+
+	if code, components, status := readyz(); code != http.StatusOK || status != "ready" || len(components) != 2 {
+		t.Fatalf("expected ready, got %d %s %+v", code, status, components)
+	}
+
+	queueFull = true
+	code, components, status := readyz()
+	if code != http.StatusServiceUnavailable || status != "not_ready" {
+		t.Fatalf("expected not ready, got %d %s", code, status)
+	}
+	if components["task_queue"].Message != "task queue saturated" || components["offline_asr"].Status != ComponentOK {
+		t.Fatalf("unexpected components %+v", components)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("livez should not depend on components, got %d", w.Code)
+	}
+}
+
+func TestReadyzDegradedCapability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	loadErr := errors.New("failed to create offline recognizer")
+	checks := []ReadinessCheck{
+		{Name: "streaming_asr", Check: func() ComponentStatus { return ComponentStatus{Status: ComponentOK} }},
+		{Name: "offline_asr", Check: func() ComponentStatus {
+			if loadErr != nil {
+				return ComponentStatus{Status: ComponentDegraded, Message: loadErr.Error()}
+			}
+			return ComponentStatus{Status: ComponentOK}
+		}},
+	}
+	r := gin.New()
+	r.GET("/readyz", HandleReadyz(checks))
+	r.POST("/offline", RequireAvailable("offline_asr", func() error { return loadErr }), func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || body.Status != "degraded" {
+		t.Fatalf("expected degraded readiness, got %d %s", w.Code, body.Status)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/offline", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while capability unavailable, got %d", w.Code)
+	}
+
+	loadErr = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/offline", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected request to pass after reload, got %d", w.Code)
+	}
+}
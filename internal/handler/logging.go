@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"airecorder/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+	maxRequestIDLen = 128
+)
+
+// RequestLogger 为每个请求分配请求 ID（沿用客户端传入的 X-Request-ID）并在响应头中返回，
+// 请求结束后记录访问日志
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []any{
+			requestIDKey, id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		// TracingMiddleware 已将 span 放入请求上下文时关联 trace_id
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			attrs = append(attrs, "trace_id", sc.TraceID().String())
+		}
+		slog.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// validRequestID 客户端传入的请求 ID 只接受可打印 ASCII，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestID 当前请求的 ID
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestLogger 带请求 ID 字段的 logger
+func requestLogger(c *gin.Context) *slog.Logger {
+	if id := RequestID(c); id != "" {
+		return slog.With(requestIDKey, id)
+	}
+	return slog.Default()
+}
+
+// HandleAdminGetLogLevel 返回当前日志级别
+func HandleAdminGetLogLevel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"level": logging.Level()})
+	}
+}
+
+// HandleAdminSetLogLevel 运行时调整日志级别（不写回配置文件，重启后恢复 logging.level）
+func HandleAdminSetLogLevel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Level string `json:"level" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "level required"})
+			return
+		}
+		previous := logging.Level()
+		if err := logging.SetLevel(req.Level); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		requestLogger(c).Info("Log level changed by admin", "from", previous, "to", logging.Level())
+		c.JSON(http.StatusOK, gin.H{"level": logging.Level()})
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"airecorder/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware 按路由模板与状态码统计请求数，未匹配路由的请求记为 unmatched，避免标签基数失控
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.Inc(route, c.Request.Method, strconv.Itoa(c.Writer.Status()))
+	}
+}
+
+// HandleMetrics 以 Prometheus 文本格式导出指标，token 不为空时要求 Authorization: Bearer <token>
+func HandleMetrics(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" {
+			got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+				return
+			}
+		}
+
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		metrics.Default.WriteText(c.Writer)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"airecorder/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MetricsMiddleware())
+	r.GET("/realkws/api/v1/offline/asr/task/:taskId", func(c *gin.Context) {
+		c.String(http.StatusNotFound, "missing")
+	})
+	r.GET("/metrics", HandleMetrics("metrics-token"))
+
+	route := "/realkws/api/v1/offline/asr/task/:taskId"
+	before := metrics.HTTPRequests.Value(route, http.MethodGet, "404")
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/realkws/api/v1/offline/asr/task/task_1", nil))
+	if got := metrics.HTTPRequests.Value(route, http.MethodGet, "404"); got != before+1 {
+		t.Fatalf("request should be counted by route template, got %v", got-before)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer metrics-token")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `airecorder_http_requests_total{route="`+route+`",method="GET",status="404"}`) {
+		t.Fatalf("request counter missing from output:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"airecorder/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleAdminGetConfig 返回可运行时修改的配置及当前取值
+func HandleAdminGetConfig(get func() config.RuntimeSettings) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"config": get()})
+	}
+}
+
+// HandleAdminPatchConfig 运行时修改配置（不写回配置文件，重启后恢复），只修改请求中给出的字段。
+// 每个实际变更的字段记录一条审计日志；apply 返回 ErrInvalidRuntimeSetting 时返回 400
+func HandleAdminPatchConfig(apply func(patch config.RuntimeSettings) ([]config.RuntimeChange, config.RuntimeSettings, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var patch config.RuntimeSettings
+		dec := json.NewDecoder(c.Request.Body)
+		dec.DisallowUnknownFields() // 拒绝拼错或不可运行时修改的字段，避免静默忽略
+		if err := dec.Decode(&patch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := patch.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		changes, current, err := apply(patch)
+		for _, ch := range changes {
+			requestLogger(c).Info("Runtime config changed by admin",
+				"audit", true, "field", ch.Field, "from", ch.From, "to", ch.To, "client_ip", c.ClientIP())
+		}
+		if changes == nil {
+			changes = []config.RuntimeChange{}
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, config.ErrInvalidRuntimeSetting) {
+				status = http.StatusBadRequest
+			}
+			requestLogger(c).Warn("Runtime config change failed", "audit", true, "error", err, "client_ip", c.ClientIP())
+			c.JSON(status, gin.H{"error": err.Error(), "config": current, "changes": changes})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"config": current, "changes": changes})
+	}
+}
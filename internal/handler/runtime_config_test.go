@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"airecorder/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminPatchConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	workers := 4
+	current := config.RuntimeSettings{WorkerPoolSize: &workers}
+	var applied int
+	apply := func(patch config.RuntimeSettings) ([]config.RuntimeChange, config.RuntimeSettings, error) {
+		applied++
+		next := current.Merge(patch)
+		changes := current.Diff(next)
+		current = next
+		return changes, current, nil
+	}
+	r := gin.New()
+	r.PATCH("/config", HandleAdminPatchConfig(apply))
+	patch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/config", strings.NewReader(body)))
+		return w
+	}
+
+	w := patch(`{"worker_pool_size": 8}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Config  config.RuntimeSettings `json:"config"`
+		Changes []config.RuntimeChange `json:"changes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if *body.Config.WorkerPoolSize != 8 || len(body.Changes) != 1 || body.Changes[0].Field != "worker_pool_size" {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+
+	// 拼错的字段、非法取值不会调用 apply
+	for _, bad := range []string{`{"worker_pool": 8}`, `{"queue_size": 0}`, `not json`} {
+		if w := patch(bad); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", bad, w.Code)
+		}
+	}
+	if applied != 1 {
+		t.Fatalf("apply should only be called for valid patches, called %d times", applied)
+	}
+
+	w = patch(`{"worker_pool_size": 8}`)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"changes":[]`)) {
+		t.Fatalf("unchanged patch should report no changes: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"airecorder/internal/asr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shutdownRetryAfter 服务关闭期间拒绝请求时建议客户端的重试间隔（秒）
+const shutdownRetryAfter = "5"
+
+// RejectWhenDraining 服务关闭期间拒绝新的识别请求与实时连接（503），
+// 已提交任务的查询等其他接口不受影响
+func RejectWhenDraining(draining func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if draining() {
+			c.Header("Retry-After", shutdownRetryAfter)
+			c.Header("Connection", "close")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": asr.ErrShuttingDown.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// HealthCheckWithDrain 健康检查，服务关闭期间返回 503 使负载均衡摘除本实例
+func HealthCheckWithDrain(draining func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if draining() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "shutting_down",
+				"service": "airecorder",
+			})
+			return
+		}
+		HealthCheck(c)
+	}
+}
+
+// submitError 任务提交失败的错误信息
+func submitError(err error) string {
+	if errors.Is(err, asr.ErrShuttingDown) {
+		return "Server is shutting down, please retry later"
+	}
+	return "Task queue full: " + err.Error()
+}
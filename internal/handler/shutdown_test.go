@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRejectWhenDraining(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var draining atomic.Bool
+	r := gin.New()
+	r.GET("/health", HealthCheckWithDrain(draining.Load))
+	r.POST("/offline/asr", RejectWhenDraining(draining.Load), func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	if w := serve(http.MethodGet, "/health"); w.Code != http.StatusOK {
+		t.Fatalf("health before shutdown = %d, want 200", w.Code)
+	}
+	if w := serve(http.MethodPost, "/offline/asr"); w.Code != http.StatusAccepted {
+		t.Fatalf("request before shutdown = %d, want 202", w.Code)
+	}
+
+	draining.Store(true)
+	if w := serve(http.MethodGet, "/health"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("health while draining = %d, want 503", w.Code)
+	}
+	w := serve(http.MethodPost, "/offline/asr")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("request while draining = %d (Retry-After %q), want 503", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"airecorder/internal/config"
+	"airecorder/internal/metrics"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +22,11 @@ const (
 
 // SignatureAuthMiddleware 对非管理员接口启用签名校验。
 func SignatureAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return SignatureAuthMiddlewareWithSkew(cfg, func() int64 { return cfg.Signature.MaxSkewSeconds })
+}
+
+// SignatureAuthMiddlewareWithSkew 签名校验，允许的时间偏差每次请求时读取（可运行中调整）
+func SignatureAuthMiddlewareWithSkew(cfg *config.Config, maxSkewSeconds func() int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !cfg.Signature.Enabled {
 			c.Next()
@@ -49,23 +55,27 @@ func SignatureAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		if timestamp == "" || signature == "" {
+			metrics.SignatureRejections.Inc("missing")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing signature or timestamp"})
 			return
 		}
 
 		tsUnix, err := parseTimestamp(timestamp)
 		if err != nil {
+			metrics.SignatureRejections.Inc("invalid_timestamp")
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid timestamp"})
 			return
 		}
 
-		if isTimestampExpired(time.Now().Unix(), tsUnix, cfg.Signature.MaxSkewSeconds) {
+		if isTimestampExpired(time.Now().Unix(), tsUnix, maxSkewSeconds()) {
+			metrics.SignatureRejections.Inc("expired")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "timestamp expired"})
 			return
 		}
 
 		expected := SignPathTimestamp(path, timestamp, cfg.Signature.Secret)
 		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+			metrics.SignatureRejections.Inc("invalid_signature")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
 			return
 		}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"airecorder/internal/asr"
+
+	"github.com/gorilla/websocket"
+)
+
+// 服务端主动结束实时连接时使用的 WebSocket 关闭码（4000-4999 为应用自定义）
+const (
+	CloseIdleTimeout      = 4000 // 长时间未收到音频
+	CloseMaxDuration      = 4001 // 超过最长连接时长
+	CloseKeepaliveTimeout = 4002 // 未收到 pong
+	CloseByAdmin          = 4003 // 管理员关闭会话
+)
+
+// closeCodes 关闭原因对应的关闭码
+var closeCodes = map[string]int{
+	asr.CloseReasonIdleTimeout: CloseIdleTimeout,
+	asr.CloseReasonMaxDuration: CloseMaxDuration,
+	asr.CloseReasonKeepalive:   CloseKeepaliveTimeout,
+	asr.CloseReasonAdmin:       CloseByAdmin,
+	asr.CloseReasonShutdown:    websocket.CloseGoingAway, // 1001，客户端可稍后重连其他实例
+}
+
+const (
+	wsWriteWait  = 5 * time.Second // 控制帧的写超时
+	wsCloseGrace = 2 * time.Second // 发送关闭帧后等待客户端回应的时间，超时直接断开
+)
+
+// watchedSession 被监控连接对应的会话
+type watchedSession interface {
+	StartedAt() time.Time
+	Done() <-chan struct{}
+	CloseReason() string
+}
+
+// streamWatchdog 实时连接的保活与时长监控：定期发送 ping，读超时视为连接失效；
+// 长时间未收到音频、超过最长时长或会话被取消时发送带原因的关闭帧并断开连接
+type streamWatchdog struct {
+	conn      *websocket.Conn
+	session   watchedSession
+	limits    asr.SessionLimits
+	deadline  time.Time // 会话的最长时长截止时间，零值表示不限制
+	lastAudio int64     // 最近收到音频的时间（UnixNano）
+	reason    string
+	mu        sync.Mutex
+	done      chan struct{}
+}
+
+// newStreamWatchdog 开始监控连接，最长时长从会话创建开始计算（重连不重新计时）
+func newStreamWatchdog(conn *websocket.Conn, limits asr.SessionLimits, session watchedSession) *streamWatchdog {
+	w := &streamWatchdog{
+		conn:      conn,
+		session:   session,
+		limits:    limits,
+		lastAudio: time.Now().UnixNano(),
+		done:      make(chan struct{}),
+	}
+	if limits.MaxDuration > 0 {
+		w.deadline = session.StartedAt().Add(limits.MaxDuration)
+	}
+	w.received()
+	conn.SetPongHandler(func(string) error {
+		w.received()
+		return nil
+	})
+	go w.run()
+	return w
+}
+
+// received 收到消息或 pong 后延长读超时
+func (w *streamWatchdog) received() {
+	if w.limits.PongTimeout > 0 {
+		w.conn.SetReadDeadline(time.Now().Add(w.limits.PongTimeout))
+	}
+}
+
+// audio 记录收到音频，重新计算空闲时间
+func (w *streamWatchdog) audio() {
+	atomic.StoreInt64(&w.lastAudio, time.Now().UnixNano())
+}
+
+func (w *streamWatchdog) run() {
+	var ping, idle, maxDuration <-chan time.Time
+	if w.limits.PingInterval > 0 {
+		t := time.NewTicker(w.limits.PingInterval)
+		defer t.Stop()
+		ping = t.C
+	}
+	if w.limits.IdleTimeout > 0 {
+		check := w.limits.IdleTimeout / 2
+		if check > time.Second {
+			check = time.Second
+		}
+		t := time.NewTicker(check)
+		defer t.Stop()
+		idle = t.C
+	}
+	if !w.deadline.IsZero() {
+		t := time.NewTimer(time.Until(w.deadline))
+		defer t.Stop()
+		maxDuration = t.C
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.session.Done():
+			w.expire(w.session.CloseReason())
+			return
+		case <-ping:
+			w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		case <-idle:
+			if time.Since(time.Unix(0, atomic.LoadInt64(&w.lastAudio))) >= w.limits.IdleTimeout {
+				w.expire(asr.CloseReasonIdleTimeout)
+				return
+			}
+		case <-maxDuration:
+			w.expire(asr.CloseReasonMaxDuration)
+			return
+		}
+	}
+}
+
+// expire 以指定原因结束连接：发送关闭帧，客户端未在 wsCloseGrace 内回应时直接断开
+func (w *streamWatchdog) expire(reason string) {
+	if !w.setReason(reason) {
+		return
+	}
+	msg := websocket.FormatCloseMessage(closeCodes[reason], reason)
+	w.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+	time.AfterFunc(wsCloseGrace, func() {
+		w.conn.Close()
+	})
+}
+
+func (w *streamWatchdog) setReason(reason string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.reason != "" {
+		return false
+	}
+	w.reason = reason
+	return true
+}
+
+// closeReason 读取失败后判断连接结束的原因：读超时视为保活失败，
+// 返回空表示客户端主动断开或发生其他网络错误
+func (w *streamWatchdog) closeReason(readErr error) string {
+	var netErr net.Error
+	if errors.As(readErr, &netErr) && netErr.Timeout() && w.setReason(asr.CloseReasonKeepalive) {
+		msg := websocket.FormatCloseMessage(CloseKeepaliveTimeout, asr.CloseReasonKeepalive)
+		w.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reason
+}
+
+// stop 结束监控
+func (w *streamWatchdog) stop() {
+	close(w.done)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"airecorder/internal/asr"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeSession 只提供监控所需信息的会话
+type fakeSession struct {
+	started time.Time
+	done    chan struct{}
+	reason  string
+}
+
+func newFakeSession() *fakeSession {
+	return &fakeSession{started: time.Now(), done: make(chan struct{})}
+}
+
+func (s *fakeSession) StartedAt() time.Time  { return s.started }
+func (s *fakeSession) Done() <-chan struct{} { return s.done }
+func (s *fakeSession) CloseReason() string   { return s.reason }
+
+// watchdogServer 启动只运行 streamWatchdog 的 WebSocket 服务，返回服务端判定的结束原因
+func watchdogServer(t *testing.T, limits asr.SessionLimits, session watchedSession) (*httptest.Server, <-chan string) {
+	reasons := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		watchdog := newStreamWatchdog(conn, limits, session)
+		defer watchdog.stop()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				reasons <- watchdog.closeReason(err)
+				return
+			}
+			watchdog.received()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, reasons
+}
+
+func dialWatchdog(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestStreamWatchdogCloseCodes(t *testing.T) {
+	cases := []struct {
+		limits asr.SessionLimits
+		code   int
+		reason string
+	}{
+		{asr.SessionLimits{IdleTimeout: 50 * time.Millisecond}, CloseIdleTimeout, asr.CloseReasonIdleTimeout},
+		{asr.SessionLimits{MaxDuration: 50 * time.Millisecond}, CloseMaxDuration, asr.CloseReasonMaxDuration},
+	}
+	for _, tc := range cases {
+		srv, reasons := watchdogServer(t, tc.limits, newFakeSession())
+		conn := dialWatchdog(t, srv)
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != tc.code || closeErr.Text != tc.reason {
+			t.Fatalf("expected close %d %q, got %v", tc.code, tc.reason, err)
+		}
+		if got := <-reasons; got != tc.reason {
+			t.Fatalf("server close reason = %q, want %q", got, tc.reason)
+		}
+	}
+}
+
+func TestStreamWatchdogKeepalive(t *testing.T) {
+	srv, reasons := watchdogServer(t, asr.SessionLimits{PongTimeout: 100 * time.Millisecond}, newFakeSession())
+	dialWatchdog(t, srv) // 客户端不读取消息，也就不回应 ping
+
+	select {
+	case got := <-reasons:
+		if got != asr.CloseReasonKeepalive {
+			t.Fatalf("close reason = %q, want %q", got, asr.CloseReasonKeepalive)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("read deadline did not expire")
+	}
+}
+
+func TestStreamWatchdogPongExtendsDeadline(t *testing.T) {
+	srv, reasons := watchdogServer(t, asr.SessionLimits{PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond}, newFakeSession())
+	conn := dialWatchdog(t, srv)
+
+	// 客户端持续读取即会自动回应 ping，连接应保持
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case got := <-reasons:
+		t.Fatalf("connection closed unexpectedly: %q", got)
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+func TestStreamWatchdogSessionCancelled(t *testing.T) {
+	session := newFakeSession()
+	srv, reasons := watchdogServer(t, asr.SessionLimits{}, session)
+	conn := dialWatchdog(t, srv)
+
+	session.reason = asr.CloseReasonAdmin
+	close(session.done)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseByAdmin {
+		t.Fatalf("expected close %d, got %v", CloseByAdmin, err)
+	}
+	if got := <-reasons; got != asr.CloseReasonAdmin {
+		t.Fatalf("server close reason = %q, want %q", got, asr.CloseReasonAdmin)
+	}
+}
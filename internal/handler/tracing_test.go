@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"airecorder/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestIDAndTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestLogger(), TracingMiddleware())
+	r.GET("/realkws/api/v1/offline/asr/task/:taskId", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "child")
+		span.End()
+		c.String(http.StatusOK, RequestID(c))
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/realkws/api/v1/offline/asr/task/task_1", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("X-Request-ID") != "req-123" || w.Body.String() != "req-123" {
+		t.Fatalf("incoming request ID should be kept, got header %q body %q", w.Header().Get("X-Request-ID"), w.Body.String())
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.Name() != "GET /realkws/api/v1/offline/asr/task/:taskId" || server.SpanContext().TraceID().String() != traceID {
+		t.Fatalf("server span should continue the incoming trace, got %s %s", server.Name(), server.SpanContext().TraceID())
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatal("handler span should be a child of the server span")
+	}
+	found := false
+	for _, attr := range server.Attributes() {
+		if attr.Key == "request.id" && attr.Value.AsString() == "req-123" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("server span should carry the request ID")
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestLogger())
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, RequestID(c)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "bad id\nwith newline")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get("X-Request-ID")
+	if id == "" || id == "bad id\nwith newline" || w.Body.String() != id {
+		t.Fatalf("invalid incoming request ID should be replaced, got %q", id)
+	}
+}
@@ -0,0 +1,117 @@
+// Package logging 结构化日志：JSON 输出、运行时可调整级别、按大小轮转的日志文件
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"airecorder/internal/config"
+)
+
+// level 全局日志级别，可在运行时调整
+var level = new(slog.LevelVar)
+
+// ParseLevel 解析级别名称（debug、info、warn、error），为空时为 info
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+}
+
+// SetLevel 调整日志级别
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level 当前日志级别名称
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// Setup 按配置初始化日志：JSON 输出到 stderr，配置了 file 时同时写入轮转的日志文件。
+// 代码中的日志均使用 slog 并显式指定级别；第三方库经标准库 log 输出的内容以 info 级别记录。
+// 级别无效时使用 info，日志文件无法打开时只输出到 stderr，两种情况都返回错误供调用方提示。
+// 返回的 Closer 用于关闭日志文件
+func Setup(cfg config.LoggingConfig) (io.Closer, error) {
+	var errs []error
+	if err := SetLevel(cfg.Level); err != nil {
+		level.Set(slog.LevelInfo)
+		errs = append(errs, err)
+	}
+
+	var w io.Writer = os.Stderr
+	var closer io.Closer = nopCloser{}
+	if cfg.File != "" {
+		f, err := NewRotatingFile(cfg.File, cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to open log file %s, logging to stderr only: %w", cfg.File, err))
+		} else {
+			w = io.MultiWriter(os.Stderr, f)
+			closer = f
+		}
+	}
+
+	install(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+	return closer, errors.Join(errs...)
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// install 设为默认 logger，并将标准库 log 转接到该 logger
+func install(logger *slog.Logger) {
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	log.SetOutput(&stdWriter{logger: logger})
+}
+
+// stdWriter 将标准库 log 的输出转为结构化日志（info 级别），仅作为未迁移到 slog 的输出的兜底
+type stdWriter struct {
+	logger *slog.Logger
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	w.logger.Info(strings.TrimRight(string(p), "\n"), "component", "stdlog")
+	return len(p), nil
+}
+
+type loggerKey struct{}
+
+// WithLogger 将 logger 放入上下文，之后的处理（如离线解码的各个分块）用 FromContext 取出，
+// 日志自动带上请求 ID、任务 ID 等字段
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext 上下文中的 logger，没有时返回默认 logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
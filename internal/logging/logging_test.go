@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	defer level.Set(slog.LevelInfo)
+	if err := SetLevel("WARNING"); err != nil || Level() != "warn" {
+		t.Fatalf("SetLevel(WARNING) = %v, level %s", err, Level())
+	}
+	if err := SetLevel("verbose"); err == nil || Level() != "warn" {
+		t.Fatalf("invalid level should be rejected and keep the current level, got %v %s", err, Level())
+	}
+}
+
+func TestStdLogBridge(t *testing.T) {
+	defer level.Set(slog.LevelInfo)
+	defer slog.SetDefault(slog.Default())
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+
+	var buf bytes.Buffer
+	install(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level})))
+
+	// 标准库 log 的输出一律为 info，不按内容推断级别
+	log.Printf("Warning: request failed %s", "abc")
+	var rec map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["level"] != "INFO" || rec["msg"] != "Warning: request failed abc" || rec["component"] != "stdlog" {
+		t.Fatalf("unexpected record %v", rec)
+	}
+
+	buf.Reset()
+	level.Set(slog.LevelWarn)
+	log.Printf("Created session %s", "abc")
+	if buf.Len() != 0 {
+		t.Fatalf("info message should be filtered at warn level, got %q", buf.String())
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Fatal("expected default logger without a logger in context")
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil)).With("task_id", "t1")
+	FromContext(WithLogger(context.Background(), logger)).Info("Chunk failed")
+	if !strings.Contains(buf.String(), `"task_id":"t1"`) {
+		t.Fatalf("expected task_id field, got %q", buf.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(path, 1, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	line := bytes.Repeat([]byte("x"), 400<<10) // 每行 400KB，1MB 上限下每个文件写两行
+	for i := 0; i < 9; i++ {
+		if _, err := f.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if backups := f.backups(); len(backups) != 2 {
+		t.Fatalf("expected 2 backups after pruning, got %d", len(backups))
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() != int64(len(line)) {
+		t.Fatalf("current file should hold the last line, got %v (%v)", fi.Size(), err)
+	}
+}
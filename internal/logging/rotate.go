@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 轮转文件名中的时间格式
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile 按大小轮转的日志文件：超过 maxSize 时将当前文件重命名为 <name>-<时间><ext>，
+// 并按 maxBackups 与 maxAge 删除旧文件
+type RotatingFile struct {
+	path       string
+	maxSize    int64 // 字节，0 表示不轮转
+	maxBackups int   // 保留的旧文件数，0 表示不限
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+	mu         sync.Mutex
+}
+
+// NewRotatingFile 打开（追加）日志文件，maxSizeMB、maxBackups、maxAgeDays 为 0 时不限制
+func NewRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, fi.Size()
+	return nil
+}
+
+// Write 写入日志，写入后超过大小上限时先轮转
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, fmt.Errorf("log file closed")
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate 重命名当前文件并打开新文件，调用方需持有锁
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(r.path)
+	// 同一毫秒内多次轮转时顺延，避免覆盖已有的旧文件
+	t := time.Now()
+	backup := strings.TrimSuffix(r.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+	for _, err := os.Stat(backup); err == nil; _, err = os.Stat(backup) {
+		t = t.Add(time.Millisecond)
+		backup = strings.TrimSuffix(r.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+	}
+	if err := os.Rename(r.path, backup); err != nil {
+		// 重命名失败时继续写原文件
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// prune 删除超出数量或时间的旧文件
+func (r *RotatingFile) prune() {
+	if r.maxBackups <= 0 && r.maxAge <= 0 {
+		return
+	}
+	backups := r.backups()
+	for i, b := range backups {
+		if (r.maxBackups > 0 && i >= r.maxBackups) || (r.maxAge > 0 && time.Since(b.time) > r.maxAge) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// backups 按时间从新到旧列出旧文件
+func (r *RotatingFile) backups() []backupFile {
+	ext := filepath.Ext(r.path)
+	prefix := filepath.Base(strings.TrimSuffix(r.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return nil
+	}
+
+	var result []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+		if err != nil {
+			continue
+		}
+		result = append(result, backupFile{path: filepath.Join(filepath.Dir(r.path), name), time: t})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].time.After(result[j].time) })
+	return result
+}
+
+// Close 关闭日志文件
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounterVec(r, "test_requests_total", "Requests.", "route", "status")
+	c.Inc("/a", "200")
+	c.Add(2, "/a", "200")
+	c.Inc(`/b"x`, "500")
+	c.Add(-1, "/a", "200")
+	NewGaugeFunc(r, "test_active", "Active.", func() float64 { return 3 })
+	h := NewHistogramVec(r, "test_seconds", "Latency.", []float64{0.1, 1}, "model")
+	h.Observe(0.05, "m")
+	h.Observe(0.5, "m")
+	h.Observe(5, "m")
+
+	var b strings.Builder
+	r.WriteText(&b)
+	want := `# HELP test_active Active.
+# TYPE test_active gauge
+test_active 3
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{route="/a",status="200"} 3
+test_requests_total{route="/b\"x",status="500"} 1
+# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{model="m",le="0.1"} 1
+test_seconds_bucket{model="m",le="1"} 2
+test_seconds_bucket{model="m",le="+Inf"} 3
+test_seconds_sum{model="m"} 5.55
+test_seconds_count{model="m"} 3
+`
+	if b.String() != want {
+		t.Fatalf("unexpected output:\n%s", b.String())
+	}
+}
+
+func TestCounterWithoutLabels(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounterVec(r, "test_failures_total", "Failures.")
+	c.Inc()
+	if c.Value() != 1 {
+		t.Fatalf("expected 1, got %v", c.Value())
+	}
+	var b strings.Builder
+	r.WriteText(&b)
+	if !strings.Contains(b.String(), "\ntest_failures_total 1\n") {
+		t.Fatalf("unexpected output:\n%s", b.String())
+	}
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	r := NewRegistry()
+	NewGaugeFunc(r, "test_gauge", "Gauge.", func() float64 { return 1 })
+	NewGaugeFunc(r, "test_gauge", "Gauge.", func() float64 { return 2 })
+	var b strings.Builder
+	r.WriteText(&b)
+	if strings.Count(b.String(), "test_gauge 2\n") != 1 || strings.Contains(b.String(), "test_gauge 1\n") {
+		t.Fatalf("re-registering should replace the gauge:\n%s", b.String())
+	}
+}
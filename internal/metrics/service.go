@@ -0,0 +1,34 @@
+package metrics
+
+// Default 服务的指标注册表，/metrics 导出其中的全部指标
+var Default = NewRegistry()
+
+// 耗时直方图的桶（秒）
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// 实时率直方图的桶（解码耗时 / 音频时长）
+var rtfBuckets = []float64{0.01, 0.02, 0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1, 1.5, 2, 5}
+
+// 服务指标
+var (
+	HTTPRequests = NewCounterVec(Default, "airecorder_http_requests_total",
+		"HTTP requests by route and status code.", "route", "method", "status")
+
+	TaskWaitSeconds = NewHistogramVec(Default, "airecorder_task_wait_seconds",
+		"Time offline ASR tasks spent in the queue before a worker picked them up.", durationBuckets)
+
+	TaskExecSeconds = NewHistogramVec(Default, "airecorder_task_exec_seconds",
+		"Time workers spent processing offline ASR tasks.", durationBuckets, "status")
+
+	DecodeRTF = NewHistogramVec(Default, "airecorder_decode_rtf",
+		"Decode real-time factor (decode time / audio duration) by model.", rtfBuckets, "type", "model")
+
+	AudioSeconds = NewCounterVec(Default, "airecorder_audio_seconds_total",
+		"Seconds of audio processed.", "type", "model")
+
+	FFmpegFailures = NewCounterVec(Default, "airecorder_ffmpeg_failures_total",
+		"Audio conversions that failed in ffmpeg (reason: unavailable or failed).", "reason")
+
+	SignatureRejections = NewCounterVec(Default, "airecorder_signature_rejections_total",
+		"Requests rejected by signature verification, by reason.", "reason")
+)
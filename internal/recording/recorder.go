@@ -0,0 +1,405 @@
+package recording
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"airecorder/internal/audio"
+	"airecorder/internal/config"
+)
+
+// SampleRate 录音的采样率（实时识别的输入采样率）
+const SampleRate = 16000
+
+const (
+	audioExt      = ".wav"
+	transcriptExt = ".jsonl"
+)
+
+// ErrActive 录音仍在进行
+var ErrActive = fmt.Errorf("recording is still active")
+
+// validID 录音 ID（会话 ID）只允许字母、数字与连字符，防止路径穿越
+var validID = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// Entry 识别结果日志中的一行
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	Offset  float64           `json:"offset"` // 对应录音中的位置（秒）
+	Type    string            `json:"type"`   // start、partial、result、end
+	Text    string            `json:"text,omitempty"`
+	Segment int               `json:"segment,omitempty"`
+	Speaker *int              `json:"speaker,omitempty"`
+	Meta    map[string]string `json:"meta,omitempty"` // start 行记录的会话信息
+}
+
+// Info 一个录音存档
+type Info struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	Duration   float64   `json:"duration"`   // 音频时长（秒）
+	Size       int64     `json:"size"`       // 音频与结果日志的总字节数
+	Transcript bool      `json:"transcript"` // 是否有结果日志
+	Active     bool      `json:"active"`     // 会话仍在录音
+}
+
+// Store 录音存档目录
+type Store struct {
+	cfg    config.RecordingConfig
+	active map[string]*Recording
+	mu     sync.Mutex
+	clean  sync.Mutex // 串行执行保留策略
+	stop   chan struct{}
+}
+
+// NewStore 创建录音存档，未启用或目录无法创建时返回 nil
+func NewStore(cfg *config.Config) *Store {
+	rc := cfg.Recording
+	if !rc.Enabled {
+		return nil
+	}
+	if rc.Dir == "" {
+		rc.Dir = "./recordings"
+	}
+	if err := os.MkdirAll(rc.Dir, 0o750); err != nil {
+		slog.Warn("Failed to create recording dir, recording disabled", "dir", rc.Dir, "error", err)
+		return nil
+	}
+	if rc.CleanupInterval <= 0 {
+		rc.CleanupInterval = 60
+	}
+
+	s := &Store{cfg: rc, active: make(map[string]*Recording), stop: make(chan struct{})}
+	go s.cleanupLoop()
+	slog.Info("Session recording enabled", "dir", rc.Dir, "max_age_days", rc.MaxAgeDays, "max_total_mb", rc.MaxTotalMB)
+	return s
+}
+
+// RecordsKey 该 API Key 的会话是否总是录音
+func (s *Store) RecordsKey(apiKey string) bool {
+	if s == nil || apiKey == "" {
+		return false
+	}
+	for _, k := range s.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(apiKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Start 开始录制会话，meta 写入结果日志的第一行。同一会话已在录制时返回原录音
+func (s *Store) Start(id string, meta map[string]string) (*Recording, error) {
+	if s == nil {
+		return nil, fmt.Errorf("recording is not enabled")
+	}
+	if !validID.MatchString(id) {
+		return nil, fmt.Errorf("invalid recording id %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.active[id]; ok {
+		return r, nil
+	}
+
+	r, err := newRecording(s, id, meta)
+	if err != nil {
+		return nil, err
+	}
+	s.active[id] = r
+	slog.Info("Recording session", "session_id", id)
+	return r, nil
+}
+
+// finished 录音结束，移出活跃列表并在后台执行保留策略
+func (s *Store) finished(id string) {
+	s.mu.Lock()
+	delete(s.active, id)
+	s.mu.Unlock()
+	go s.Cleanup()
+}
+
+// path 录音文件路径
+func (s *Store) path(id, ext string) string {
+	return filepath.Join(s.cfg.Dir, id+ext)
+}
+
+// AudioPath 录音 WAV 文件路径，ID 非法或文件不存在时返回错误
+func (s *Store) AudioPath(id string) (string, error) {
+	return s.existing(id, audioExt)
+}
+
+// TranscriptPath 结果日志 JSONL 文件路径，ID 非法或文件不存在时返回错误
+func (s *Store) TranscriptPath(id string) (string, error) {
+	return s.existing(id, transcriptExt)
+}
+
+func (s *Store) existing(id, ext string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid recording id %q", id)
+	}
+	p := s.path(id, ext)
+	if _, err := os.Stat(p); err != nil {
+		return "", fmt.Errorf("recording %s not found", id)
+	}
+	return p, nil
+}
+
+// List 按开始时间从新到旧列出录音
+func (s *Store) List() ([]Info, error) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byID := make(map[string]*Info)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		id := strings.TrimSuffix(e.Name(), ext)
+		if e.IsDir() || (ext != audioExt && ext != transcriptExt) || !validID.MatchString(id) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		info, ok := byID[id]
+		if !ok {
+			info = &Info{ID: id, StartedAt: fi.ModTime()}
+			_, info.Active = s.active[id]
+			byID[id] = info
+		}
+		info.Size += fi.Size()
+		if ext == audioExt {
+			if fi.Size() > 44 {
+				info.Duration = float64(fi.Size()-44) / 2 / SampleRate
+			}
+		} else {
+			info.Transcript = true
+			// 开始时间取结果日志第一行（start）的时间
+			if start, err := readStartTime(s.path(id, transcriptExt)); err == nil {
+				info.StartedAt = start
+			}
+		}
+	}
+
+	result := make([]Info, 0, len(byID))
+	for _, info := range byID {
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.After(result[j].StartedAt) })
+	return result, nil
+}
+
+// readStartTime 读取结果日志 start 行的时间
+func readStartTime(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	var first Entry
+	if err := json.NewDecoder(f).Decode(&first); err != nil {
+		return time.Time{}, err
+	}
+	return first.Time, nil
+}
+
+// Delete 删除录音，正在录制的会话不能删除
+func (s *Store) Delete(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid recording id %q", id)
+	}
+	s.mu.Lock()
+	_, active := s.active[id]
+	s.mu.Unlock()
+	if active {
+		return ErrActive
+	}
+	return s.remove(id)
+}
+
+func (s *Store) remove(id string) error {
+	found := false
+	for _, ext := range []string{audioExt, transcriptExt} {
+		err := os.Remove(s.path(id, ext))
+		if err == nil {
+			found = true
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("recording %s not found", id)
+	}
+	return nil
+}
+
+// Cleanup 执行保留策略：删除超过 max_age_days 的录音，总大小超过 max_total_mb 时从最早的录音开始删除。
+// 正在录制的会话不删除
+func (s *Store) Cleanup() {
+	s.clean.Lock()
+	defer s.clean.Unlock()
+
+	list, err := s.List()
+	if err != nil {
+		slog.Warn("Failed to list recordings", "error", err)
+		return
+	}
+
+	var total int64
+	for _, info := range list {
+		total += info.Size
+	}
+	maxAge := time.Duration(s.cfg.MaxAgeDays) * 24 * time.Hour
+	maxTotal := int64(s.cfg.MaxTotalMB) << 20
+
+	// list 从新到旧，从末尾（最早）开始删除
+	for i := len(list) - 1; i >= 0; i-- {
+		info := list[i]
+		if info.Active {
+			continue
+		}
+		expired := maxAge > 0 && time.Since(info.StartedAt) > maxAge
+		oversize := maxTotal > 0 && total > maxTotal
+		if !expired && !oversize {
+			continue
+		}
+		if err := s.remove(info.ID); err != nil {
+			slog.Warn("Failed to delete recording", "session_id", info.ID, "error", err)
+			continue
+		}
+		total -= info.Size
+		slog.Info("Deleted recording by retention policy", "session_id", info.ID)
+	}
+}
+
+func (s *Store) cleanupLoop() {
+	ticker := time.NewTicker(time.Duration(s.cfg.CleanupInterval) * time.Minute)
+	defer ticker.Stop()
+	s.Cleanup()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Cleanup()
+		}
+	}
+}
+
+// Close 停止保留策略检查
+func (s *Store) Close() {
+	if s == nil {
+		return
+	}
+	close(s.stop)
+}
+
+// Recording 一个会话的录音：音频追加写入 WAV，识别结果逐行写入 JSONL
+type Recording struct {
+	ID      string
+	store   *Store
+	wav     *os.File
+	log     *os.File
+	samples int64
+	failed  bool
+	mu      sync.Mutex
+}
+
+func newRecording(s *Store, id string, meta map[string]string) (*Recording, error) {
+	wav, err := os.OpenFile(s.path(id, audioExt), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wav.Write(audio.WAVHeader(0, SampleRate)); err != nil {
+		wav.Close()
+		return nil, err
+	}
+	logFile, err := os.OpenFile(s.path(id, transcriptExt), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		wav.Close()
+		return nil, err
+	}
+
+	r := &Recording{ID: id, store: s, wav: wav, log: logFile}
+	r.writeEntry(Entry{Type: "start", Meta: meta})
+	return r, nil
+}
+
+// WriteAudio 追加音频
+func (r *Recording) WriteAudio(samples []float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed || r.wav == nil {
+		return
+	}
+	if _, err := r.wav.Write(audio.EncodePCM16(samples)); err != nil {
+		r.fail(err)
+		return
+	}
+	r.samples += int64(len(samples))
+}
+
+// WriteEntry 追加一行识别结果，时间与录音位置由录音填写
+func (r *Recording) WriteEntry(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeEntry(e)
+}
+
+func (r *Recording) writeEntry(e Entry) {
+	if r.failed || r.log == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.Offset = float64(r.samples) / SampleRate
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if _, err := r.log.Write(append(line, '\n')); err != nil {
+		r.fail(err)
+	}
+}
+
+// fail 写入失败后停止录音，避免每次音频都报错
+func (r *Recording) fail(err error) {
+	r.failed = true
+	slog.Warn("Recording stopped after write error", "session_id", r.ID, "error", err)
+}
+
+// Close 结束录音：写入 end 行并补全 WAV 文件头中的长度，可重复调用
+func (r *Recording) Close() {
+	r.mu.Lock()
+	if r.wav == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.writeEntry(Entry{Type: "end"})
+	dataSize := int(r.samples * 2)
+	if _, err := r.wav.WriteAt(audio.WAVHeader(dataSize, SampleRate), 0); err != nil {
+		slog.Warn("Failed to finalize recording", "session_id", r.ID, "error", err)
+	}
+	r.wav.Close()
+	r.log.Close()
+	r.wav, r.log = nil, nil
+	r.mu.Unlock()
+
+	slog.Info("Recording finished", "session_id", r.ID, "duration_sec", float64(r.samples)/SampleRate)
+	r.store.finished(r.ID)
+}
@@ -0,0 +1,126 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"airecorder/internal/config"
+)
+
+func newTestStore(t *testing.T, rc config.RecordingConfig) *Store {
+	rc.Enabled = true
+	rc.Dir = t.TempDir()
+	s := NewStore(&config.Config{Recording: rc})
+	if s == nil {
+		t.Fatal("store should be created")
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRecordingWritesWAVAndTranscript(t *testing.T) {
+	s := newTestStore(t, config.RecordingConfig{})
+
+	r, err := s.Start("abc-123", map[string]string{"model": "zipformer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := s.Start("abc-123", nil); again != r {
+		t.Fatal("starting an active recording should return it")
+	}
+	r.WriteAudio(make([]float32, SampleRate))
+	speaker := 1
+	r.WriteEntry(Entry{Type: "result", Text: "你好。", Segment: 1, Speaker: &speaker})
+
+	if err := s.Delete("abc-123"); !errors.Is(err, ErrActive) {
+		t.Fatalf("deleting an active recording should fail with ErrActive, got %v", err)
+	}
+	r.Close()
+	r.Close()
+
+	wavPath, err := s.AudioPath("abc-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wav, _ := os.ReadFile(wavPath)
+	if len(wav) != 44+SampleRate*2 || binary.LittleEndian.Uint32(wav[40:44]) != SampleRate*2 {
+		t.Fatalf("WAV header should record %d data bytes, file has %d bytes", SampleRate*2, len(wav))
+	}
+
+	logPath, _ := s.TranscriptPath("abc-123")
+	f, _ := os.Open(logPath)
+	defer f.Close()
+	var entries []Entry
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 3 || entries[0].Meta["model"] != "zipformer" || entries[1].Offset != 1 || entries[2].Type != "end" {
+		t.Fatalf("unexpected transcript entries: %+v", entries)
+	}
+
+	list, err := s.List()
+	if err != nil || len(list) != 1 || list[0].Duration != 1 || !list[0].Transcript || list[0].Active {
+		t.Fatalf("unexpected list %+v (%v)", list, err)
+	}
+	if !list[0].StartedAt.Equal(entries[0].Time) {
+		t.Fatalf("started_at should come from the start entry")
+	}
+
+	if err := s.Delete("abc-123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AudioPath("abc-123"); err == nil {
+		t.Fatal("deleted recording should not be found")
+	}
+}
+
+func TestRecordingRejectsInvalidID(t *testing.T) {
+	s := newTestStore(t, config.RecordingConfig{})
+	for _, id := range []string{"../etc/passwd", "a/b", ""} {
+		if _, err := s.Start(id, nil); err == nil {
+			t.Fatalf("Start(%q) should fail", id)
+		}
+		if _, err := s.AudioPath(id); err == nil {
+			t.Fatalf("AudioPath(%q) should fail", id)
+		}
+	}
+}
+
+func TestCleanupTotalSize(t *testing.T) {
+	s := newTestStore(t, config.RecordingConfig{MaxTotalMB: 1})
+
+	// 两段各约 0.6MB 的录音，超出 1MB 时删除较早的一段，正在录制的不删除
+	old, _ := s.Start("old", nil)
+	old.WriteAudio(make([]float32, 300000))
+	old.Close()
+	active, _ := s.Start("active", nil)
+	active.WriteAudio(make([]float32, 300000))
+
+	s.Cleanup()
+	if _, err := s.AudioPath("old"); err == nil {
+		t.Fatal("oldest recording should be deleted when over the size limit")
+	}
+	if _, err := s.AudioPath("active"); err != nil {
+		t.Fatal("active recording should be kept")
+	}
+	active.Close()
+}
+
+func TestRecordsKey(t *testing.T) {
+	s := newTestStore(t, config.RecordingConfig{APIKeys: []string{"customer-a"}})
+	if !s.RecordsKey("customer-a") || s.RecordsKey("customer-b") || s.RecordsKey("") {
+		t.Fatal("RecordsKey should match configured keys only")
+	}
+	var disabled *Store
+	if disabled.RecordsKey("customer-a") {
+		t.Fatal("nil store should not record")
+	}
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"airecorder/internal/asr"
+	"airecorder/internal/config"
+	"airecorder/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 可独立降级的识别能力
+const (
+	capabilityStreaming   = "streaming_asr"
+	capabilityOffline     = "offline_asr"
+	capabilityDiarization = "diarization"
+)
+
+// defaultLoadRetryInterval 未配置 health.load_retry_sec 时加载失败能力的重试间隔
+const defaultLoadRetryInterval = time.Minute
+
+// capability 一项识别能力。加载失败时服务照常启动，该能力的接口返回 503，
+// 并按 health.load_retry_sec 定期重试或由管理员手动重试
+type capability struct {
+	name   string
+	load   func(retry bool) error
+	loadMu sync.Mutex // 串行化加载，关闭时持有以阻止新的加载
+
+	mu          sync.RWMutex
+	loaded      bool
+	err         error
+	attempts    int
+	lastAttempt time.Time
+	nextRetry   time.Time
+}
+
+// available 返回能力不可用的原因，可用时返回 nil
+func (c *capability) available() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.loaded {
+		return nil
+	}
+	if c.err == nil {
+		return fmt.Errorf("not loaded")
+	}
+	return c.err
+}
+
+// tryLoad 加载尚未就绪的能力，已就绪时直接返回。interval 大于 0 时记录下次自动重试时间
+func (c *capability) tryLoad(retry bool, interval time.Duration) error {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	if c.available() == nil {
+		return nil
+	}
+
+	err := c.load(retry)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	c.lastAttempt = now
+	c.err = err
+	c.loaded = err == nil
+	c.nextRetry = time.Time{}
+	if err != nil && interval > 0 {
+		c.nextRetry = now.Add(interval)
+	}
+	return err
+}
+
+func (c *capability) status() handler.CapabilityStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status := handler.CapabilityStatus{Status: handler.CapabilityReady, Attempts: c.attempts}
+	if !c.loaded {
+		status.Status = handler.CapabilityUnavailable
+		if c.err != nil {
+			status.Error = c.err.Error()
+		}
+	}
+	if !c.lastAttempt.IsZero() {
+		t := c.lastAttempt
+		status.LastAttempt = &t
+	}
+	if !c.nextRetry.IsZero() {
+		t := c.nextRetry
+		status.NextRetry = &t
+	}
+	return status
+}
+
+// loadRetryInterval 自动重试间隔，0 表示关闭自动重试
+func (s *Server) loadRetryInterval() time.Duration {
+	switch sec := s.config.Health.LoadRetrySec; {
+	case sec < 0:
+		return 0
+	case sec == 0:
+		return defaultLoadRetryInterval
+	default:
+		return time.Duration(sec) * time.Second
+	}
+}
+
+// addCapability 登记并加载一项能力，失败时记录警告，服务以降级模式继续启动
+func (s *Server) addCapability(name string, load func(retry bool) error) {
+	c := &capability{name: name, load: load}
+	s.capabilities = append(s.capabilities, c)
+	if err := c.tryLoad(false, s.loadRetryInterval()); err != nil {
+		slog.Warn("Capability unavailable, running in degraded mode", "capability", name, "error", err)
+	}
+}
+
+// capability 按名称查找已启用的能力，未启用时返回 nil
+func (s *Server) capability(name string) *capability {
+	for _, c := range s.capabilities {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// capabilityStatus 各已启用能力的加载状态
+func (s *Server) capabilityStatus() map[string]handler.CapabilityStatus {
+	statuses := make(map[string]handler.CapabilityStatus, len(s.capabilities))
+	for _, c := range s.capabilities {
+		statuses[c.name] = c.status()
+	}
+	return statuses
+}
+
+// retryCapability 立即重试加载一项能力（管理接口），返回重试后的状态
+func (s *Server) retryCapability(name string) (handler.CapabilityStatus, bool) {
+	c := s.capability(name)
+	if c == nil {
+		return handler.CapabilityStatus{}, false
+	}
+	if err := c.tryLoad(true, s.loadRetryInterval()); err != nil {
+		slog.Error("Retry loading capability failed", "capability", name, "error", err)
+	} else {
+		slog.Info("Capability is available", "capability", name)
+		s.refreshSelfTest()
+	}
+	return c.status(), true
+}
+
+// require 能力不可用时以 503 拒绝请求
+func (s *Server) require(name string) []gin.HandlerFunc {
+	c := s.capability(name)
+	if c == nil {
+		return nil
+	}
+	return []gin.HandlerFunc{handler.RequireAvailable(name, c.available)}
+}
+
+// retryLoop 定期重试加载不可用的能力
+func (s *Server) retryLoop() {
+	defer s.wg.Done()
+
+	interval := s.loadRetryInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, c := range s.capabilities {
+				if c.available() == nil {
+					continue
+				}
+				if err := c.tryLoad(true, interval); err != nil {
+					slog.Error("Retry loading capability failed", "capability", c.name, "error", err)
+				} else {
+					slog.Info("Capability is available, leaving degraded mode", "capability", c.name)
+					s.refreshSelfTest()
+				}
+			}
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+// stopLoading 等待进行中的加载结束并阻止之后的加载，关闭时在释放管理器前调用
+func (s *Server) stopLoading() {
+	for _, c := range s.capabilities {
+		c.loadMu.Lock()
+	}
+}
+
+// reloadFailedModels 重试加载时先重新加载该类型中加载失败的模型
+func (s *Server) reloadFailedModels(modelType string) {
+	for _, name := range s.models.FailedModels(modelType) {
+		if err := s.reloadModels(modelType, name); err != nil {
+			slog.Error("Reload model failed", "type", modelType, "model", name, "error", err)
+		}
+	}
+}
+
+func (s *Server) loadStreaming(retry bool) error {
+	if retry {
+		s.reloadFailedModels(config.ModelTypeStreaming)
+	}
+	mgr, err := asr.NewStreamingASRManagerWithRegistry(s.effectiveConfig(), s.models)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.streamingASR = mgr
+	s.mu.Unlock()
+	return nil
+}
+
+// loadOffline 加载离线识别并创建任务队列（用于处理长时间音频）
+func (s *Server) loadOffline(retry bool) error {
+	if retry {
+		s.reloadFailedModels(config.ModelTypeOffline)
+	}
+	cfg := s.effectiveConfig()
+	mgr, err := asr.NewOfflineASRManagerWithRegistry(cfg, s.models)
+	if err != nil {
+		return err
+	}
+	queue := asr.NewTaskQueue(cfg, mgr)
+	s.mu.Lock()
+	s.offlineASR = mgr
+	s.taskQueue = queue
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) loadDiarization(bool) error {
+	mgr, err := asr.NewDiarizationManager(s.config)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.diarizationMgr = mgr
+	s.mu.Unlock()
+	return nil
+}
+
+// streaming 实时识别管理器，未启用或未加载时为 nil
+func (s *Server) streaming() *asr.StreamingASRManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.streamingASR
+}
+
+// offline 离线识别管理器，未启用或未加载时为 nil
+func (s *Server) offline() *asr.OfflineASRManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.offlineASR
+}
+
+// diarization 说话者分离管理器，未启用或未加载时为 nil
+func (s *Server) diarization() *asr.DiarizationManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.diarizationMgr
+}
+
+// queue 离线任务队列，随离线识别加载
+func (s *Server) queue() *asr.TaskQueue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.taskQueue
+}
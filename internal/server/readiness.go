@@ -0,0 +1,200 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"airecorder/internal/audio"
+	"airecorder/internal/config"
+	"airecorder/internal/handler"
+)
+
+// defaultQueueThreshold 未配置 health.queue_threshold 时，排队任务达到队列容量的该比例视为饱和
+const defaultQueueThreshold = 0.9
+
+// readinessChecks /readyz 的组件检查，只包含已启用的组件
+func (s *Server) readinessChecks() []handler.ReadinessCheck {
+	checks := []handler.ReadinessCheck{{Name: "server", Check: s.checkDraining}}
+
+	if s.streamingEnabled() {
+		checks = append(checks, handler.ReadinessCheck{Name: "streaming_asr", Check: s.checkStreaming})
+	}
+	if s.offlineEnabled() {
+		checks = append(checks, handler.ReadinessCheck{Name: "offline_asr", Check: s.checkOffline})
+		checks = append(checks, handler.ReadinessCheck{Name: "task_queue", Check: s.checkTaskQueue})
+	}
+	if s.config.SpeakerDiarization.Enabled {
+		checks = append(checks, handler.ReadinessCheck{Name: "diarization", Check: s.checkDiarization})
+	}
+	// 上传的非 WAV 音频需要 FFmpeg 转换
+	if (s.offlineEnabled() || s.config.SpeakerDiarization.Enabled) && !s.config.Health.WAVOnly {
+		checks = append(checks, handler.ReadinessCheck{Name: "ffmpeg", Check: checkFFmpeg})
+	}
+	if s.config.Health.StartupSelfTest {
+		checks = append(checks, handler.ReadinessCheck{Name: "self_test", Check: s.checkSelfTest})
+	}
+	return checks
+}
+
+func ok(details map[string]interface{}) handler.ComponentStatus {
+	return handler.ComponentStatus{Status: handler.ComponentOK, Details: details}
+}
+
+func fail(format string, args ...interface{}) handler.ComponentStatus {
+	return handler.ComponentStatus{Status: handler.ComponentFail, Message: fmt.Sprintf(format, args...)}
+}
+
+// checkDraining 关闭中或已启用的识别能力全部不可用时未就绪
+func (s *Server) checkDraining() handler.ComponentStatus {
+	if s.draining.Load() {
+		return fail("server is shutting down")
+	}
+	if len(s.capabilities) == 0 {
+		return ok(nil)
+	}
+	for _, c := range s.capabilities {
+		if c.available() == nil {
+			return ok(nil)
+		}
+	}
+	return fail("no recognition capability available")
+}
+
+// degraded 能力加载失败时的组件状态，不影响其他能力的就绪
+func degraded(c *capability) handler.ComponentStatus {
+	status := c.status()
+	details := map[string]interface{}{"attempts": status.Attempts}
+	if status.NextRetry != nil {
+		details["next_retry"] = status.NextRetry
+	}
+	return handler.ComponentStatus{Status: handler.ComponentDegraded, Message: status.Error, Details: details}
+}
+
+// checkModels 默认模型可用时正常，加载失败的其他模型只列在详情中
+func (s *Server) checkModels(modelType string, details map[string]interface{}) handler.ComponentStatus {
+	name, err := s.models.ResolveName(modelType, "")
+	if err != nil {
+		return fail("default model unavailable: %v", err)
+	}
+	details["default_model"] = name
+
+	if failed := s.models.FailedModels(modelType); len(failed) > 0 {
+		details["failed_models"] = failed
+	}
+	return ok(details)
+}
+
+func (s *Server) checkStreaming() handler.ComponentStatus {
+	if c := s.capability(capabilityStreaming); c.available() != nil {
+		return degraded(c)
+	}
+	mgr := s.streaming()
+	return s.checkModels(config.ModelTypeStreaming, map[string]interface{}{
+		"active_sessions": mgr.ActiveSessions(),
+		"max_sessions":    mgr.MaxSessions(),
+	})
+}
+
+func (s *Server) checkOffline() handler.ComponentStatus {
+	if c := s.capability(capabilityOffline); c.available() != nil {
+		return degraded(c)
+	}
+	return s.checkModels(config.ModelTypeOffline, map[string]interface{}{})
+}
+
+func (s *Server) checkTaskQueue() handler.ComponentStatus {
+	queue := s.queue()
+	if queue == nil {
+		return handler.ComponentStatus{Status: handler.ComponentDegraded, Message: "offline ASR unavailable"}
+	}
+	threshold := s.config.Health.QueueThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultQueueThreshold
+	}
+	depth, capacity := queue.QueueDepth(), queue.Capacity()
+	limit := int(threshold * float64(capacity))
+	status := ok(map[string]interface{}{
+		"queue_length": depth,
+		"capacity":     capacity,
+		"threshold":    limit,
+		"processing":   queue.ProcessingTasks(),
+	})
+	if depth >= limit {
+		status.Status = handler.ComponentFail
+		status.Message = "task queue saturated"
+	}
+	return status
+}
+
+func (s *Server) checkDiarization() handler.ComponentStatus {
+	if c := s.capability(capabilityDiarization); c.available() != nil {
+		return degraded(c)
+	}
+	return ok(nil)
+}
+
+func checkFFmpeg() handler.ComponentStatus {
+	if !audio.FFmpegAvailable() {
+		return fail("ffmpeg not found in PATH, only WAV uploads can be decoded (set health.wav_only if intended)")
+	}
+	return ok(nil)
+}
+
+func (s *Server) checkSelfTest() handler.ComponentStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.selfTest
+}
+
+// refreshSelfTest 启用 health.startup_self_test 时重新自检：启动时、能力重试加载成功后
+// 以及模型热加载后执行，之后才可用的模型也会被检验，模型恢复后 /readyz 随之恢复
+func (s *Server) refreshSelfTest() {
+	if s.config.Health.StartupSelfTest {
+		s.runSelfTest()
+	}
+}
+
+// runSelfTest 用各类型的默认模型解码一小段静音，结果由 /readyz 的 self_test 组件返回。
+// 关闭中不再自检，避免使用正在释放的管理器
+func (s *Server) runSelfTest() {
+	s.selfTestMu.Lock()
+	defer s.selfTestMu.Unlock()
+	if s.draining.Load() {
+		return
+	}
+
+	start := time.Now()
+	details := map[string]interface{}{}
+	failed := false
+	record := func(name string, err error) {
+		if err != nil {
+			failed = true
+			details[name] = err.Error()
+			slog.Error("Self test failed", "component", name, "error", err)
+			return
+		}
+		details[name] = handler.ComponentOK
+	}
+
+	// 加载失败的能力已在对应组件中报告为降级，不参与自检
+	if mgr := s.streaming(); mgr != nil {
+		record("streaming_asr", mgr.SelfTest())
+	}
+	if mgr := s.offline(); mgr != nil {
+		record("offline_asr", mgr.SelfTest())
+	}
+	details["duration_ms"] = time.Since(start).Milliseconds()
+
+	result := ok(details)
+	if failed {
+		result.Status = handler.ComponentFail
+		result.Message = "synthetic decode failed"
+	} else {
+		slog.Info("Self test passed", "duration_ms", time.Since(start).Milliseconds())
+	}
+
+	s.mu.Lock()
+	s.selfTest = result
+	s.mu.Unlock()
+}
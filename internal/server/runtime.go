@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+
+	"airecorder/internal/asr"
+	"airecorder/internal/config"
+)
+
+// effectiveConfig 应用运行时修改后的配置副本，用于重新加载的管理器
+func (s *Server) effectiveConfig() *config.Config {
+	s.mu.RLock()
+	runtime := s.runtime
+	s.mu.RUnlock()
+
+	cfg := *s.config
+	cfg.Models = append([]config.ModelEntryConfig(nil), s.config.Models...)
+	cfg.ApplyRuntime(runtime)
+	return &cfg
+}
+
+// maxSkewSeconds 签名允许的时间偏差（可运行中调整）
+func (s *Server) maxSkewSeconds() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return *s.runtime.MaxSkewSeconds
+}
+
+// runtimeSettings 当前生效的运行时配置，队列参数取自任务队列
+func (s *Server) runtimeSettings() config.RuntimeSettings {
+	s.mu.RLock()
+	runtime, queue := s.runtime, s.taskQueue
+	s.mu.RUnlock()
+
+	if queue != nil {
+		capacity, workers := queue.Capacity(), queue.Workers()
+		runtime.QueueSize, runtime.WorkerPoolSize = &capacity, &workers
+	}
+	return runtime
+}
+
+// applyRuntime 修改运行时配置并立即生效，返回实际变更的字段。
+// 可能失败的修改（任务队列、重新加载实时模型）先执行，失败时恢复原值，不做任何修改。
+// 端点规则用内存中的配置重新加载实时模型（不重新读取配置文件），全部加载成功后才一起切换，
+// 在途会话继续使用旧规则
+func (s *Server) applyRuntime(patch config.RuntimeSettings) ([]config.RuntimeChange, config.RuntimeSettings, error) {
+	s.runtimeMu.Lock()
+	defer s.runtimeMu.Unlock()
+
+	previous := s.runtimeSettings()
+	queue := s.queue()
+	if patch.QueueSize != nil && queue != nil && *patch.QueueSize > queue.MaxCapacity() {
+		return nil, previous, fmt.Errorf("%w: queue_size must not exceed %d", config.ErrInvalidRuntimeSetting, queue.MaxCapacity())
+	}
+
+	s.mu.Lock()
+	old := s.runtime
+	s.runtime = old.Merge(patch)
+	s.mu.Unlock()
+
+	if err := s.applyQueue(queue, patch, previous); err != nil {
+		s.setRuntime(old)
+		return nil, previous, err
+	}
+	if patch.EndpointChanged() && s.streamingEnabled() {
+		if err := s.models.ReloadAll(s.effectiveConfig(), config.ModelTypeStreaming, ""); err != nil {
+			s.setRuntime(old)
+			s.applyQueue(queue, previous, previous)
+			return nil, previous, fmt.Errorf("failed to reload streaming models: %w", err)
+		}
+	}
+
+	if mgr := s.streaming(); mgr != nil && patch.MaxStreamingSessions != nil {
+		mgr.SetMaxSessions(*patch.MaxStreamingSessions)
+	}
+	if mgr := s.offline(); mgr != nil && (patch.ChunkDurationSec != nil || patch.MaxConcurrency != nil) {
+		next := s.runtimeSettings()
+		mgr.SetChunking(*next.ChunkDurationSec, *next.MaxConcurrency)
+	}
+
+	current := s.runtimeSettings()
+	return previous.Diff(current), current, nil
+}
+
+// applyQueue 调整任务队列的排队上限与 worker 数，失败时恢复为 previous 中的取值
+func (s *Server) applyQueue(queue *asr.TaskQueue, patch, previous config.RuntimeSettings) error {
+	if queue == nil {
+		return nil
+	}
+	if patch.QueueSize != nil {
+		if err := queue.SetCapacity(*patch.QueueSize); err != nil {
+			return err
+		}
+	}
+	if patch.WorkerPoolSize != nil {
+		if err := queue.SetWorkers(*patch.WorkerPoolSize); err != nil {
+			if patch.QueueSize != nil {
+				queue.SetCapacity(*previous.QueueSize)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) setRuntime(runtime config.RuntimeSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runtime = runtime
+}
@@ -3,14 +3,21 @@ package server
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"airecorder/internal/asr"
 	"airecorder/internal/config"
 	"airecorder/internal/handler"
+	"airecorder/internal/metrics"
+	"airecorder/internal/recording"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -19,15 +26,29 @@ import (
 type Server struct {
 	config         *config.Config
 	router         *gin.Engine
+	models         *asr.ModelRegistry
+	mu             sync.RWMutex // 保护各管理器，加载失败的能力重试成功后才赋值
 	streamingASR   *asr.StreamingASRManager
 	offlineASR     *asr.OfflineASRManager
 	diarizationMgr *asr.DiarizationManager
 	taskQueue      *asr.TaskQueue
+	capabilities   []*capability          // 已启用的识别能力及加载状态
+	runtime        config.RuntimeSettings // 运行时可修改的配置（由 mu 保护，修改由 runtimeMu 串行化）
+	runtimeMu      sync.Mutex
 	httpServer     *http.Server
+	metricsServer  *http.Server // 指标接口的独立监听（metrics.listen）
+	metricsEnabled bool
 	shutdown       chan struct{}
+	shutdownOnce   sync.Once
+	draining       atomic.Bool             // 正在关闭：健康检查失败，拒绝新的识别请求
+	selfTest       handler.ComponentStatus // 最近一次自检结果（health.startup_self_test，由 mu 保护）
+	selfTestMu     sync.Mutex              // 串行化自检，避免较早的结果覆盖较新的结果
 	wg             sync.WaitGroup
 }
 
+// defaultShutdownTimeout 未配置 server.shutdown_timeout 时等待连接与任务结束的时间
+const defaultShutdownTimeout = 30 * time.Second
+
 func NewServer(cfg *config.Config) *Server {
 	// 设置 Gin 模式
 	if cfg.Logging.Level == "debug" {
@@ -36,7 +57,9 @@ func NewServer(cfg *config.Config) *Server {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.Default()
+	// 访问日志由 RequestLogger 以结构化格式输出
+	router := gin.New()
+	router.Use(gin.Recovery(), handler.RequestLogger())
 
 	// 设置上传文件大小限制（默认50MB）
 	maxFileSizeMB := cfg.OfflineASR.MaxFileSizeMB
@@ -49,98 +72,191 @@ func NewServer(cfg *config.Config) *Server {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Timestamp", "X-Signature", "x-timestamp", "x-signature"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Timestamp", "X-Signature", "x-timestamp", "x-signature", "X-Request-ID", "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// 初始化服务器
 	srv := &Server{
-		config:   cfg,
-		router:   router,
-		shutdown: make(chan struct{}),
+		config:         cfg,
+		router:         router,
+		shutdown:       make(chan struct{}),
+		metricsEnabled: metricsEnabled(cfg),
 	}
 
-	// 初始化 ASR 管理器
-	if cfg.StreamingASR.Enabled {
-		srv.streamingASR = asr.NewStreamingASRManager(cfg)
+	// 请求计数与追踪需在注册路由前启用
+	if srv.metricsEnabled {
+		router.Use(handler.MetricsMiddleware())
+	}
+	if cfg.Tracing.Enabled {
+		router.Use(handler.TracingMiddleware())
 	}
 
-	if cfg.OfflineASR.Enabled {
-		srv.offlineASR = asr.NewOfflineASRManager(cfg)
+	// 加载模型注册表（主配置 + models 列表）
+	srv.models = asr.NewModelRegistry(cfg)
+	srv.runtime = cfg.RuntimeSettings()
+
+	// 初始化 ASR 管理器，加载失败的能力停用并定期重试，其他能力照常提供服务
+	if srv.streamingEnabled() {
+		srv.addCapability(capabilityStreaming, srv.loadStreaming)
 	}
 
-	if cfg.SpeakerDiarization.Enabled {
-		srv.diarizationMgr = asr.NewDiarizationManager(cfg)
+	if srv.offlineEnabled() {
+		srv.addCapability(capabilityOffline, srv.loadOffline)
 	}
 
-	// 初始化任务队列（用于处理长时间音频）
-	if cfg.OfflineASR.Enabled {
-		srv.taskQueue = asr.NewTaskQueue(cfg, srv.offlineASR)
+	if cfg.SpeakerDiarization.Enabled {
+		srv.addCapability(capabilityDiarization, srv.loadDiarization)
 	}
 
+	srv.refreshSelfTest()
+
 	// 设置路由
 	srv.setupRoutes()
+	srv.setupMetrics()
 
 	return srv
 }
 
+// metricsEnabled 指标接口需配置 token 或独立监听地址，避免在公开端口上无鉴权暴露
+func metricsEnabled(cfg *config.Config) bool {
+	if !cfg.Metrics.Enabled {
+		return false
+	}
+	if cfg.Metrics.Token == "" && cfg.Metrics.Listen == "" {
+		slog.Warn("Metrics enabled without token or listen address, /metrics disabled")
+		return false
+	}
+	return true
+}
+
+// setupMetrics 注册运行状态指标并挂载 /metrics
+func (s *Server) setupMetrics() {
+	if !s.metricsEnabled {
+		return
+	}
+
+	// 能力尚未加载时指标为 0
+	if s.streamingEnabled() {
+		metrics.NewGaugeFunc(metrics.Default, "airecorder_streaming_sessions_active",
+			"Streaming sessions currently open, including sessions waiting for resume.",
+			func() float64 {
+				if mgr := s.streaming(); mgr != nil {
+					return float64(mgr.ActiveSessions())
+				}
+				return 0
+			})
+	}
+	if s.offlineEnabled() {
+		metrics.NewGaugeFunc(metrics.Default, "airecorder_task_queue_depth",
+			"Offline ASR tasks waiting in the queue.",
+			func() float64 {
+				if q := s.queue(); q != nil {
+					return float64(q.QueueDepth())
+				}
+				return 0
+			})
+		metrics.NewGaugeFunc(metrics.Default, "airecorder_tasks_processing",
+			"Offline ASR tasks being processed by workers.",
+			func() float64 {
+				if q := s.queue(); q != nil {
+					return float64(q.ProcessingTasks())
+				}
+				return 0
+			})
+	}
+
+	handle := handler.HandleMetrics(s.config.Metrics.Token)
+	if s.config.Metrics.Listen == "" {
+		s.router.GET("/metrics", handle)
+		slog.Info("Metrics enabled at /metrics")
+		return
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET("/metrics", handle)
+	s.metricsServer = &http.Server{
+		Addr:         s.config.Metrics.Listen,
+		Handler:      router,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+}
+
+// streamingEnabled 主配置启用或 models 列表中存在实时模型时启用实时识别
+func (s *Server) streamingEnabled() bool {
+	return s.config.StreamingASR.Enabled || s.models.HasType(config.ModelTypeStreaming)
+}
+
+// recordings 实时会话录音存档，实时识别未加载或未启用录音时为 nil
+func (s *Server) recordings() *recording.Store {
+	mgr := s.streaming()
+	if mgr == nil {
+		return nil
+	}
+	return mgr.Recordings()
+}
+
+// offlineEnabled 主配置启用或 models 列表中存在离线模型时启用离线识别
+func (s *Server) offlineEnabled() bool {
+	return s.config.OfflineASR.Enabled || s.models.HasType(config.ModelTypeOffline)
+}
+
 func (s *Server) setupRoutes() {
+	// 关闭期间拒绝新的识别请求与实时连接
+	reject := handler.RejectWhenDraining(s.draining.Load)
+
+	// 存活与就绪探针（不需要签名，供容器编排使用）
+	s.router.GET("/livez", handler.HandleLivez)
+	s.router.GET("/readyz", handler.HandleReadyz(s.readinessChecks()))
+
 	// 创建 /realkws 路由组
 	realkws := s.router.Group("/realkws")
-	realkws.Use(handler.SignatureAuthMiddleware(s.config))
+	realkws.Use(handler.SignatureAuthMiddlewareWithSkew(s.config, s.maxSkewSeconds))
 	{
 		// 兼容旧测试页面入口，统一跳转到后台管理页
 		realkws.GET("/test", func(c *gin.Context) {
 			c.Redirect(http.StatusTemporaryRedirect, "/realkws/admin")
 		})
 
-		// 健康检查
-		realkws.GET("/health", handler.HealthCheck)
-		realkws.GET("/", handler.Index)
+		// 健康检查（关闭期间返回 503）
+		realkws.GET("/health", handler.HealthCheckWithDrain(s.draining.Load))
+		realkws.GET("/", handler.IndexWithCapabilities(s.models, s.capabilityStatus))
 
 		// API 路由组
 		api := realkws.Group("/api/v1")
 		{
 			// 实时语音识别 WebSocket
-			if s.config.StreamingASR.Enabled {
-				api.GET("/streaming/asr", func(c *gin.Context) {
-					handler.HandleStreamingASR(c, s.streamingASR)
-				})
+			if s.streamingEnabled() {
+				api.GET("/streaming/asr", s.with(reject, s.handleStreaming, capabilityStreaming)...)
 			}
 
 			// 离线语音识别
-			if s.config.OfflineASR.Enabled {
+			if s.offlineEnabled() {
 				// 异步模式：立即返回 taskId
-				api.POST("/offline/asr", func(c *gin.Context) {
-					handler.HandleOfflineASRAsync(c, s.offlineASR, nil, s.taskQueue)
-				})
+				api.POST("/offline/asr", s.with(reject, s.handleOffline, capabilityOffline)...)
 
 				// 查询异步任务状态/结果
-				api.GET("/offline/asr/task/:taskId", func(c *gin.Context) {
-					handler.HandleASRTaskQuery(c, s.taskQueue)
-				})
+				api.GET("/offline/asr/task/:taskId", s.with(nil, s.handleTaskQuery, capabilityOffline)...)
 
 				// 带说话者分离模式
 				if s.config.SpeakerDiarization.Enabled {
-					api.POST("/offline/asr/diarization", func(c *gin.Context) {
-						handler.HandleOfflineASRAsync(c, s.offlineASR, s.diarizationMgr, s.taskQueue)
-					})
+					api.POST("/offline/asr/diarization", s.with(reject, s.handleOfflineDiarization, capabilityOffline, capabilityDiarization)...)
 				}
 			}
 
 			// 说话者分离独立接口
 			if s.config.SpeakerDiarization.Enabled {
-				api.POST("/diarization", func(c *gin.Context) {
-					handler.HandleDiarization(c, s.diarizationMgr)
-				})
+				api.POST("/diarization", s.with(reject, func(c *gin.Context) {
+					handler.HandleDiarization(c, s.diarization())
+				}, capabilityDiarization)...)
 			}
 
 			// 统计信息
-			api.GET("/stats", func(c *gin.Context) {
-				handler.HandleStats(c, s.streamingASR, s.offlineASR)
-			})
+			api.GET("/stats", s.handleStats)
 		}
 
 		// 后台管理路由（需密码认证）
@@ -157,39 +273,49 @@ func (s *Server) setupRoutes() {
 			// 以下接口需要认证
 			adminAPI := admin.Group("/api", adminAuth)
 			{
-				adminAPI.GET("/stats", handler.HandleAdminStats(s.streamingASR, s.offlineASR, s.taskQueue))
-				adminAPI.GET("/tasks", handler.HandleAdminListTasks(s.taskQueue))
-				adminAPI.POST("/tasks/:taskId/cancel", handler.HandleAdminCancelTask(s.taskQueue))
-				adminAPI.GET("/sessions", handler.HandleAdminListSessions(s.streamingASR))
-				adminAPI.POST("/sessions/:sessionId/close", handler.HandleAdminCloseSession(s.streamingASR))
-				adminAPI.GET("/workers", handler.HandleAdminWorkers(s.taskQueue))
+				// 管理器可能在重试加载后才可用，每次请求时获取
+				adminAPI.GET("/stats", func(c *gin.Context) {
+					handler.HandleAdminStats(s.streaming(), s.offline(), s.queue())(c)
+				})
+				adminAPI.GET("/tasks", func(c *gin.Context) { handler.HandleAdminListTasks(s.queue())(c) })
+				adminAPI.POST("/tasks/:taskId/cancel", func(c *gin.Context) { handler.HandleAdminCancelTask(s.queue())(c) })
+				adminAPI.GET("/sessions", func(c *gin.Context) { handler.HandleAdminListSessions(s.streaming())(c) })
+				adminAPI.GET("/sessions/:sessionId", func(c *gin.Context) { handler.HandleAdminGetSession(s.streaming())(c) })
+				adminAPI.GET("/sessions/:sessionId/watch", func(c *gin.Context) { handler.HandleAdminWatchSession(s.streaming())(c) })
+				adminAPI.POST("/sessions/:sessionId/close", func(c *gin.Context) { handler.HandleAdminCloseSession(s.streaming())(c) })
+				adminAPI.GET("/recordings", func(c *gin.Context) { handler.HandleAdminListRecordings(s.recordings())(c) })
+				adminAPI.GET("/recordings/:id", func(c *gin.Context) { handler.HandleAdminDownloadRecording(s.recordings())(c) })
+				adminAPI.DELETE("/recordings/:id", func(c *gin.Context) { handler.HandleAdminDeleteRecording(s.recordings())(c) })
+				adminAPI.GET("/workers", func(c *gin.Context) { handler.HandleAdminWorkers(s.queue())(c) })
+				adminAPI.GET("/config", handler.HandleAdminGetConfig(s.runtimeSettings))
+				adminAPI.PATCH("/config", handler.HandleAdminPatchConfig(s.applyRuntime))
+				adminAPI.GET("/capabilities", handler.HandleAdminListCapabilities(s.capabilityStatus))
+				adminAPI.POST("/capabilities/:name/retry", handler.HandleAdminRetryCapability(s.retryCapability))
+				adminAPI.GET("/logging", handler.HandleAdminGetLogLevel())
+				adminAPI.PUT("/logging", handler.HandleAdminSetLogLevel())
+				adminAPI.GET("/models", handler.HandleAdminListModels(s.models))
+				adminAPI.POST("/models/reload", handler.HandleAdminReloadModels(s.models, s.reloadModelsAndSelfTest))
+				adminAPI.GET("/hotwords", handler.HandleAdminListHotwords(s.models.Hotwords()))
+				adminAPI.GET("/hotwords/:name", handler.HandleAdminGetHotwords(s.models.Hotwords()))
+				adminAPI.PUT("/hotwords/:name", handler.HandleAdminPutHotwords(s.models.Hotwords()))
+				adminAPI.DELETE("/hotwords/:name", handler.HandleAdminDeleteHotwords(s.models.Hotwords()))
 
 				// 测试能力接口（全部受 admin 鉴权保护）
 				adminAPI.GET("/health", handler.HealthCheck)
-				adminAPI.GET("/info", handler.Index)
-				adminAPI.GET("/capability/stats", func(c *gin.Context) {
-					handler.HandleStats(c, s.streamingASR, s.offlineASR)
-				})
+				adminAPI.GET("/info", handler.IndexWithCapabilities(s.models, s.capabilityStatus))
+				adminAPI.GET("/capability/stats", s.handleStats)
 
-				if s.config.StreamingASR.Enabled {
-					adminAPI.GET("/capability/streaming/asr", func(c *gin.Context) {
-						handler.HandleStreamingASR(c, s.streamingASR)
-					})
+				if s.streamingEnabled() {
+					adminAPI.GET("/capability/streaming/asr", s.with(reject, s.handleStreaming, capabilityStreaming)...)
 				}
 
-				if s.config.OfflineASR.Enabled {
-					adminAPI.POST("/capability/offline/asr", func(c *gin.Context) {
-						handler.HandleOfflineASRAsync(c, s.offlineASR, nil, s.taskQueue)
-					})
+				if s.offlineEnabled() {
+					adminAPI.POST("/capability/offline/asr", s.with(reject, s.handleOffline, capabilityOffline)...)
 
-					adminAPI.GET("/capability/offline/asr/task/:taskId", func(c *gin.Context) {
-						handler.HandleASRTaskQuery(c, s.taskQueue)
-					})
+					adminAPI.GET("/capability/offline/asr/task/:taskId", s.with(nil, s.handleTaskQuery, capabilityOffline)...)
 
 					if s.config.SpeakerDiarization.Enabled {
-						adminAPI.POST("/capability/offline/asr/diarization", func(c *gin.Context) {
-							handler.HandleOfflineASRAsync(c, s.offlineASR, s.diarizationMgr, s.taskQueue)
-						})
+						adminAPI.POST("/capability/offline/asr/diarization", s.with(reject, s.handleOfflineDiarization, capabilityOffline, capabilityDiarization)...)
 					}
 				}
 			}
@@ -197,6 +323,38 @@ func (s *Server) setupRoutes() {
 	}
 }
 
+// with 组合识别接口的处理链：关闭期间拒绝（reject 不为 nil 时）、所需能力不可用时返回 503
+func (s *Server) with(reject gin.HandlerFunc, h gin.HandlerFunc, capabilities ...string) []gin.HandlerFunc {
+	var chain []gin.HandlerFunc
+	if reject != nil {
+		chain = append(chain, reject)
+	}
+	for _, name := range capabilities {
+		chain = append(chain, s.require(name)...)
+	}
+	return append(chain, h)
+}
+
+func (s *Server) handleStreaming(c *gin.Context) {
+	handler.HandleStreamingASR(c, s.streaming())
+}
+
+func (s *Server) handleOffline(c *gin.Context) {
+	handler.HandleOfflineASRAsync(c, s.offline(), nil, s.queue())
+}
+
+func (s *Server) handleTaskQuery(c *gin.Context) {
+	handler.HandleASRTaskQuery(c, s.queue())
+}
+
+func (s *Server) handleOfflineDiarization(c *gin.Context) {
+	handler.HandleOfflineASRAsync(c, s.offline(), s.diarization(), s.queue())
+}
+
+func (s *Server) handleStats(c *gin.Context) {
+	handler.HandleStats(c, s.streaming(), s.offline())
+}
+
 func (s *Server) Start() error {
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
 
@@ -219,31 +377,180 @@ func (s *Server) Start() error {
 		WriteTimeout: writeTimeout,
 	}
 
-	// 启动 HTTP 服务器
+	// 启动 HTTP 服务器，端口绑定失败时释放模型后返回错误
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		s.StopContext(context.Background())
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	serveErr := make(chan error, 1)
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
-		log.Printf("Server listening on %s", addr)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+		slog.Info("Server listening", "addr", addr)
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
 		}
 	}()
 
-	// 等待关闭信号
-	<-s.shutdown
+	if s.metricsServer != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			slog.Info("Metrics listening", "addr", s.metricsServer.Addr)
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("Metrics server error", "error", err)
+			}
+		}()
+	}
+
+	// SIGHUP 触发模型热加载
+	s.wg.Add(1)
+	go s.watchReloadSignal()
 
-	return s.Stop()
+	// 定期重试加载失败的识别能力
+	s.wg.Add(1)
+	go s.retryLoop()
+
+	// 等待 SIGTERM/SIGINT，在 server.shutdown_timeout 内排空连接与任务，再次收到信号时立即结束排空
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sigCh)
+
+	// HTTP 服务异常退出时同样排空后关闭，并返回错误
+	var serveFailure error
+	select {
+	case sig := <-sigCh:
+		slog.Info("Received signal, draining", "signal", sig.String(), "timeout", s.shutdownTimeout().String())
+	case serveFailure = <-serveErr:
+		slog.Error("Server error, draining", "error", serveFailure, "timeout", s.shutdownTimeout().String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout())
+	defer cancel()
+	go func() {
+		select {
+		case sig := <-sigCh:
+			slog.Warn("Received signal again, aborting drain", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := s.StopContext(ctx); err != nil {
+		return err
+	}
+	if serveFailure != nil {
+		return fmt.Errorf("server error: %w", serveFailure)
+	}
+	return nil
 }
 
-func (s *Server) Stop() error {
-	log.Println("Shutting down server...")
+// shutdownTimeout 关闭时等待连接与任务结束的时间
+func (s *Server) shutdownTimeout() time.Duration {
+	if s.config.Server.ShutdownTimeout > 0 {
+		return time.Duration(s.config.Server.ShutdownTimeout) * time.Second
+	}
+	return defaultShutdownTimeout
+}
+
+// reloadModels 重新读取配置文件并热加载匹配的模型，modelType/name 为空时匹配全部
+func (s *Server) reloadModels(modelType, name string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	// 保留管理接口修改的运行时配置
+	s.mu.RLock()
+	cfg.ApplyRuntime(s.runtime)
+	s.mu.RUnlock()
+	return s.models.Reload(cfg, modelType, name)
+}
+
+// reloadModelsAndSelfTest 热加载模型后重新自检（管理接口与 SIGHUP），
+// 部分模型加载失败时默认模型仍可能已替换，因此无论成败都重新自检
+func (s *Server) reloadModelsAndSelfTest(modelType, name string) error {
+	err := s.reloadModels(modelType, name)
+	s.refreshSelfTest()
+	return err
+}
+
+// watchReloadSignal 收到 SIGHUP 时热加载全部模型
+func (s *Server) watchReloadSignal() {
+	defer s.wg.Done()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-sigCh:
+			slog.Info("Received SIGHUP, reloading models...")
+			if err := s.reloadModelsAndSelfTest("", ""); err != nil {
+				slog.Error("Model reload failed", "error", err)
+			} else {
+				slog.Info("Models reloaded")
+			}
+		case <-s.shutdown:
+			return
+		}
+	}
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// Stop 按 server.shutdown_timeout 优雅关闭服务器
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout())
 	defer cancel()
+	return s.StopContext(ctx)
+}
+
+// StopContext 优雅关闭服务器：先标记为关闭中（健康检查返回 503，拒绝新的识别请求与实时连接），
+// 以关闭码 1001 断开实时连接，同时等待排队与处理中的离线任务完成。ctx 结束时仍未完成的任务被中止并
+// 记录日志（配置 server.unfinished_tasks_dir 时保存音频与参数），之后关闭 HTTP 服务并释放模型
+func (s *Server) StopContext(ctx context.Context) error {
+	slog.Info("Shutting down server...")
+	s.draining.Store(true)
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+
+	// 等待进行中的重试加载与自检结束，之后不再加载或自检
+	s.stopLoading()
+	s.selfTestMu.Lock()
+	s.selfTestMu.Unlock()
+
+	// 实时会话与离线任务同时排空，共用同一个截止时间，关闭慢的实时客户端不占用离线任务的时间
+	var drain sync.WaitGroup
+	if s.streamingASR != nil {
+		drain.Add(1)
+		go func() {
+			defer drain.Done()
+			if remaining := s.streamingASR.Shutdown(ctx); remaining > 0 {
+				slog.Warn("Streaming sessions still open at shutdown deadline", "sessions", remaining)
+			}
+		}()
+	}
+	if s.taskQueue != nil {
+		drain.Add(1)
+		go func() {
+			defer drain.Done()
+			if unfinished := s.taskQueue.Drain(ctx, s.config.Server.UnfinishedTasksDir); len(unfinished) > 0 {
+				slog.Warn("Offline tasks aborted by shutdown", "tasks", len(unfinished))
+			}
+		}()
+	}
+	drain.Wait()
 
-	// 关闭 HTTP 服务器
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+	// 排空后剩余的同步请求已收到结果，HTTP 服务只需短暂等待响应写完
+	httpCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.httpServer.Shutdown(httpCtx); err != nil {
+		slog.Error("Server shutdown error", "error", err)
+	}
+	if s.metricsServer != nil {
+		s.metricsServer.Shutdown(httpCtx)
 	}
 
 	// 关闭任务队列
@@ -264,8 +571,11 @@ func (s *Server) Stop() error {
 		s.diarizationMgr.Close()
 	}
 
+	// 释放模型（需在管理器关闭之后）
+	s.models.Close()
+
 	s.wg.Wait()
-	log.Println("Server stopped")
+	slog.Info("Server stopped")
 
 	return nil
 }
@@ -1,19 +1,27 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"airecorder/internal/config"
+	"airecorder/internal/logging"
 	"airecorder/internal/server"
+	"airecorder/internal/tracing"
 	"airecorder/internal/version"
 )
 
 var (
 	showVersion = flag.Bool("version", false, "显示版本信息")
 	showV       = flag.Bool("v", false, "显示版本信息（简短）")
+	configFile  = flag.String("config", "", "配置文件路径，默认读取环境变量 CONFIG_PATH，均未设置时为 config.yaml")
+	checkConfig = flag.Bool("check-config", false, "校验配置并输出生效的配置（隐藏密钥），有错误时以非零状态退出")
 )
 
 func main() {
@@ -31,6 +39,20 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 模型热加载时重新读取同一配置文件
+	if *configFile != "" {
+		os.Setenv("CONFIG_PATH", *configFile)
+	}
+
+	if *checkConfig {
+		os.Exit(runCheckConfig())
+	}
+
+	os.Exit(run())
+}
+
+// run 启动服务并阻塞到服务关闭，返回退出码。日志与链路追踪在返回前关闭，os.Exit 不会跳过清理
+func run() int {
 	// 打印启动信息
 	log.Printf("AI Recorder %s starting...", version.Short())
 
@@ -39,13 +61,77 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%s", config.FormatProblems(err))
+	}
+	// 模型文件缺失时对应能力以降级模式启动
+	if err := cfg.CheckFiles(); err != nil {
+		log.Printf("Warning: missing model files:\n%s", config.FormatProblems(err))
+	}
+
+	// 初始化日志（之后的日志均为 JSON 格式）
+	logCloser, err := logging.Setup(cfg.Logging)
+	if err != nil {
+		slog.Warn("Logging setup failed, using defaults", "error", err)
+	}
+	defer logCloser.Close()
+
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		slog.Warn("Tracing disabled", "error", err)
+	}
 
 	// 初始化服务器
 	srv := server.NewServer(cfg)
 
 	// 启动服务器
-	log.Printf("Starting server on %s:%d", cfg.Server.Host, cfg.Server.Port)
+	slog.Info("Starting server", "host", cfg.Server.Host, "port", cfg.Server.Port)
+	exitCode := 0
 	if err := srv.Start(); err != nil {
-		log.Fatalf("Server failed: %v", err)
+		slog.Error("Server failed", "error", err)
+		exitCode = 1
+	}
+
+	// 导出尚未发送的 span
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+	return exitCode
+}
+
+// runCheckConfig 输出合并环境变量后的生效配置（隐藏密钥），并校验取值与模型文件，返回退出码
+func runCheckConfig() int {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	out, err := cfg.MarshalRedacted()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print config: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(out)
+
+	code := 0
+	if unknown := cfg.UnknownEnv(); len(unknown) > 0 {
+		fmt.Fprintf(os.Stderr, "Unknown environment overrides (no matching config field):\n  - %s\n", strings.Join(unknown, "\n  - "))
+		code = 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config:\n%s\n", config.FormatProblems(err))
+		code = 1
+	}
+	if err := cfg.CheckFiles(); err != nil {
+		fmt.Fprintf(os.Stderr, "Missing model files:\n%s\n", config.FormatProblems(err))
+		code = 1
+	}
+	if code == 0 {
+		fmt.Fprintln(os.Stderr, "Config OK")
 	}
+	return code
 }
@@ -242,7 +242,7 @@ services:
       - TZ=Asia/Shanghai
       - LD_LIBRARY_PATH=/usr/local/lib
     healthcheck:
-      test: ["CMD", "wget", "--no-verbose", "--tries=1", "--method=GET", "-O", "/dev/null", "http://localhost:11123/realkws/health"]
+      test: ["CMD", "wget", "--no-verbose", "--tries=1", "--method=GET", "-O", "/dev/null", "http://localhost:11123/readyz"]
       interval: 30s
       timeout: 10s
       retries: 3
@@ -307,7 +307,7 @@ services:
       - CONFIG_PATH=/app/config.yaml
       - TZ=Asia/Shanghai
     healthcheck:
-      test: ["CMD", "wget", "--no-verbose", "--tries=1", "--method=GET", "-O", "/dev/null", "http://localhost:11123/realkws/health"]
+      test: ["CMD", "wget", "--no-verbose", "--tries=1", "--method=GET", "-O", "/dev/null", "http://localhost:11123/readyz"]
       interval: 30s
       timeout: 10s
       retries: 3